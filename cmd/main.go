@@ -14,9 +14,17 @@ import (
 
 	"github.com/shinespb/pumba/pkg/chaos"
 	"github.com/shinespb/pumba/pkg/chaos/docker/cmd"
+	"github.com/shinespb/pumba/pkg/chaos/iptables"
+	iptablesCmd "github.com/shinespb/pumba/pkg/chaos/iptables/cmd"
 	netemCmd "github.com/shinespb/pumba/pkg/chaos/netem/cmd"
+	partitionCmd "github.com/shinespb/pumba/pkg/chaos/partition/cmd"
+	scenarioCmd "github.com/shinespb/pumba/pkg/chaos/scenario/cmd"
+	serviceCmd "github.com/shinespb/pumba/pkg/chaos/service/cmd"
+	stressCmd "github.com/shinespb/pumba/pkg/chaos/stress/cmd"
 	"github.com/shinespb/pumba/pkg/container"
 	"github.com/shinespb/pumba/pkg/logger"
+	"github.com/shinespb/pumba/pkg/metrics"
+	serverCmd "github.com/shinespb/pumba/pkg/server/cmd"
 
 	log "github.com/sirupsen/logrus"
 
@@ -49,8 +57,8 @@ var (
 const (
 	// Re2Prefix re2 regexp string prefix
 	Re2Prefix = "re2:"
-	// DefaultInterface default network interface
-	DefaultInterface = "eth0"
+	// default Docker daemon socket
+	defaultDockerHost = "unix:///var/run/docker.sock"
 )
 
 func contains(slice []string, item string) bool {
@@ -97,12 +105,32 @@ func main() {
 	app.Before = before
 	app.Commands = initializeCLICommands()
 	app.Flags = []cli.Flag{
-		cli.StringFlag{
+		cli.StringSliceFlag{
 			Name:   "host, H",
-			Usage:  "daemon socket to connect to",
-			Value:  "unix:///var/run/docker.sock",
+			Usage:  "daemon socket to connect to: unix://, tcp:// or ssh://[user@]host[:port] (default: \"unix:///var/run/docker.sock\"); can be repeated to run chaos across several daemons",
 			EnvVar: "DOCKER_HOST",
 		},
+		cli.StringFlag{
+			Name:  "hosts-file",
+			Usage: "file with daemon sockets to connect to, one per line; added to --host daemons",
+		},
+		cli.StringFlag{
+			Name:   "runtime",
+			Usage:  "container runtime: docker, podman or containerd; containerd runtime uses 'ctr' and 'nsenter' tools on the host",
+			Value:  "docker",
+			EnvVar: "PUMBA_RUNTIME",
+		},
+		cli.StringFlag{
+			Name:  "containerd-address",
+			Usage: "containerd socket to connect to (containerd runtime)",
+			Value: container.DefaultContainerdAddress,
+		},
+		cli.StringFlag{
+			Name:   "containerd-namespace",
+			Usage:  "containerd namespace of target containers (containerd runtime); Kubernetes uses 'k8s.io'",
+			Value:  container.DefaultContainerdNamespace,
+			EnvVar: "CONTAINERD_NAMESPACE",
+		},
 		cli.BoolFlag{
 			Name:  "tls",
 			Usage: "use TLS; implied by --tlsverify",
@@ -155,6 +183,38 @@ func main() {
 			Name:  "random, r",
 			Usage: "randomly select single matching container from list of target containers",
 		},
+		cli.StringSliceFlag{
+			Name:  "label",
+			Usage: "filter target containers by label: 'key=value', 'key!=value' or 'key' (label exists); can be repeated, all labels must match",
+		},
+		cli.StringFlag{
+			Name:  "compose-project",
+			Usage: "filter target containers by Docker Compose project name",
+		},
+		cli.StringFlag{
+			Name:  "compose-service",
+			Usage: "filter target containers by Docker Compose service name",
+		},
+		cli.StringFlag{
+			Name:  "service",
+			Usage: "filter target containers by Docker Swarm service name: containers of service tasks; default target of 'service' command",
+		},
+		cli.BoolFlag{
+			Name:  "per-service",
+			Usage: "apply command limit to every Docker Compose or Swarm service; example: 'kill --limit 1' kills one replica of each service",
+		},
+		cli.StringFlag{
+			Name:  "metrics-addr",
+			Usage: "serve Prometheus metrics on '/metrics' path of this address; example: ':9090'",
+		},
+		cli.StringFlag{
+			Name:  "journal",
+			Usage: "journal file of applied faults; faults left by killed pumba are undone with 'recover' command (or on startup with --recover-on-start); journal is locked by single pumba instance",
+		},
+		cli.BoolFlag{
+			Name:  "recover-on-start",
+			Usage: "undo faults, recorded in journal by killed pumba, before running chaos command",
+		},
 		cli.BoolFlag{
 			Name:   "dry-run",
 			Usage:  "dry run does not create chaos, only logs planned chaos commands",
@@ -203,16 +263,81 @@ func before(c *cli.Context) error {
 	traceHook := logger.NewHook()
 	traceHook.AppName = "pumba"
 	log.AddHook(traceHook)
+	// serve Prometheus metrics
+	if addr := c.GlobalString("metrics-addr"); addr != "" {
+		if err := metrics.Serve(addr); err != nil {
+			return err
+		}
+	}
 	// Set-up container client
-	tls, err := tlsConfig(c)
-	if err != nil {
-		return err
+	hosts := c.GlobalStringSlice("host")
+	if path := c.GlobalString("hosts-file"); path != "" {
+		fileHosts, err := container.ReadHostsFile(path)
+		if err != nil {
+			return err
+		}
+		hosts = append(hosts, fileHosts...)
+	}
+	switch runtime := c.GlobalString("runtime"); runtime {
+	case "docker":
+		tls, err := tlsConfig(c)
+		if err != nil {
+			return err
+		}
+		if len(hosts) == 0 {
+			hosts = []string{defaultDockerHost}
+		}
+		// create new Docker client
+		chaos.DockerClient = newClient(hosts, func(host string) container.Client {
+			return container.NewClient(host, tls)
+		})
+	case "podman":
+		tls, err := tlsConfig(c)
+		if err != nil {
+			return err
+		}
+		// Podman API service socket, unless set explicitly
+		if len(hosts) == 0 {
+			hosts = []string{container.PodmanSocket()}
+		}
+		chaos.DockerClient = newClient(hosts, func(host string) container.Client {
+			return container.NewPodmanClient(host, tls)
+		})
+	case "containerd":
+		chaos.DockerClient = container.NewContainerdClient(c.GlobalString("containerd-address"), c.GlobalString("containerd-namespace"))
+	default:
+		return fmt.Errorf("unsupported container runtime: %s", runtime)
+	}
+	// open journal and undo (if requested) or report faults left by previous run; 'recover' command does it explicitly
+	if path := c.GlobalString("journal"); path != "" {
+		if err := container.OpenJournal(path); err != nil {
+			return err
+		}
+		if c.Args().First() != "recover" {
+			if c.GlobalBool("recover-on-start") {
+				if err := container.Recover(topContext, chaos.DockerClient, c.GlobalBool("dry-run")); err != nil {
+					log.WithError(err).Error("failed to recover faults left by previous run")
+				}
+			} else {
+				container.WarnPendingFaults()
+			}
+		}
 	}
-	// create new Docker client
-	chaos.DockerClient = container.NewClient(c.GlobalString("host"), tls)
 	return nil
 }
 
+// client of single daemon or fan-out client of several daemons
+func newClient(hosts []string, newHostClient func(string) container.Client) container.Client {
+	if len(hosts) == 1 {
+		return newHostClient(hosts[0])
+	}
+	clients := make(map[string]container.Client, len(hosts))
+	for _, host := range hosts {
+		clients[host] = newHostClient(host)
+	}
+	return container.NewMultiHostClient(clients)
+}
+
 func handleSignals() context.Context {
 	// Graceful shut-down on SIGINT/SIGTERM
 	sig := make(chan os.Signal, 1)
@@ -287,6 +412,7 @@ func initializeCLICommands() []cli.Command {
 		*cmd.NewStopCLICommand(topContext),
 		*cmd.NewPauseCLICommand(topContext),
 		*cmd.NewRemoveCLICommand(topContext),
+		*stressCmd.NewStressCLICommand(topContext),
 		{
 			Name: "netem",
 			Flags: []cli.Flag{
@@ -296,16 +422,42 @@ func initializeCLICommands() []cli.Command {
 				},
 				cli.StringFlag{
 					Name:  "interface, i",
-					Usage: "network interface to apply delay on",
-					Value: DefaultInterface,
+					Usage: "network interface to apply delay on; 'auto': resolve interface inside container, carrying traffic to target IPs (or default route)",
+					Value: chaos.DefaultInterface,
+				},
+				cli.StringFlag{
+					Name:  "network",
+					Usage: "Docker network name; apply netem on container interface, connected to this network (implies '--interface auto')",
+				},
+				cli.StringFlag{
+					Name:  "direction",
+					Usage: "traffic direction to apply netem on: egress, ingress or both; ingress requires 'ifb' kernel module on Docker host",
+					Value: container.DirectionEgress,
 				},
 				cli.StringSliceFlag{
 					Name:  "target, t",
-					Usage: "target IP filter; supports multiple IPs; supports CIDR notation",
+					Usage: "target IP filter; supports multiple IPs; supports CIDR notation; supports IPv4 and IPv6 addresses; target container IPs: 'container:<name>' or 're2:<pattern>'",
+				},
+				cli.StringSliceFlag{
+					Name:  "source",
+					Usage: "source IP filter; supports multiple IPs; supports CIDR notation; supports IPv4 and IPv6 addresses",
 				},
 				cli.StringFlag{
 					Name:  "port, p",
-					Usage: "target port filter",
+					Usage: "target port filter; comma separated list of ports and port ranges (e.g. 80,8000-8100)",
+				},
+				cli.StringFlag{
+					Name:  "src-port",
+					Usage: "source port filter; comma separated list of ports and port ranges (e.g. 80,8000-8100)",
+				},
+				cli.StringFlag{
+					Name:  "protocol",
+					Usage: "L4 protocol filter: tcp, udp or icmp",
+				},
+				cli.IntFlag{
+					Name:  "limit, l",
+					Usage: "limit number of target containers (0: all matching)",
+					Value: 0,
 				},
 				cli.StringFlag{
 					Name:  "tc-image",
@@ -327,7 +479,81 @@ func initializeCLICommands() []cli.Command {
 				*netemCmd.NewRateCLICommand(topContext),
 				*netemCmd.NewDuplicateCLICommand(topContext),
 				*netemCmd.NewCorruptCLICommand(topContext),
+				*netemCmd.NewReorderCLICommand(topContext),
+				*netemCmd.NewSlotCLICommand(topContext),
+				*netemCmd.NewReplayCLICommand(topContext),
+				*netemCmd.NewProfileCLICommand(topContext),
+			},
+		},
+		{
+			Name: "iptables",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "duration, d",
+					Usage: "iptables rules duration; should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+				},
+				cli.StringFlag{
+					Name:  "direction",
+					Usage: "traffic direction to apply iptables rules on: ingress (INPUT chain), egress (OUTPUT chain) or both",
+					Value: container.DirectionIngress,
+				},
+				cli.StringFlag{
+					Name:  "protocol, p",
+					Usage: "protocol filter: all, tcp, udp or icmp",
+					Value: iptables.DefaultProtocol,
+				},
+				cli.StringSliceFlag{
+					Name:  "source, s",
+					Usage: "source IP filter; supports multiple IPs; supports CIDR notation; IPv4 addresses only",
+				},
+				cli.StringSliceFlag{
+					Name:  "destination",
+					Usage: "destination IP filter; supports multiple IPs; supports CIDR notation; IPv4 addresses only",
+				},
+				cli.StringFlag{
+					Name:  "src-port",
+					Usage: "source port filter; requires tcp or udp protocol",
+				},
+				cli.StringFlag{
+					Name:  "dst-port",
+					Usage: "destination port filter; requires tcp or udp protocol",
+				},
+				cli.IntFlag{
+					Name:  "limit, l",
+					Usage: "limit number of target containers (0: all matching)",
+					Value: 0,
+				},
+				cli.StringFlag{
+					Name:  "iptables-image",
+					Usage: "Docker image with iptables tool as entrypoint",
+				},
+				cli.BoolTFlag{
+					Name:  "pull-image",
+					Usage: "try to pull iptables-image",
+				},
 			},
+			Usage:       "drop or reject network packets with iptables",
+			ArgsUsage:   fmt.Sprintf("containers (name, list of names, or RE2 regex if prefixed with %q", Re2Prefix),
+			Description: "drop or reject packets, matching iptables filter, to emulate network partitions; inserted rules are removed on timeout or abort",
+			Subcommands: []cli.Command{
+				*iptablesCmd.NewDropCLICommand(topContext),
+				*iptablesCmd.NewRejectCLICommand(topContext),
+			},
+		},
+		*partitionCmd.NewPartitionCLICommand(topContext),
+		*serviceCmd.NewServiceCLICommand(topContext),
+		*scenarioCmd.NewRunCLICommand(topContext),
+		*serverCmd.NewServerCLICommand(topContext),
+		{
+			Name:        "recover",
+			Usage:       "undo faults left by killed pumba",
+			Description: "replay journal (set with global 'journal' option): stop netem, delete iptables rules, unpause and restart containers, scale services back",
+			Action:      recoverJournal,
 		},
 	}
 }
+
+// RECOVER Command
+func recoverJournal(c *cli.Context) error {
+	return container.Recover(topContext, chaos.DockerClient, c.GlobalBool("dry-run"))
+}
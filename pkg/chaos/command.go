@@ -2,10 +2,13 @@ package chaos
 
 import (
 	"context"
+	"path"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/metrics"
 	"github.com/shinespb/pumba/pkg/util"
 
 	log "github.com/sirupsen/logrus"
@@ -15,6 +18,8 @@ import (
 const (
 	// Re2Prefix re2 regexp string prefix
 	Re2Prefix = "re2:"
+	// DefaultInterface default network interface
+	DefaultInterface = "eth0"
 )
 
 var (
@@ -51,6 +56,17 @@ func GetNamesOrPattern(c *cli.Context) ([]string, string) {
 	return names, pattern
 }
 
+// GetLabels get label selectors from global `label`, `compose-project`, `compose-service` and `service` flags
+func GetLabels(c *cli.Context) []string {
+	labels := append([]string{}, c.GlobalStringSlice("label")...)
+	labels = append(labels, container.ComposeLabels(c.GlobalString("compose-project"), c.GlobalString("compose-service"))...)
+	labels = append(labels, container.SwarmServiceLabels(c.GlobalString("service"))...)
+	if len(labels) > 0 {
+		log.WithField("labels", labels).Debug("using labels")
+	}
+	return labels
+}
+
 // RunChaosCommand run chaos command in go routine
 func RunChaosCommand(topContext context.Context, command Command, intervalStr string, random bool) error {
 	// parse interval
@@ -73,9 +89,10 @@ func RunChaosCommand(topContext context.Context, command Command, intervalStr st
 	// cancel current context on exit
 	defer cancel()
 	// run chaos command
+	name := commandName(command)
 	for {
 		// run chaos function
-		if err := command.Run(ctx, random); err != nil {
+		if err := runCommand(ctx, name, command, random); err != nil {
 			log.WithError(err).Error("failed to run chaos command")
 			return err
 		}
@@ -91,3 +108,31 @@ func RunChaosCommand(topContext context.Context, command Command, intervalStr st
 		}
 	}
 }
+
+// run chaos command once and record metrics
+func runCommand(ctx context.Context, name string, command Command, random bool) error {
+	start := time.Now()
+	metrics.LastTick.Set(float64(start.Unix()))
+	err := command.Run(ctx, random)
+	metrics.ChaosActionDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+	outcome := metrics.OutcomeSuccess
+	if err != nil {
+		outcome = metrics.OutcomeFailure
+	}
+	metrics.ChaosActions.WithLabelValues(name, outcome).Inc()
+	return err
+}
+
+// get chaos command name from its type: '*netem.DelayCommand' -> 'netem delay', '*docker.KillCommand' -> 'kill'
+func commandName(command Command) string {
+	t := reflect.TypeOf(command)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	name := strings.ToLower(strings.TrimSuffix(t.Name(), "Command"))
+	pkg := path.Base(t.PkgPath())
+	if pkg == "docker" || pkg == name {
+		return name
+	}
+	return pkg + " " + name
+}
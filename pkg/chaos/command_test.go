@@ -0,0 +1,32 @@
+package chaos_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/docker"
+	"github.com/shinespb/pumba/pkg/chaos/iptables"
+	"github.com/shinespb/pumba/pkg/chaos/netem"
+	"github.com/shinespb/pumba/pkg/chaos/stress"
+)
+
+func TestCommandName(t *testing.T) {
+	tests := []struct {
+		command chaos.Command
+		want    string
+	}{
+		{command: &docker.KillCommand{}, want: "kill"},
+		{command: &docker.PauseCommand{}, want: "pause"},
+		{command: &netem.DelayCommand{}, want: "netem delay"},
+		{command: &stress.StressCommand{}, want: "stress"},
+		{command: &iptables.DropCommand{}, want: "iptables drop"},
+		{command: &iptables.RejectCommand{}, want: "iptables reject"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, chaos.CommandName(tt.command))
+		})
+	}
+}
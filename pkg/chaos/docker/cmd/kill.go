@@ -48,12 +48,16 @@ func (cmd *killContext) kill(c *cli.Context) error {
 	interval := c.GlobalString("interval")
 	// get names or pattern
 	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
 	// get signal
 	signal := c.String("signal")
 	// get limit for number of containers to kill
 	limit := c.Int("limit")
 	// init kill command
-	killCommand, err := docker.NewKillCommand(chaos.DockerClient, names, pattern, signal, limit, dryRun)
+	killCommand, err := docker.NewKillCommand(chaos.DockerClient, names, pattern, labels, signal, limit, perService, dryRun)
 	if err != nil {
 		return err
 	}
@@ -49,10 +49,14 @@ func (cmd *pauseContext) pause(c *cli.Context) error {
 	limit := c.Int("limit")
 	// get names or pattern
 	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
 	// get chaos command duration
 	duration := c.String("duration")
 	// init pause command
-	pauseCommand, err := docker.NewPauseCommand(chaos.DockerClient, names, pattern, interval, duration, limit, dryRun)
+	pauseCommand, err := docker.NewPauseCommand(chaos.DockerClient, names, pattern, labels, interval, duration, limit, perService, dryRun)
 	if err != nil {
 		return err
 	}
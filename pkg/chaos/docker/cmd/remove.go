@@ -55,6 +55,10 @@ func (cmd *removeContext) remove(c *cli.Context) error {
 	interval := c.GlobalString("interval")
 	// get names or pattern
 	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
 	// get force flag
 	force := c.BoolT("force")
 	// get links flag
@@ -64,7 +68,7 @@ func (cmd *removeContext) remove(c *cli.Context) error {
 	// get limit for number of containers to remove
 	limit := c.Int("limit")
 	// init remove command
-	removeCommand, err := docker.NewRemoveCommand(chaos.DockerClient, names, pattern, force, links, volumes, limit, dryRun)
+	removeCommand, err := docker.NewRemoveCommand(chaos.DockerClient, names, pattern, labels, force, links, volumes, limit, perService, dryRun)
 	if err != nil {
 		return err
 	}
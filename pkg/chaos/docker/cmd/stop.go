@@ -37,7 +37,7 @@ func NewStopCLICommand(ctx context.Context) *cli.Command {
 			cli.StringFlag{
 				Name:  "duration, d",
 				Usage: "stop duration (works only with `restart` flag): must be shorter than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
-				Value: "10s",
+				Value: docker.DefaultStopDuration,
 			},
 		},
 		Usage:       "stop containers",
@@ -61,12 +61,16 @@ func (cmd *stopContext) stop(c *cli.Context) error {
 	limit := c.Int("limit")
 	// get names or pattern
 	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
 	// get restart flag
 	restart := c.Bool("restart")
 	// get chaos command duration
 	duration := c.String("duration")
 	// init stop command
-	stopCommand, err := docker.NewStopCommand(chaos.DockerClient, names, pattern, restart, interval, duration, waitTime, limit, dryRun)
+	stopCommand, err := docker.NewStopCommand(chaos.DockerClient, names, pattern, labels, restart, interval, duration, waitTime, limit, perService, dryRun)
 	if err != nil {
 		return err
 	}
@@ -51,17 +51,19 @@ var LinuxSignals = map[string]int{
 
 // KillCommand `docker kill` command
 type KillCommand struct {
-	client  container.Client
-	names   []string
-	pattern string
-	signal  string
-	limit   int
-	dryRun  bool
+	client     container.Client
+	names      []string
+	pattern    string
+	labels     []string
+	signal     string
+	limit      int
+	perService bool
+	dryRun     bool
 }
 
 // NewKillCommand create new Kill Command instance
-func NewKillCommand(client container.Client, names []string, pattern string, signal string, limit int, dryRun bool) (chaos.Command, error) {
-	kill := &KillCommand{client, names, pattern, signal, limit, dryRun}
+func NewKillCommand(client container.Client, names []string, pattern string, labels []string, signal string, limit int, perService bool, dryRun bool) (chaos.Command, error) {
+	kill := &KillCommand{client, names, pattern, labels, signal, limit, perService, dryRun}
 	if kill.signal == "" {
 		kill.signal = DefaultKillSignal
 	}
@@ -77,11 +79,13 @@ func NewKillCommand(client container.Client, names []string, pattern string, sig
 func (k *KillCommand) Run(ctx context.Context, random bool) error {
 	log.Debug("killing all matching containers")
 	log.WithFields(log.Fields{
-		"names":   k.names,
-		"pattern": k.pattern,
-		"limit":   k.limit,
+		"names":      k.names,
+		"pattern":    k.pattern,
+		"labels":     k.labels,
+		"limit":      k.limit,
+		"perService": k.perService,
 	}).Debug("listing matching containers")
-	containers, err := container.ListNContainers(ctx, k.client, k.names, k.pattern, k.limit)
+	containers, err := container.ListNContainers(ctx, k.client, k.names, k.pattern, k.labels, k.limit, k.perService)
 	if err != nil {
 		log.WithError(err).Error("failed to list containers")
 		return err
@@ -17,11 +17,13 @@ func TestKillCommand_Run(t *testing.T) {
 		killError bool
 	}
 	type fields struct {
-		names   []string
-		pattern string
-		signal  string
-		limit   int
-		dryRun  bool
+		names      []string
+		pattern    string
+		labels     []string
+		signal     string
+		limit      int
+		perService bool
+		dryRun     bool
 	}
 	type args struct {
 		ctx    context.Context
@@ -58,6 +60,30 @@ func TestKillCommand_Run(t *testing.T) {
 			},
 			expected: container.CreateTestContainers(3),
 		},
+		{
+			name: "kill matching containers by labels",
+			fields: fields{
+				labels: []string{"app=web", "tier!=db"},
+				signal: "SIGKILL",
+			},
+			args: args{
+				ctx: context.TODO(),
+			},
+			expected: container.CreateTestContainers(2),
+		},
+		{
+			name: "kill one matching container per service",
+			fields: fields{
+				labels:     []string{"com.docker.compose.project=test"},
+				signal:     "SIGKILL",
+				limit:      1,
+				perService: true,
+			},
+			args: args{
+				ctx: context.TODO(),
+			},
+			expected: container.CreateTestContainers(3),
+		},
 		{
 			name: "kill random matching container by names",
 			fields: fields{
@@ -110,12 +136,14 @@ func TestKillCommand_Run(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockClient := new(container.MockClient)
 			k := &KillCommand{
-				client:  mockClient,
-				names:   tt.fields.names,
-				pattern: tt.fields.pattern,
-				signal:  tt.fields.signal,
-				limit:   tt.fields.limit,
-				dryRun:  tt.fields.dryRun,
+				client:     mockClient,
+				names:      tt.fields.names,
+				pattern:    tt.fields.pattern,
+				labels:     tt.fields.labels,
+				signal:     tt.fields.signal,
+				limit:      tt.fields.limit,
+				perService: tt.fields.perService,
+				dryRun:     tt.fields.dryRun,
 			}
 			call := mockClient.On("ListContainers", tt.args.ctx, mock.AnythingOfType("container.Filter"))
 			if tt.errs.listError {
@@ -153,12 +181,14 @@ func TestKillCommand_Run(t *testing.T) {
 
 func TestNewKillCommand(t *testing.T) {
 	type args struct {
-		client  container.Client
-		names   []string
-		pattern string
-		signal  string
-		limit   int
-		dryRun  bool
+		client     container.Client
+		names      []string
+		pattern    string
+		labels     []string
+		signal     string
+		limit      int
+		perService bool
+		dryRun     bool
 	}
 	tests := []struct {
 		name    string
@@ -201,7 +231,7 @@ func TestNewKillCommand(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewKillCommand(tt.args.client, tt.args.names, tt.args.pattern, tt.args.signal, tt.args.limit, tt.args.dryRun)
+			got, err := NewKillCommand(tt.args.client, tt.args.names, tt.args.pattern, tt.args.labels, tt.args.signal, tt.args.limit, tt.args.perService, tt.args.dryRun)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewKillCommand() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -12,16 +12,18 @@ import (
 
 // PauseCommand `docker pause` command
 type PauseCommand struct {
-	client   container.Client
-	names    []string
-	pattern  string
-	duration time.Duration
-	limit    int
-	dryRun   bool
+	client     container.Client
+	names      []string
+	pattern    string
+	labels     []string
+	duration   time.Duration
+	limit      int
+	perService bool
+	dryRun     bool
 }
 
 // NewPauseCommand create new Pause Command instance
-func NewPauseCommand(client container.Client, names []string, pattern string, intervalStr string, durationStr string, limit int, dryRun bool) (chaos.Command, error) {
+func NewPauseCommand(client container.Client, names []string, pattern string, labels []string, intervalStr string, durationStr string, limit int, perService bool, dryRun bool) (chaos.Command, error) {
 	// get interval
 	interval, err := util.GetIntervalValue(intervalStr)
 	if err != nil {
@@ -32,19 +34,21 @@ func NewPauseCommand(client container.Client, names []string, pattern string, in
 	if err != nil {
 		return nil, err
 	}
-	return &PauseCommand{client, names, pattern, duration, limit, dryRun}, nil
+	return &PauseCommand{client, names, pattern, labels, duration, limit, perService, dryRun}, nil
 }
 
 // Run pause command
 func (p *PauseCommand) Run(ctx context.Context, random bool) error {
 	log.Debug("pausing all matching containers")
 	log.WithFields(log.Fields{
-		"names":    p.names,
-		"pattern":  p.pattern,
-		"duration": p.duration,
-		"limit":    p.limit,
+		"names":      p.names,
+		"pattern":    p.pattern,
+		"labels":     p.labels,
+		"duration":   p.duration,
+		"limit":      p.limit,
+		"perService": p.perService,
 	}).Debug("listing matching containers")
-	containers, err := container.ListNContainers(ctx, p.client, p.names, p.pattern, p.limit)
+	containers, err := container.ListNContainers(ctx, p.client, p.names, p.pattern, p.labels, p.limit, p.perService)
 	if err != nil {
 		log.WithError(err).Error("failed to list containers")
 		return err
@@ -14,13 +14,15 @@ import (
 
 func TestNewPauseCommand(t *testing.T) {
 	type args struct {
-		client   container.Client
-		names    []string
-		pattern  string
-		interval string
-		duration string
-		limit    int
-		dryRun   bool
+		client     container.Client
+		names      []string
+		pattern    string
+		labels     []string
+		interval   string
+		duration   string
+		limit      int
+		perService bool
+		dryRun     bool
 	}
 	tests := []struct {
 		name    string
@@ -47,7 +49,7 @@ func TestNewPauseCommand(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewPauseCommand(tt.args.client, tt.args.names, tt.args.pattern, tt.args.interval, tt.args.duration, tt.args.limit, tt.args.dryRun)
+			got, err := NewPauseCommand(tt.args.client, tt.args.names, tt.args.pattern, tt.args.labels, tt.args.interval, tt.args.duration, tt.args.limit, tt.args.perService, tt.args.dryRun)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewPauseCommand() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -66,10 +68,12 @@ func TestPauseCommand_Run(t *testing.T) {
 		unpauseError bool
 	}
 	type fields struct {
-		names   []string
-		pattern string
-		limit   int
-		dryRun  bool
+		names      []string
+		pattern    string
+		labels     []string
+		limit      int
+		perService bool
+		dryRun     bool
 	}
 	type args struct {
 		ctx    context.Context
@@ -164,11 +168,13 @@ func TestPauseCommand_Run(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockClient := new(container.MockClient)
 			s := &PauseCommand{
-				client:  mockClient,
-				names:   tt.fields.names,
-				pattern: tt.fields.pattern,
-				limit:   tt.fields.limit,
-				dryRun:  tt.fields.dryRun,
+				client:     mockClient,
+				names:      tt.fields.names,
+				pattern:    tt.fields.pattern,
+				labels:     tt.fields.labels,
+				limit:      tt.fields.limit,
+				perService: tt.fields.perService,
+				dryRun:     tt.fields.dryRun,
 			}
 			call := mockClient.On("ListContainers", tt.args.ctx, mock.AnythingOfType("container.Filter"))
 			if tt.errs.listError {
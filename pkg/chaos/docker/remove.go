@@ -10,19 +10,21 @@ import (
 
 // RemoveCommand `docker kill` command
 type RemoveCommand struct {
-	client  container.Client
-	names   []string
-	pattern string
-	force   bool
-	links   bool
-	volumes bool
-	limit   int
-	dryRun  bool
+	client     container.Client
+	names      []string
+	pattern    string
+	labels     []string
+	force      bool
+	links      bool
+	volumes    bool
+	limit      int
+	perService bool
+	dryRun     bool
 }
 
 // NewRemoveCommand create new Kill Command instance
-func NewRemoveCommand(client container.Client, names []string, pattern string, force bool, links bool, volumes bool, limit int, dryRun bool) (chaos.Command, error) {
-	remove := &RemoveCommand{client, names, pattern, force, links, volumes, limit, dryRun}
+func NewRemoveCommand(client container.Client, names []string, pattern string, labels []string, force bool, links bool, volumes bool, limit int, perService bool, dryRun bool) (chaos.Command, error) {
+	remove := &RemoveCommand{client, names, pattern, labels, force, links, volumes, limit, perService, dryRun}
 	return remove, nil
 }
 
@@ -30,11 +32,13 @@ func NewRemoveCommand(client container.Client, names []string, pattern string, f
 func (r *RemoveCommand) Run(ctx context.Context, random bool) error {
 	log.Debug("removing all matching containers")
 	log.WithFields(log.Fields{
-		"names":   r.names,
-		"pattern": r.pattern,
-		"limit":   r.limit,
+		"names":      r.names,
+		"pattern":    r.pattern,
+		"labels":     r.labels,
+		"limit":      r.limit,
+		"perService": r.perService,
 	}).Debug("listing matching containers")
-	containers, err := container.ListNContainers(ctx, r.client, r.names, r.pattern, r.limit)
+	containers, err := container.ListNContainers(ctx, r.client, r.names, r.pattern, r.labels, r.limit, r.perService)
 	if err != nil {
 		log.WithError(err).Error("failed to list containers")
 		return err
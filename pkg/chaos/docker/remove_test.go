@@ -17,13 +17,15 @@ func TestRemoveCommand_Run(t *testing.T) {
 		removeError bool
 	}
 	type fields struct {
-		names   []string
-		pattern string
-		force   bool
-		links   bool
-		volumes bool
-		limit   int
-		dryRun  bool
+		names      []string
+		pattern    string
+		labels     []string
+		force      bool
+		links      bool
+		volumes    bool
+		limit      int
+		perService bool
+		dryRun     bool
 	}
 	type args struct {
 		ctx    context.Context
@@ -112,14 +114,16 @@ func TestRemoveCommand_Run(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockClient := new(container.MockClient)
 			k := &RemoveCommand{
-				client:  mockClient,
-				names:   tt.fields.names,
-				pattern: tt.fields.pattern,
-				force:   tt.fields.force,
-				links:   tt.fields.links,
-				volumes: tt.fields.volumes,
-				limit:   tt.fields.limit,
-				dryRun:  tt.fields.dryRun,
+				client:     mockClient,
+				names:      tt.fields.names,
+				pattern:    tt.fields.pattern,
+				labels:     tt.fields.labels,
+				force:      tt.fields.force,
+				links:      tt.fields.links,
+				volumes:    tt.fields.volumes,
+				limit:      tt.fields.limit,
+				perService: tt.fields.perService,
+				dryRun:     tt.fields.dryRun,
 			}
 			call := mockClient.On("ListContainers", tt.args.ctx, mock.AnythingOfType("container.Filter"))
 			if tt.errs.listError {
@@ -157,14 +161,16 @@ func TestRemoveCommand_Run(t *testing.T) {
 
 func TestNewRemoveCommand(t *testing.T) {
 	type args struct {
-		client  container.Client
-		names   []string
-		pattern string
-		force   bool
-		links   bool
-		volumes bool
-		limit   int
-		dryRun  bool
+		client     container.Client
+		names      []string
+		pattern    string
+		labels     []string
+		force      bool
+		links      bool
+		volumes    bool
+		limit      int
+		perService bool
+		dryRun     bool
 	}
 	tests := []struct {
 		name    string
@@ -192,7 +198,7 @@ func TestNewRemoveCommand(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewRemoveCommand(tt.args.client, tt.args.names, tt.args.pattern, tt.args.force, tt.args.links, tt.args.volumes, tt.args.limit, tt.args.dryRun)
+			got, err := NewRemoveCommand(tt.args.client, tt.args.names, tt.args.pattern, tt.args.labels, tt.args.force, tt.args.links, tt.args.volumes, tt.args.limit, tt.args.perService, tt.args.dryRun)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewRemoveCommand() error = %v, wantErr %v", err, tt.wantErr)
 				return
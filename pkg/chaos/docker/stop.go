@@ -13,22 +13,26 @@ import (
 const (
 	// DeafultWaitTime time to wait before stopping container (in seconds)
 	DeafultWaitTime = 5
+	// DefaultStopDuration time to keep container stopped before restart
+	DefaultStopDuration = "10s"
 )
 
 // StopCommand `docker stop` command
 type StopCommand struct {
-	client   container.Client
-	names    []string
-	pattern  string
-	restart  bool
-	duration time.Duration
-	waitTime int
-	limit    int
-	dryRun   bool
+	client     container.Client
+	names      []string
+	pattern    string
+	labels     []string
+	restart    bool
+	duration   time.Duration
+	waitTime   int
+	limit      int
+	perService bool
+	dryRun     bool
 }
 
 // NewStopCommand create new Stop Command instance
-func NewStopCommand(client container.Client, names []string, pattern string, restart bool, intervalStr string, durationStr string, waitTime int, limit int, dryRun bool) (chaos.Command, error) {
+func NewStopCommand(client container.Client, names []string, pattern string, labels []string, restart bool, intervalStr string, durationStr string, waitTime int, limit int, perService bool, dryRun bool) (chaos.Command, error) {
 	if waitTime <= 0 {
 		waitTime = DeafultWaitTime
 	}
@@ -42,20 +46,22 @@ func NewStopCommand(client container.Client, names []string, pattern string, res
 	if err != nil {
 		return nil, err
 	}
-	return &StopCommand{client, names, pattern, restart, duration, waitTime, limit, dryRun}, nil
+	return &StopCommand{client, names, pattern, labels, restart, duration, waitTime, limit, perService, dryRun}, nil
 }
 
 // Run stop command
 func (s *StopCommand) Run(ctx context.Context, random bool) error {
 	log.Debug("stopping all matching containers")
 	log.WithFields(log.Fields{
-		"names":    s.names,
-		"pattern":  s.pattern,
-		"duration": s.duration,
-		"waitTime": s.waitTime,
-		"limit":    s.limit,
+		"names":      s.names,
+		"pattern":    s.pattern,
+		"labels":     s.labels,
+		"duration":   s.duration,
+		"waitTime":   s.waitTime,
+		"limit":      s.limit,
+		"perService": s.perService,
 	}).Debug("listing matching containers")
-	containers, err := container.ListNContainers(ctx, s.client, s.names, s.pattern, s.limit)
+	containers, err := container.ListNContainers(ctx, s.client, s.names, s.pattern, s.labels, s.limit, s.perService)
 	if err != nil {
 		log.WithError(err).Error("failed to list containers")
 		return err
@@ -76,17 +82,24 @@ func (s *StopCommand) Run(ctx context.Context, random bool) error {
 	// keep stopped containers
 	stoppedContainers := []container.Container{}
 	// pause containers
-	for _, container := range containers {
+	for _, c := range containers {
 		log.WithFields(log.Fields{
-			"container": container,
+			"container": c,
 			"waitTime":  s.waitTime,
 		}).Debug("stopping container")
-		err = s.client.StopContainer(ctx, container, s.waitTime, s.dryRun)
+		// journal container to restart, so it's restarted by recovery if pumba dies
+		if s.restart && !s.dryRun {
+			if err = container.RecordFault(container.StopFault(c)); err != nil {
+				break
+			}
+		}
+		err = s.client.StopContainer(ctx, c, s.waitTime, s.dryRun)
 		if err != nil {
 			log.WithError(err).Error("failed to stop container")
+			container.ClearFault(container.StopFault(c))
 			break
 		}
-		stoppedContainers = append(stoppedContainers, container)
+		stoppedContainers = append(stoppedContainers, c)
 	}
 
 	// if there are stopped containers and want to (re)start ...
@@ -111,12 +124,14 @@ func (s *StopCommand) Run(ctx context.Context, random bool) error {
 // start previously stopped containers after duration on exit
 func (s *StopCommand) startStoppedContainers(ctx context.Context, containers []container.Container) error {
 	var err error
-	for _, container := range containers {
-		log.WithField("container", container).Debug("start stopped container")
-		if e := s.client.StartContainer(ctx, container, s.dryRun); e != nil {
+	for _, c := range containers {
+		log.WithField("container", c).Debug("start stopped container")
+		if e := s.client.StartContainer(ctx, c, s.dryRun); e != nil {
 			log.WithError(e).Error("failed to start stopped container")
 			err = e
+			continue
 		}
+		container.ClearFault(container.StopFault(c))
 	}
 	return err // last non nil error
 }
@@ -14,15 +14,17 @@ import (
 
 func TestNewStopCommand(t *testing.T) {
 	type args struct {
-		client   container.Client
-		names    []string
-		pattern  string
-		restart  bool
-		interval string
-		duration string
-		waitTime int
-		limit    int
-		dryRun   bool
+		client     container.Client
+		names      []string
+		pattern    string
+		labels     []string
+		restart    bool
+		interval   string
+		duration   string
+		waitTime   int
+		limit      int
+		perService bool
+		dryRun     bool
 	}
 	tests := []struct {
 		name    string
@@ -70,7 +72,7 @@ func TestNewStopCommand(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewStopCommand(tt.args.client, tt.args.names, tt.args.pattern, tt.args.restart, tt.args.interval, tt.args.duration, tt.args.waitTime, tt.args.limit, tt.args.dryRun)
+			got, err := NewStopCommand(tt.args.client, tt.args.names, tt.args.pattern, tt.args.labels, tt.args.restart, tt.args.interval, tt.args.duration, tt.args.waitTime, tt.args.limit, tt.args.perService, tt.args.dryRun)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewStopCommand() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -89,12 +91,14 @@ func TestStopCommand_Run(t *testing.T) {
 		startError bool
 	}
 	type fields struct {
-		names    []string
-		pattern  string
-		restart  bool
-		waitTime int
-		limit    int
-		dryRun   bool
+		names      []string
+		pattern    string
+		labels     []string
+		restart    bool
+		waitTime   int
+		limit      int
+		perService bool
+		dryRun     bool
 	}
 	type args struct {
 		ctx    context.Context
@@ -222,13 +226,15 @@ func TestStopCommand_Run(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockClient := new(container.MockClient)
 			s := &StopCommand{
-				client:   mockClient,
-				names:    tt.fields.names,
-				pattern:  tt.fields.pattern,
-				restart:  tt.fields.restart,
-				waitTime: tt.fields.waitTime,
-				limit:    tt.fields.limit,
-				dryRun:   tt.fields.dryRun,
+				client:     mockClient,
+				names:      tt.fields.names,
+				pattern:    tt.fields.pattern,
+				labels:     tt.fields.labels,
+				restart:    tt.fields.restart,
+				waitTime:   tt.fields.waitTime,
+				limit:      tt.fields.limit,
+				perService: tt.fields.perService,
+				dryRun:     tt.fields.dryRun,
 			}
 			call := mockClient.On("ListContainers", tt.args.ctx, mock.AnythingOfType("container.Filter"))
 			if tt.errs.listError {
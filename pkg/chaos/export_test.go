@@ -0,0 +1,4 @@
+package chaos
+
+// CommandName export commandName for tests of real chaos commands (chaos_test package avoids import cycle)
+var CommandName = commandName
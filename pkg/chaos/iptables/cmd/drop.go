@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/iptables"
+)
+
+type dropContext struct {
+	context context.Context
+}
+
+// NewDropCLICommand initialize CLI drop command and bind it to the dropContext
+func NewDropCLICommand(ctx context.Context) *cli.Command {
+	cmdContext := &dropContext{context: ctx}
+	return &cli.Command{
+		Name:        "drop",
+		Usage:       "drop matching packets",
+		ArgsUsage:   fmt.Sprintf("containers (name, list of names, or RE2 regex if prefixed with %q", chaos.Re2Prefix),
+		Description: "silently drop all packets, matching 'iptables' filter, for specified containers",
+		Action:      cmdContext.drop,
+	}
+}
+
+// IPTABLES Drop Command - drop packets
+func (cmd *dropContext) drop(c *cli.Context) error {
+	// get random flag
+	random := c.GlobalBool("random")
+	// get dry-run mode
+	dryRun := c.GlobalBool("dry-run")
+	// get names or pattern
+	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
+	// get global chaos interval
+	interval := c.GlobalString("interval")
+
+	// get iptables filter from parent `iptables` command
+	filter := getFilter(c.Parent())
+	// get duration from parent `iptables` command
+	duration := c.Parent().String("duration")
+	// get iptables image from parent `iptables` command
+	image := c.Parent().String("iptables-image")
+	// get pull iptables image flag
+	pull := c.Parent().BoolT("pull-image")
+	// get limit for number of containers
+	limit := c.Parent().Int("limit")
+
+	// init iptables drop command
+	dropCommand, err := iptables.NewDropCommand(chaos.DockerClient, names, pattern, labels, filter, duration, interval, image, pull, limit, perService, dryRun)
+	if err != nil {
+		return err
+	}
+	// run iptables command
+	return chaos.RunChaosCommand(cmd.context, dropCommand, interval, random)
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos/iptables"
+)
+
+// get iptables filter from `iptables` command flags
+func getFilter(c *cli.Context) iptables.Filter {
+	return iptables.Filter{
+		Direction:    c.String("direction"),
+		Protocol:     c.String("protocol"),
+		Sources:      c.StringSlice("source"),
+		Destinations: c.StringSlice("destination"),
+		SrcPort:      c.String("src-port"),
+		DstPort:      c.String("dst-port"),
+	}
+}
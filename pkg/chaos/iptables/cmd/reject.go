@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/iptables"
+)
+
+type rejectContext struct {
+	context context.Context
+}
+
+// NewRejectCLICommand initialize CLI reject command and bind it to the rejectContext
+func NewRejectCLICommand(ctx context.Context) *cli.Command {
+	cmdContext := &rejectContext{context: ctx}
+	return &cli.Command{
+		Name: "reject",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "reject-with, w",
+				Usage: fmt.Sprintf("reject reply type, can be one of {%s}; 'tcp-reset' requires 'tcp' protocol (default: 'icmp-port-unreachable')", strings.Join(iptables.RejectTypes, " | ")),
+			},
+		},
+		Usage:       "reject matching packets",
+		ArgsUsage:   fmt.Sprintf("containers (name, list of names, or RE2 regex if prefixed with %q", chaos.Re2Prefix),
+		Description: "drop all packets, matching 'iptables' filter, for specified containers and send back an error packet",
+		Action:      cmdContext.reject,
+	}
+}
+
+// IPTABLES Reject Command - reject packets
+func (cmd *rejectContext) reject(c *cli.Context) error {
+	// get random flag
+	random := c.GlobalBool("random")
+	// get dry-run mode
+	dryRun := c.GlobalBool("dry-run")
+	// get names or pattern
+	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
+	// get global chaos interval
+	interval := c.GlobalString("interval")
+
+	// get iptables filter from parent `iptables` command
+	filter := getFilter(c.Parent())
+	// get duration from parent `iptables` command
+	duration := c.Parent().String("duration")
+	// get iptables image from parent `iptables` command
+	image := c.Parent().String("iptables-image")
+	// get pull iptables image flag
+	pull := c.Parent().BoolT("pull-image")
+	// get limit for number of containers
+	limit := c.Parent().Int("limit")
+
+	// get reject reply type
+	rejectWith := c.String("reject-with")
+	// init iptables reject command
+	rejectCommand, err := iptables.NewRejectCommand(chaos.DockerClient, names, pattern, labels, filter, rejectWith, duration, interval, image, pull, limit, perService, dryRun)
+	if err != nil {
+		return err
+	}
+	// run iptables command
+	return chaos.RunChaosCommand(cmd.context, rejectCommand, interval, random)
+}
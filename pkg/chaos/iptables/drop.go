@@ -0,0 +1,35 @@
+package iptables
+
+import (
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DropCommand `iptables drop` command
+type DropCommand struct {
+	*IPTablesCommand
+}
+
+// NewDropCommand create new iptables drop command
+func NewDropCommand(client container.Client,
+	names []string, // containers
+	pattern string, // re2 regex pattern
+	labels []string, // label selectors
+	filter Filter, // iptables match options
+	durationStr string, // chaos duration
+	intervalStr string, // repeatable chaos interval
+	image string, // iptables image
+	pull bool, // pull iptables image
+	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
+	dryRun bool, // dry-run do not change iptables just log
+) (chaos.Command, error) {
+	cmd, err := newIPTablesCommand(client, names, pattern, labels, filter, []string{ActionDrop}, durationStr, intervalStr, image, pull, limit, perService, dryRun)
+	if err != nil {
+		log.WithError(err).Error("failed to construct IPTables Drop Command")
+		return nil, err
+	}
+	return &DropCommand{cmd}, nil
+}
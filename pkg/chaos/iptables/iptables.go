@@ -0,0 +1,276 @@
+package iptables
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ActionDrop silently drop matching packets
+	ActionDrop = "DROP"
+	// ActionReject drop matching packets and send back an error packet
+	ActionReject = "REJECT"
+	// DefaultProtocol default protocol filter: match all protocols
+	DefaultProtocol = "all"
+)
+
+// iptables protocols, supported by port filter
+var portProtocols = []string{"tcp", "udp"}
+
+// IPTablesCommand `iptables drop|reject` command
+type IPTablesCommand struct {
+	client     container.Client
+	names      []string
+	pattern    string
+	labels     []string
+	rules      [][]string
+	duration   time.Duration
+	image      string
+	pull       bool
+	limit      int
+	perService bool
+	dryRun     bool
+}
+
+// Filter iptables rule match options
+type Filter struct {
+	Direction    string   // traffic direction: ingress, egress or both
+	Protocol     string   // protocol: tcp, udp, icmp or all
+	Sources      []string // source IPs; supports CIDR notation
+	Destinations []string // destination IPs; supports CIDR notation
+	SrcPort      string   // source port
+	DstPort      string   // destination port
+}
+
+// create iptables command, that inserts rules with specified target (jump) for all matching packets
+func newIPTablesCommand(client container.Client,
+	names []string, // containers
+	pattern string, // re2 regex pattern
+	labels []string, // label selectors
+	filter Filter, // iptables match options
+	target []string, // iptables target with options
+	durationStr string, // chaos duration
+	intervalStr string, // repeatable chaos interval
+	image string, // iptables image
+	pull bool, // pull iptables image
+	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
+	dryRun bool, // dry-run do not change iptables just log
+) (*IPTablesCommand, error) {
+	// get interval
+	interval, err := util.GetIntervalValue(intervalStr)
+	if err != nil {
+		return nil, err
+	}
+	// get duration
+	duration, err := util.GetDurationValue(durationStr, interval)
+	if err != nil {
+		return nil, err
+	}
+	// build iptables rules
+	rules, err := buildRules(filter, target)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IPTablesCommand{
+		client:     client,
+		names:      names,
+		pattern:    pattern,
+		labels:     labels,
+		rules:      rules,
+		duration:   duration,
+		image:      image,
+		pull:       pull,
+		limit:      limit,
+		perService: perService,
+		dryRun:     dryRun,
+	}, nil
+}
+
+// build iptables rule specifications (without command): one rule per chain
+func buildRules(filter Filter, target []string) ([][]string, error) {
+	// get chains from traffic direction
+	var chains []string
+	switch filter.Direction {
+	case "", container.DirectionIngress:
+		chains = []string{"INPUT"}
+	case container.DirectionEgress:
+		chains = []string{"OUTPUT"}
+	case container.DirectionBoth:
+		chains = []string{"INPUT", "OUTPUT"}
+	default:
+		return nil, fmt.Errorf("invalid traffic direction: must be one of {%s | %s | %s}", container.DirectionIngress, container.DirectionEgress, container.DirectionBoth)
+	}
+	// validate protocol
+	protocol := strings.ToLower(filter.Protocol)
+	switch protocol {
+	case "", "all", "tcp", "udp", "icmp":
+	default:
+		return nil, fmt.Errorf("invalid protocol '%s': must be one of {all | tcp | udp | icmp}", filter.Protocol)
+	}
+	// build match options
+	var match []string
+	if protocol != "" && protocol != "all" {
+		match = append(match, "-p", protocol)
+	}
+	sources, err := parseIPs(filter.Sources)
+	if err != nil {
+		return nil, err
+	}
+	if sources != "" {
+		match = append(match, "-s", sources)
+	}
+	destinations, err := parseIPs(filter.Destinations)
+	if err != nil {
+		return nil, err
+	}
+	if destinations != "" {
+		match = append(match, "-d", destinations)
+	}
+	for _, p := range []struct{ option, port string }{{"--sport", filter.SrcPort}, {"--dport", filter.DstPort}} {
+		if p.port == "" {
+			continue
+		}
+		if !util.SliceContains(portProtocols, protocol) {
+			return nil, fmt.Errorf("port filter requires protocol to be one of {%s}", strings.Join(portProtocols, " | "))
+		}
+		if _, err := strconv.ParseUint(p.port, 10, 16); err != nil {
+			return nil, fmt.Errorf("bad port: '%s' is not a valid port number", p.port)
+		}
+		match = append(match, p.option, p.port)
+	}
+	// one rule per chain
+	rules := make([][]string, 0, len(chains))
+	for _, chain := range chains {
+		rule := append([]string{chain}, match...)
+		rule = append(rule, "-j")
+		rule = append(rule, target...)
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// validate IPs and join them into comma separated list, accepted by iptables;
+// iptables handles IPv4 only: IPv6 addresses (ip6tables) are rejected
+func parseIPs(ipsList []string) (string, error) {
+	ips := make([]string, 0, len(ipsList))
+	for _, str := range ipsList {
+		ip := util.ParseCIDR(str)
+		if ip == nil {
+			return "", fmt.Errorf("bad IP: '%s' is not a valid IP or CIDR", str)
+		}
+		if ip.IP.To4() == nil {
+			return "", fmt.Errorf("bad IP: '%s' is IPv6 address; iptables supports IPv4 addresses only", str)
+		}
+		ips = append(ips, ip.String())
+	}
+	return strings.Join(ips, ","), nil
+}
+
+// Run iptables command
+func (n *IPTablesCommand) Run(ctx context.Context, random bool) error {
+	log.Debug("adding iptables rules to all matching containers")
+	log.WithFields(log.Fields{
+		"names":      n.names,
+		"pattern":    n.pattern,
+		"labels":     n.labels,
+		"limit":      n.limit,
+		"perService": n.perService,
+	}).Debug("listing matching containers")
+	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.labels, n.limit, n.perService)
+	if err != nil {
+		log.WithError(err).Error("failed to list containers")
+		return err
+	}
+	if len(containers) == 0 {
+		log.Warning("no containers found")
+		return nil
+	}
+
+	// select single random container from matching container and replace list with selected item
+	if random {
+		log.Debug("selecting single random container")
+		if c := container.RandomContainer(containers); c != nil {
+			containers = []container.Container{*c}
+		}
+	}
+
+	// run iptables command for selected containers
+	var wg sync.WaitGroup
+	errors := make([]error, len(containers))
+	for i, c := range containers {
+		log.WithFields(log.Fields{
+			"container": c,
+			"rules":     n.rules,
+		}).Debug("adding iptables rules for container")
+		wg.Add(1)
+		go func(i int, c container.Container) {
+			defer wg.Done()
+			// rules are removed after duration (or on abort) by runIPTables
+			errors[i] = runIPTables(ctx, n.client, c, n.rules, n.duration, n.image, n.pull, n.dryRun)
+			if errors[i] != nil {
+				log.WithError(errors[i]).Error("failed to set iptables rules for container")
+			}
+		}(i, c)
+	}
+
+	// Wait for all iptables commands to complete
+	wg.Wait()
+
+	// scan through all errors in goroutines
+	for _, e := range errors {
+		// take first found error
+		if e != nil {
+			err = e
+			break
+		}
+	}
+
+	return err
+}
+
+// run iptables command, remove iptables rules on timeout or abort
+func runIPTables(ctx context.Context, client container.Client, c container.Container, rules [][]string, duration time.Duration, image string, pull bool, dryRun bool) error {
+	log.WithFields(log.Fields{
+		"id":       c.ID(),
+		"name":     c.Name(),
+		"rules":    rules,
+		"duration": duration,
+		"image":    image,
+		"pull":     pull,
+	}).Debug("running iptables command")
+	err := client.IPTablesContainer(ctx, c, rules, image, pull, dryRun)
+	if err != nil {
+		// rules, inserted before failure, are deleted by IPTablesContainer
+		log.WithError(err).Error("failed to add iptables rules for container")
+		return err
+	}
+
+	// wait for specified duration and then remove iptables rules or remove on ctx.Done()
+	select {
+	case <-ctx.Done():
+		log.WithFields(log.Fields{
+			"id":    c.ID(),
+			"name":  c.Name(),
+			"rules": rules,
+		}).Debug("removing iptables rules on abort")
+	case <-time.After(duration):
+		log.WithFields(log.Fields{
+			"id":    c.ID(),
+			"name":  c.Name(),
+			"rules": rules,
+		}).Debug("removing iptables rules on timout")
+	}
+	// use different context to remove rules since parent context is canceled
+	return client.StopIPTablesContainer(context.Background(), c, rules, image, pull, dryRun)
+}
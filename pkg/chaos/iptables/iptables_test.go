@@ -0,0 +1,275 @@
+package iptables
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_buildRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filter
+		target  []string
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:   "drop all ingress traffic",
+			filter: Filter{},
+			target: []string{"DROP"},
+			want:   [][]string{{"INPUT", "-j", "DROP"}},
+		},
+		{
+			name:   "drop ingress traffic from CIDR to port",
+			filter: Filter{Direction: "ingress", Protocol: "tcp", Sources: []string{"10.0.0.0/24"}, DstPort: "5432"},
+			target: []string{"DROP"},
+			want:   [][]string{{"INPUT", "-p", "tcp", "-s", "10.0.0.0/24", "--dport", "5432", "-j", "DROP"}},
+		},
+		{
+			name:   "reject egress traffic to multiple IPs",
+			filter: Filter{Direction: "egress", Protocol: "all", Destinations: []string{"1.2.3.4", "5.6.7.0/24"}},
+			target: []string{"REJECT"},
+			want:   [][]string{{"OUTPUT", "-d", "1.2.3.4/32,5.6.7.0/24", "-j", "REJECT"}},
+		},
+		{
+			name:   "reject traffic in both directions",
+			filter: Filter{Direction: "both", Protocol: "UDP", SrcPort: "53"},
+			target: []string{"REJECT", "--reject-with", "icmp-port-unreachable"},
+			want: [][]string{
+				{"INPUT", "-p", "udp", "--sport", "53", "-j", "REJECT", "--reject-with", "icmp-port-unreachable"},
+				{"OUTPUT", "-p", "udp", "--sport", "53", "-j", "REJECT", "--reject-with", "icmp-port-unreachable"},
+			},
+		},
+		{
+			name:    "invalid direction",
+			filter:  Filter{Direction: "sideways"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid protocol",
+			filter:  Filter{Protocol: "sctp"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid source IP",
+			filter:  Filter{Sources: []string{"1.2.3.4/3.4.5.6..."}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid destination IP",
+			filter:  Filter{Destinations: []string{"1.2.3"}},
+			wantErr: true,
+		},
+		{
+			name:    "IPv6 source CIDR",
+			filter:  Filter{Sources: []string{"2001:db8::/64"}},
+			wantErr: true,
+		},
+		{
+			name:    "IPv4 and IPv6 destinations",
+			filter:  Filter{Destinations: []string{"1.2.3.4", "fd00::1"}},
+			wantErr: true,
+		},
+		{
+			name:    "port without protocol",
+			filter:  Filter{DstPort: "80"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid port",
+			filter:  Filter{Protocol: "tcp", DstPort: "80000"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildRules(tt.filter, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("buildRules() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRejectCommand(t *testing.T) {
+	type args struct {
+		names       []string
+		filter      Filter
+		rejectWith  string
+		durationStr string
+		intervalStr string
+		image       string
+		limit       int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    chaos.Command
+		wantErr bool
+	}{
+		{
+			name: "reject with tcp-reset",
+			args: args{
+				names:       []string{"c1"},
+				filter:      Filter{Protocol: "tcp", DstPort: "5432"},
+				rejectWith:  "tcp-reset",
+				durationStr: "30s",
+				intervalStr: "1m",
+				image:       "test/image",
+				limit:       1,
+			},
+			want: &RejectCommand{&IPTablesCommand{
+				names:    []string{"c1"},
+				rules:    [][]string{{"INPUT", "-p", "tcp", "--dport", "5432", "-j", "REJECT", "--reject-with", "tcp-reset"}},
+				duration: 30 * time.Second,
+				image:    "test/image",
+				limit:    1,
+			}},
+		},
+		{
+			name: "tcp-reset without tcp protocol",
+			args: args{
+				rejectWith:  "tcp-reset",
+				durationStr: "30s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid reject type",
+			args: args{
+				rejectWith:  "icmp-go-away",
+				durationStr: "30s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "bad interval value",
+			args: args{
+				intervalStr: "bad-interval",
+			},
+			wantErr: true,
+		},
+		{
+			name: "duration is bigger than interval value",
+			args: args{
+				intervalStr: "1m",
+				durationStr: "2m",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRejectCommand(nil, tt.args.names, "", nil, tt.args.filter, tt.args.rejectWith, tt.args.durationStr, tt.args.intervalStr, tt.args.image, false, tt.args.limit, false, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRejectCommand() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewRejectCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPTablesCommand_Run(t *testing.T) {
+	rules := [][]string{{"INPUT", "-j", "DROP"}}
+	tests := []struct {
+		name      string
+		random    bool
+		expected  []container.Container
+		listErr   bool
+		addErr    bool
+		wantErr   bool
+		wantCalls int
+	}{
+		{name: "drop multiple containers", expected: container.CreateTestContainers(3), wantCalls: 3},
+		{name: "drop random container", random: true, expected: container.CreateTestContainers(3), wantCalls: 1},
+		{name: "no container found"},
+		{name: "error listing containers", listErr: true, wantErr: true},
+		{name: "error adding rules", expected: container.CreateTestContainers(1), addErr: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(container.MockClient)
+			n := &IPTablesCommand{
+				client:   mockClient,
+				names:    []string{"c1", "c2", "c3"},
+				rules:    rules,
+				duration: 10 * time.Microsecond,
+				image:    "test/image",
+			}
+			call := mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter"))
+			if tt.listErr {
+				call.Return(nil, errors.New("ERROR"))
+				goto Invoke
+			}
+			call.Return(tt.expected, nil)
+			if tt.expected == nil {
+				goto Invoke
+			}
+			if tt.addErr {
+				// inserted rules are deleted by IPTablesContainer
+				mockClient.On("IPTablesContainer", mock.Anything, mock.AnythingOfType("container.Container"), rules, "test/image", false, false).Return(errors.New("ERROR"))
+				goto Invoke
+			}
+			mockClient.On("IPTablesContainer", mock.Anything, mock.AnythingOfType("container.Container"), rules, "test/image", false, false).Return(nil)
+			mockClient.On("StopIPTablesContainer", mock.Anything, mock.AnythingOfType("container.Container"), rules, "test/image", false, false).Return(nil)
+		Invoke:
+			if err := n.Run(context.TODO(), tt.random); (err != nil) != tt.wantErr {
+				t.Errorf("IPTablesCommand.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockClient.AssertExpectations(t)
+			mockClient.AssertNumberOfCalls(t, "StopIPTablesContainer", tt.wantCalls)
+		})
+	}
+}
+
+func Test_runIPTables(t *testing.T) {
+	c := container.CreateTestContainers(1)[0]
+	rules := [][]string{{"OUTPUT", "-j", "DROP"}}
+	tests := []struct {
+		name    string
+		abort   bool
+		stopErr bool
+		wantErr bool
+	}{
+		{name: "iptables with duration"},
+		{name: "iptables with abort", abort: true},
+		{name: "iptables error in StopIPTablesContainer", stopErr: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(container.MockClient)
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			duration := 10 * time.Microsecond
+			if tt.abort {
+				duration = time.Hour
+				cancel()
+			}
+			mockClient.On("IPTablesContainer", ctx, c, rules, "test/image", true, false).Return(nil)
+			call := mockClient.On("StopIPTablesContainer", mock.Anything, c, rules, "test/image", true, false)
+			if tt.stopErr {
+				call.Return(errors.New("test error"))
+			} else {
+				call.Return(nil)
+			}
+			if err := runIPTables(ctx, mockClient, c, rules, duration, "test/image", true, false); (err != nil) != tt.wantErr {
+				t.Errorf("runIPTables() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
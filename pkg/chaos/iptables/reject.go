@@ -0,0 +1,74 @@
+package iptables
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RejectTypes supported ICMP (or TCP reset) reject reply types
+// see: http://ipset.netfilter.org/iptables-extensions.man.html#lbDU
+var RejectTypes = []string{
+	"icmp-net-unreachable",
+	"icmp-host-unreachable",
+	"icmp-port-unreachable",
+	"icmp-proto-unreachable",
+	"icmp-net-prohibited",
+	"icmp-host-prohibited",
+	"icmp-admin-prohibited",
+	"tcp-reset",
+}
+
+// RejectCommand `iptables reject` command
+type RejectCommand struct {
+	*IPTablesCommand
+}
+
+// NewRejectCommand create new iptables reject command
+func NewRejectCommand(client container.Client,
+	names []string, // containers
+	pattern string, // re2 regex pattern
+	labels []string, // label selectors
+	filter Filter, // iptables match options
+	rejectWith string, // reject reply type
+	durationStr string, // chaos duration
+	intervalStr string, // repeatable chaos interval
+	image string, // iptables image
+	pull bool, // pull iptables image
+	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
+	dryRun bool, // dry-run do not change iptables just log
+) (chaos.Command, error) {
+	// log error
+	var err error
+	defer func() {
+		if err != nil {
+			log.WithError(err).Error("failed to construct IPTables Reject Command")
+		}
+	}()
+
+	target := []string{ActionReject}
+	if rejectWith != "" {
+		if !util.SliceContains(RejectTypes, rejectWith) {
+			err = fmt.Errorf("invalid reject type '%s': must be one of %v", rejectWith, RejectTypes)
+			return nil, err
+		}
+		// TCP RST packet can be sent only in reply to TCP packet
+		if rejectWith == "tcp-reset" && strings.ToLower(filter.Protocol) != "tcp" {
+			err = fmt.Errorf("reject with 'tcp-reset' requires 'tcp' protocol")
+			return nil, err
+		}
+		target = append(target, "--reject-with", rejectWith)
+	}
+
+	cmd, err := newIPTablesCommand(client, names, pattern, labels, filter, target, durationStr, intervalStr, image, pull, limit, perService, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	return &RejectCommand{cmd}, nil
+}
@@ -46,15 +46,21 @@ func (cmd *corruptContext) corrupt(c *cli.Context) error {
 	dryRun := c.GlobalBool("dry-run")
 	// get names or pattern
 	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
 	// get global chaos interval
 	interval := c.GlobalString("interval")
 
 	// get network interface from parent `netem` command
 	iface := c.Parent().String("interface")
-	// get ips list from parent `netem`` command `target` flag
-	ips := c.Parent().StringSlice("target")
-	// get port from parent `netem`` command `target` flag
-	port := uint16(c.Parent().Uint64("port"))
+	// get Docker network to resolve network interface on from parent `netem` command
+	network := c.Parent().String("network")
+	// get traffic direction from parent `netem` command
+	direction := c.Parent().String("direction")
+	// get traffic filter from parent `netem` command flags
+	filter := getFilter(c.Parent())
 	// get duration from parent `netem`` command
 	duration := c.Parent().String("duration")
 	// get traffic control image from parent `netem` command
@@ -69,7 +75,7 @@ func (cmd *corruptContext) corrupt(c *cli.Context) error {
 	// get delay variation
 	correlation := c.Float64("correlation")
 	// init netem corrupt command
-	corruptCommand, err := netem.NewCorruptCommand(chaos.DockerClient, names, pattern, iface, ips, port, duration, interval, percent, correlation, image, pull, limit, dryRun)
+	corruptCommand, err := netem.NewCorruptCommand(chaos.DockerClient, names, pattern, labels, iface, network, direction, filter, duration, interval, percent, correlation, image, pull, limit, perService, dryRun)
 	if err != nil {
 		return err
 	}
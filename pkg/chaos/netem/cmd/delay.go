@@ -40,10 +40,23 @@ func NewDelayCLICommand(ctx context.Context) *cli.Command {
 				Usage: "delay distribution, can be one of {<empty> | uniform | normal | pareto |  paretonormal}",
 				Value: "",
 			},
+			cli.IntFlag{
+				Name:  "ramp-to",
+				Usage: "ramp delay time linearly from --time to this value during netem duration; in milliseconds",
+			},
+			cli.StringFlag{
+				Name:  "ramp-step",
+				Usage: "change ramped delay time every step; use with unit suffix: 'ms/s/m/h'",
+				Value: netem.DefaultRampStep,
+			},
+			cli.StringFlag{
+				Name:  "schedule",
+				Usage: "delay schedule: comma separated list of '<time>@<offset>' items, changing delay time at offset from netem start; example: '100@1m,500@3m'",
+			},
 		},
 		Usage:       "delay egress traffic",
 		ArgsUsage:   fmt.Sprintf("containers (name, list of names, or RE2 regex if prefixed with %q", chaos.Re2Prefix),
-		Description: "delay egress traffic for specified containers; networks show variability so it is possible to add random variation; delay variation isn't purely random, so to emulate that there is a correlation",
+		Description: "delay egress traffic for specified containers; networks show variability so it is possible to add random variation; delay variation isn't purely random, so to emulate that there is a correlation; delay time can be changed during netem duration, following linear ramp or step schedule, without tearing netem down",
 		Action:      cmdContext.delay,
 	}
 }
@@ -56,15 +69,21 @@ func (cmd *delayContext) delay(c *cli.Context) error {
 	dryRun := c.GlobalBool("dry-run")
 	// get names or pattern
 	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
 	// get global chaos interval
 	interval := c.GlobalString("interval")
 
 	// get network interface from parent `netem` command
 	iface := c.Parent().String("interface")
-	// get ips list from parent `netem`` command `target` flag
-	ips := c.Parent().StringSlice("target")
-	// get port from parent `netem`` command `target` flag
-	port := uint16(c.Parent().Uint64("port"))
+	// get Docker network to resolve network interface on from parent `netem` command
+	network := c.Parent().String("network")
+	// get traffic direction from parent `netem` command
+	direction := c.Parent().String("direction")
+	// get traffic filter from parent `netem` command flags
+	filter := getFilter(c.Parent())
 	// get duration from parent `netem`` command
 	duration := c.Parent().String("duration")
 	// get traffic control image from parent `netem` command
@@ -82,9 +101,14 @@ func (cmd *delayContext) delay(c *cli.Context) error {
 	correlation := c.Float64("correlation")
 	// get delay distribution
 	distribution := c.String("distribution")
+	// get delay ramp target and step
+	rampTo := c.Int("ramp-to")
+	rampStep := c.String("ramp-step")
+	// get delay schedule
+	schedule := c.String("schedule")
 
 	// init netem delay command
-	delayCommand, err := netem.NewDelayCommand(chaos.DockerClient, names, pattern, iface, ips, port, duration, interval, time, jitter, correlation, distribution, image, pull, limit, dryRun)
+	delayCommand, err := netem.NewDelayCommand(chaos.DockerClient, names, pattern, labels, iface, network, direction, filter, duration, interval, time, jitter, correlation, distribution, rampTo, rampStep, schedule, image, pull, limit, perService, dryRun)
 	if err != nil {
 		return err
 	}
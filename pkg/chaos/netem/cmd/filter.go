@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos/netem"
+)
+
+// get netem traffic filter from `netem` command flags
+func getFilter(c *cli.Context) netem.Filter {
+	return netem.Filter{
+		Targets:  c.StringSlice("target"),
+		Sources:  c.StringSlice("source"),
+		Ports:    c.String("port"),
+		SrcPorts: c.String("src-port"),
+		Protocol: c.String("protocol"),
+	}
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/netem"
+)
+
+type profileContext struct {
+	context context.Context
+}
+
+// NewProfileCLICommand initialize CLI profile command and bind it to the profileContext
+func NewProfileCLICommand(ctx context.Context) *cli.Command {
+	cmdContext := &profileContext{context: ctx}
+	return &cli.Command{
+		Name: "profile",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "preset",
+				Usage: "network condition preset: edge, 3g, satellite, flaky-wifi or custom preset from presets file; explicitly set flags override preset values",
+			},
+			cli.StringFlag{
+				Name:  "presets-file",
+				Usage: "YAML file with custom presets: map of preset name to conditions (delay, jitter, delay-correlation, distribution, loss, loss-correlation, duplicate, duplicate-correlation, corrupt, corrupt-correlation, rate)",
+			},
+			cli.IntFlag{
+				Name:  "delay",
+				Usage: "delay time; in milliseconds",
+			},
+			cli.IntFlag{
+				Name:  "jitter",
+				Usage: "random delay variation (jitter); in milliseconds; example: 100ms ± 10ms",
+			},
+			cli.Float64Flag{
+				Name:  "delay-correlation",
+				Usage: "delay correlation; in percentage",
+			},
+			cli.StringFlag{
+				Name:  "distribution",
+				Usage: "delay distribution, can be one of {<empty> | uniform | normal | pareto |  paretonormal}",
+			},
+			cli.Float64Flag{
+				Name:  "loss",
+				Usage: "packet loss percentage",
+			},
+			cli.Float64Flag{
+				Name:  "loss-correlation",
+				Usage: "loss correlation; in percentage",
+			},
+			cli.Float64Flag{
+				Name:  "duplicate",
+				Usage: "packet duplication percentage",
+			},
+			cli.Float64Flag{
+				Name:  "duplicate-correlation",
+				Usage: "duplicate correlation; in percentage",
+			},
+			cli.Float64Flag{
+				Name:  "corrupt",
+				Usage: "packet corruption percentage",
+			},
+			cli.Float64Flag{
+				Name:  "corrupt-correlation",
+				Usage: "corrupt correlation; in percentage",
+			},
+			cli.StringFlag{
+				Name:  "rate",
+				Usage: "rate limit; in common units; example: 100kbit",
+			},
+		},
+		Usage:       "combine delay, loss, duplicate, corrupt and rate",
+		ArgsUsage:   fmt.Sprintf("containers (name, list of names, or RE2 regex if prefixed with %q", chaos.Re2Prefix),
+		Description: "emulate realistic network (example: bad 3G link) with delay, loss, duplicate, corrupt and rate applied together in single netem qdisc; only defined conditions are applied; use named preset to start with typical network conditions",
+		Action:      cmdContext.profile,
+	}
+}
+
+// NETEM PROFILE Command - combined network emulation
+func (cmd *profileContext) profile(c *cli.Context) error {
+	// get random flag
+	random := c.GlobalBool("random")
+	// get dry-run mode
+	dryRun := c.GlobalBool("dry-run")
+	// get names or pattern
+	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
+	// get global chaos interval
+	interval := c.GlobalString("interval")
+
+	// get network interface from parent `netem` command
+	iface := c.Parent().String("interface")
+	// get Docker network to resolve network interface on from parent `netem` command
+	network := c.Parent().String("network")
+	// get traffic direction from parent `netem` command
+	direction := c.Parent().String("direction")
+	// get traffic filter from parent `netem` command flags
+	filter := getFilter(c.Parent())
+	// get duration from parent `netem`` command
+	duration := c.Parent().String("duration")
+	// get traffic control image from parent `netem` command
+	image := c.Parent().String("tc-image")
+	// get pull tc image flag
+	pull := c.Parent().BoolT("pull-image")
+	// get limit for number of containers to netem
+	limit := c.Parent().Int("limit")
+
+	// get network conditions: preset values, overridden by explicitly set flags
+	profile, err := getProfile(c)
+	if err != nil {
+		return err
+	}
+
+	// init netem profile command
+	profileCommand, err := netem.NewProfileCommand(chaos.DockerClient, names, pattern, labels, iface, network, direction, filter, duration, interval, profile, image, pull, limit, perService, dryRun)
+	if err != nil {
+		return err
+	}
+	// run netem profile command
+	return chaos.RunChaosCommand(cmd.context, profileCommand, interval, random)
+}
+
+// get network conditions from preset and flags
+func getProfile(c *cli.Context) (netem.Profile, error) {
+	var profile netem.Profile
+	if preset := c.String("preset"); preset != "" {
+		presets, err := netem.LoadPresets(c.String("presets-file"))
+		if err != nil {
+			return profile, err
+		}
+		if profile, err = presets.Profile(preset); err != nil {
+			return profile, err
+		}
+	}
+	if c.IsSet("delay") {
+		profile.Delay = c.Int("delay")
+	}
+	if c.IsSet("jitter") {
+		profile.Jitter = c.Int("jitter")
+	}
+	if c.IsSet("delay-correlation") {
+		profile.DelayCorrelation = c.Float64("delay-correlation")
+	}
+	if c.IsSet("distribution") {
+		profile.Distribution = c.String("distribution")
+	}
+	if c.IsSet("loss") {
+		profile.Loss = c.Float64("loss")
+	}
+	if c.IsSet("loss-correlation") {
+		profile.LossCorrelation = c.Float64("loss-correlation")
+	}
+	if c.IsSet("duplicate") {
+		profile.Duplicate = c.Float64("duplicate")
+	}
+	if c.IsSet("duplicate-correlation") {
+		profile.DuplicateCorrelation = c.Float64("duplicate-correlation")
+	}
+	if c.IsSet("corrupt") {
+		profile.Corrupt = c.Float64("corrupt")
+	}
+	if c.IsSet("corrupt-correlation") {
+		profile.CorruptCorrelation = c.Float64("corrupt-correlation")
+	}
+	if c.IsSet("rate") {
+		profile.Rate = c.String("rate")
+	}
+	return profile, nil
+}
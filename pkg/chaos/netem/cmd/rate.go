@@ -23,7 +23,7 @@ func NewRateCLICommand(ctx context.Context) *cli.Command {
 			cli.StringFlag{
 				Name:  "rate, r",
 				Usage: "delay outgoing packets; in common units",
-				Value: "100kbit",
+				Value: netem.DefaultRate,
 			},
 			cli.IntFlag{
 				Name:  "packetoverhead, p",
@@ -56,15 +56,21 @@ func (cmd *rateContext) rate(c *cli.Context) error {
 	dryRun := c.GlobalBool("dry-run")
 	// get names or pattern
 	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
 	// get global chaos interval
 	interval := c.GlobalString("interval")
 
 	// get network interface from parent `netem` command
 	iface := c.Parent().String("interface")
-	// get ips list from parent `netem`` command `target` flag
-	ips := c.Parent().StringSlice("target")
-	// get port from parent `netem`` command `target` flag
-	port := uint16(c.Parent().Uint64("port"))
+	// get Docker network to resolve network interface on from parent `netem` command
+	network := c.Parent().String("network")
+	// get traffic direction from parent `netem` command
+	direction := c.Parent().String("direction")
+	// get traffic filter from parent `netem` command flags
+	filter := getFilter(c.Parent())
 	// get duration from parent `netem`` command
 	duration := c.Parent().String("duration")
 	// get traffic control image from parent `netem` command
@@ -84,7 +90,7 @@ func (cmd *rateContext) rate(c *cli.Context) error {
 	cellOverhead := c.Int("celloverhead")
 
 	// init netem rate command
-	lossCommand, err := netem.NewRateCommand(chaos.DockerClient, names, pattern, iface, ips, port, duration, interval, rate, packetOverhead, cellSize, cellOverhead, image, pull, limit, dryRun)
+	lossCommand, err := netem.NewRateCommand(chaos.DockerClient, names, pattern, labels, iface, network, direction, filter, duration, interval, rate, packetOverhead, cellSize, cellOverhead, image, pull, limit, perService, dryRun)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/netem"
+)
+
+type reorderContext struct {
+	context context.Context
+}
+
+// NewReorderCLICommand initialize CLI reorder command and bind it to the reorderContext
+func NewReorderCLICommand(ctx context.Context) *cli.Command {
+	cmdContext := &reorderContext{context: ctx}
+	return &cli.Command{
+		Name: "reorder",
+		Flags: []cli.Flag{
+			cli.IntFlag{
+				Name:  "time, t",
+				Usage: "delay time of not reordered packets; in milliseconds; reorder requires delay",
+				Value: 10,
+			},
+			cli.Float64Flag{
+				Name:  "percent, p",
+				Usage: "packet reorder percentage: packets sent immediately, without delay",
+				Value: 0.0,
+			},
+			cli.Float64Flag{
+				Name:  "correlation, c",
+				Usage: "reorder correlation; in percentage",
+				Value: 0.0,
+			},
+			cli.IntFlag{
+				Name:  "gap, g",
+				Usage: "reorder every gap-th packet (0: reorder with percentage probability)",
+				Value: 0,
+			},
+		},
+		Usage:       "reorder packets",
+		ArgsUsage:   fmt.Sprintf("containers (name, list of names, or RE2 regex if prefixed with %q", chaos.Re2Prefix),
+		Description: "reorder packets: reordered packets are sent immediately, others are delayed by specified time; use to reproduce out-of-order delivery",
+		Action:      cmdContext.reorder,
+	}
+}
+
+// NETEM REORDER Command - network emulation reorder
+func (cmd *reorderContext) reorder(c *cli.Context) error {
+	// get random flag
+	random := c.GlobalBool("random")
+	// get dry-run mode
+	dryRun := c.GlobalBool("dry-run")
+	// get names or pattern
+	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
+	// get global chaos interval
+	interval := c.GlobalString("interval")
+
+	// get network interface from parent `netem` command
+	iface := c.Parent().String("interface")
+	// get Docker network to resolve network interface on from parent `netem` command
+	network := c.Parent().String("network")
+	// get traffic direction from parent `netem` command
+	direction := c.Parent().String("direction")
+	// get traffic filter from parent `netem` command flags
+	filter := getFilter(c.Parent())
+	// get duration from parent `netem`` command
+	duration := c.Parent().String("duration")
+	// get traffic control image from parent `netem` command
+	image := c.Parent().String("tc-image")
+	// get pull tc image flag
+	pull := c.Parent().BoolT("pull-image")
+	// get limit for number of containers to netem
+	limit := c.Parent().Int("limit")
+
+	// get delay time
+	time := c.Int("time")
+	// get reorder percentage
+	percent := c.Float64("percent")
+	// get reorder correlation
+	correlation := c.Float64("correlation")
+	// get reorder gap
+	gap := c.Int("gap")
+
+	// init netem reorder command
+	reorderCommand, err := netem.NewReorderCommand(chaos.DockerClient, names, pattern, labels, iface, network, direction, filter, duration, interval, time, percent, correlation, gap, image, pull, limit, perService, dryRun)
+	if err != nil {
+		return err
+	}
+	// run netem command
+	return chaos.RunChaosCommand(cmd.context, reorderCommand, interval, random)
+}
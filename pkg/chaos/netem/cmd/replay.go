@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/netem"
+)
+
+type replayContext struct {
+	context context.Context
+}
+
+// NewReplayCLICommand initialize CLI replay command and bind it to the replayContext
+func NewReplayCLICommand(ctx context.Context) *cli.Command {
+	cmdContext := &replayContext{context: ctx}
+	return &cli.Command{
+		Name: "replay",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "trace",
+				Usage: "CSV trace file with 'timestamp, delay_ms, jitter_ms, loss_pct' records; timestamp in seconds or RFC 3339 time",
+			},
+			cli.Float64Flag{
+				Name:  "speed",
+				Usage: "replay speed multiplier; example: 2 replays trace twice faster",
+				Value: 1,
+			},
+		},
+		Usage:       "replay recorded delay and loss trace",
+		ArgsUsage:   fmt.Sprintf("containers (name, list of names, or RE2 regex if prefixed with %q", chaos.Re2Prefix),
+		Description: "replay network conditions (delay, jitter and loss), recorded from real network, in real time or with speed multiplier; netem parameters are updated as the trace advances and trace is cut at netem duration",
+		Action:      cmdContext.replay,
+	}
+}
+
+// NETEM REPLAY Command - network emulation trace replay
+func (cmd *replayContext) replay(c *cli.Context) error {
+	// get random flag
+	random := c.GlobalBool("random")
+	// get dry-run mode
+	dryRun := c.GlobalBool("dry-run")
+	// get names or pattern
+	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
+	// get global chaos interval
+	interval := c.GlobalString("interval")
+
+	// get network interface from parent `netem` command
+	iface := c.Parent().String("interface")
+	// get Docker network to resolve network interface on from parent `netem` command
+	network := c.Parent().String("network")
+	// get traffic direction from parent `netem` command
+	direction := c.Parent().String("direction")
+	// get traffic filter from parent `netem` command flags
+	filter := getFilter(c.Parent())
+	// get duration from parent `netem`` command
+	duration := c.Parent().String("duration")
+	// get traffic control image from parent `netem` command
+	image := c.Parent().String("tc-image")
+	// get pull tc image flag
+	pull := c.Parent().BoolT("pull-image")
+	// get limit for number of containers to netem
+	limit := c.Parent().Int("limit")
+
+	// get trace file and replay speed
+	trace := c.String("trace")
+	speed := c.Float64("speed")
+
+	// init netem replay command
+	replayCommand, err := netem.NewReplayCommand(chaos.DockerClient, names, pattern, labels, iface, network, direction, filter, duration, interval, trace, speed, image, pull, limit, perService, dryRun)
+	if err != nil {
+		return err
+	}
+	// run netem command
+	return chaos.RunChaosCommand(cmd.context, replayCommand, interval, random)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/netem"
+)
+
+type slotContext struct {
+	context context.Context
+}
+
+// NewSlotCLICommand initialize CLI slot command and bind it to the slotContext
+func NewSlotCLICommand(ctx context.Context) *cli.Command {
+	cmdContext := &slotContext{context: ctx}
+	return &cli.Command{
+		Name: "slot",
+		Flags: []cli.Flag{
+			cli.IntFlag{
+				Name:  "min-delay",
+				Usage: "minimal delay between transmission slots; in milliseconds",
+				Value: 10,
+			},
+			cli.IntFlag{
+				Name:  "max-delay",
+				Usage: "maximal delay between transmission slots; random delay between min and max; in milliseconds (0: fixed min delay)",
+				Value: 0,
+			},
+			cli.IntFlag{
+				Name:  "packets",
+				Usage: "max packets sent in single slot (0: unlimited)",
+				Value: 0,
+			},
+			cli.IntFlag{
+				Name:  "bytes",
+				Usage: "max bytes sent in single slot (0: unlimited)",
+				Value: 0,
+			},
+		},
+		Usage:       "send packets in bursts (slots)",
+		ArgsUsage:   fmt.Sprintf("containers (name, list of names, or RE2 regex if prefixed with %q", chaos.Re2Prefix),
+		Description: "accumulate packets and send them in bursts (transmission slots), emulating bursty Wi-Fi/LTE link scheduling",
+		Action:      cmdContext.slot,
+	}
+}
+
+// NETEM SLOT Command - network emulation slot
+func (cmd *slotContext) slot(c *cli.Context) error {
+	// get random flag
+	random := c.GlobalBool("random")
+	// get dry-run mode
+	dryRun := c.GlobalBool("dry-run")
+	// get names or pattern
+	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
+	// get global chaos interval
+	interval := c.GlobalString("interval")
+
+	// get network interface from parent `netem` command
+	iface := c.Parent().String("interface")
+	// get Docker network to resolve network interface on from parent `netem` command
+	network := c.Parent().String("network")
+	// get traffic direction from parent `netem` command
+	direction := c.Parent().String("direction")
+	// get traffic filter from parent `netem` command flags
+	filter := getFilter(c.Parent())
+	// get duration from parent `netem`` command
+	duration := c.Parent().String("duration")
+	// get traffic control image from parent `netem` command
+	image := c.Parent().String("tc-image")
+	// get pull tc image flag
+	pull := c.Parent().BoolT("pull-image")
+	// get limit for number of containers to netem
+	limit := c.Parent().Int("limit")
+
+	// get slot delays
+	minDelay := c.Int("min-delay")
+	maxDelay := c.Int("max-delay")
+	// get slot size limits
+	packets := c.Int("packets")
+	bytes := c.Int("bytes")
+
+	// init netem slot command
+	slotCommand, err := netem.NewSlotCommand(chaos.DockerClient, names, pattern, labels, iface, network, direction, filter, duration, interval, minDelay, maxDelay, packets, bytes, image, pull, limit, perService, dryRun)
+	if err != nil {
+		return err
+	}
+	// run netem command
+	return chaos.RunChaosCommand(cmd.context, slotCommand, interval, random)
+}
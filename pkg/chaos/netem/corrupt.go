@@ -2,11 +2,8 @@ package netem
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"net"
 	"regexp"
-	"strconv"
 	"sync"
 	"time"
 
@@ -22,15 +19,18 @@ type CorruptCommand struct {
 	client      container.Client
 	names       []string
 	pattern     string
+	labels      []string
 	iface       string
-	ips         []*net.IPNet
-	port		uint16
+	network     string
+	direction   string
+	filter      container.NetemFilter
 	duration    time.Duration
 	percent     float64
 	correlation float64
 	image       string
 	pull        bool
 	limit       int
+	perService  bool
 	dryRun      bool
 }
 
@@ -38,9 +38,11 @@ type CorruptCommand struct {
 func NewCorruptCommand(client container.Client,
 	names []string, // containers
 	pattern string, // re2 regex pattern
+	labels []string, // label selectors
 	iface string, // network interface
-	ipsList []string, // list of target ips
-	port uint16,
+	network string, // Docker network to resolve network interface on; implies 'auto' interface
+	direction string, // traffic direction: egress, ingress or both
+	filter Filter, // traffic filter: target and source IPs, ports and protocol
 	durationStr string, // chaos duration
 	intervalStr string, // repeatable chaos interval
 	percent float64, // corrupt percent
@@ -48,6 +50,7 @@ func NewCorruptCommand(client container.Client,
 	image string, // traffic control image
 	pull bool, // pull tc image
 	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
 	dryRun bool, // dry-run do not netem just log
 ) (chaos.Command, error) {
 	// log error
@@ -75,24 +78,22 @@ func NewCorruptCommand(client container.Client,
 		err = fmt.Errorf("bad network interface name: must match '%s'", reInterface.String())
 		return nil, err
 	}
-	// validate ips
-	var ips []*net.IPNet
-	for _, str := range ipsList {
-		ip := util.ParseCIDR(str)
-		if ip == nil {
-			err = fmt.Errorf("bad target: '%s' is not a valid IP", str)
-			return nil, err
-		}
-		ips = append(ips, ip)
+	// resolve network interface inside container on Docker network
+	if network != "" {
+		iface = container.AutoInterface
+	}
+	// validate traffic direction
+	direction, err = parseDirection(direction)
+	if err != nil {
+		return nil, err
 	}
-	// get netem corrupt percent
-	if percent < 0.0 || percent > 100.0 {
-		err = errors.New("invalid corrupt percent: must be between 0.0 and 100.0")
+	// validate traffic filter
+	netemFilter, err := container.ParseNetemFilter(filter.Targets, filter.Sources, filter.Ports, filter.SrcPorts, filter.Protocol)
+	if err != nil {
 		return nil, err
 	}
-	// get netem corrupt variation
-	if correlation < 0.0 || correlation > 100.0 {
-		err = errors.New("invalid corrupt correlation: must be between 0.0 and 100.0")
+	// validate netem corrupt percent and correlation
+	if err = validatePercent("corrupt", percent, correlation); err != nil {
 		return nil, err
 	}
 
@@ -100,15 +101,18 @@ func NewCorruptCommand(client container.Client,
 		client:      client,
 		names:       names,
 		pattern:     pattern,
+		labels:      labels,
 		iface:       iface,
-		ips:         ips,
-		port:		 port,
+		network:     network,
+		direction:   direction,
+		filter:      netemFilter,
 		duration:    duration,
 		percent:     percent,
 		correlation: correlation,
 		image:       image,
 		pull:        pull,
 		limit:       limit,
+		perService:  perService,
 		dryRun:      dryRun,
 	}, nil
 }
@@ -117,11 +121,13 @@ func NewCorruptCommand(client container.Client,
 func (n *CorruptCommand) Run(ctx context.Context, random bool) error {
 	log.Debug("adding network random packet corrupt to all matching containers")
 	log.WithFields(log.Fields{
-		"names":   n.names,
-		"pattern": n.pattern,
-		"limit":   n.limit,
+		"names":      n.names,
+		"pattern":    n.pattern,
+		"labels":     n.labels,
+		"limit":      n.limit,
+		"perService": n.perService,
 	}).Debug("listing matching containers")
-	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.limit)
+	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.labels, n.limit, n.perService)
 	if err != nil {
 		log.WithError(err).Error("failed to list containers")
 		return err
@@ -140,10 +146,7 @@ func (n *CorruptCommand) Run(ctx context.Context, random bool) error {
 	}
 
 	// prepare netem corrupt command
-	netemCmd := []string{"corrupt", strconv.FormatFloat(n.percent, 'f', 2, 64)}
-	if n.correlation > 0 {
-		netemCmd = append(netemCmd, strconv.FormatFloat(n.correlation, 'f', 2, 64))
-	}
+	netemCmd := percentArgs("corrupt", n.percent, n.correlation)
 
 	// run netem corrupt command for selected containers
 	var wg sync.WaitGroup
@@ -158,7 +161,7 @@ func (n *CorruptCommand) Run(ctx context.Context, random bool) error {
 		wg.Add(1)
 		go func(i int, c container.Container) {
 			defer wg.Done()
-			errors[i] = runNetem(netemCtx, n.client, c, n.iface, netemCmd, n.ips, n.port, n.duration, n.image, n.pull, n.dryRun)
+			errors[i] = runNetem(netemCtx, n.client, c, n.iface, n.network, n.direction, netemCmd, n.filter, n.duration, n.image, n.pull, n.dryRun)
 			if errors[i] != nil {
 				log.WithError(errors[i]).Error("failed to set packet corrupt for container")
 			}
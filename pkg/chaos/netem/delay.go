@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net"
 	"regexp"
 	"strconv"
 	"sync"
@@ -17,27 +16,72 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// DefaultRampStep default interval of ramped delay time change
+	DefaultRampStep = "10s"
+)
+
 var (
 	// DelayDistribution netem delay distributions
 	delayDistribution = []string{"", "uniform", "normal", "pareto", "paretonormal"}
 )
 
+// validate delay time, jitter, correlation and distribution
+func validateDelay(time int, jitter int, correlation float64, distribution string) error {
+	// check delay time
+	if time <= 0 {
+		return errors.New("non-positive delay time")
+	}
+	// get delay variation
+	if jitter < 0 || jitter > time {
+		return errors.New("invalid delay jitter: must be non-negative and smaller than delay time")
+	}
+	// get delay variation
+	if correlation < 0.0 || correlation > 100.0 {
+		return errors.New("invalid delay correlation: must be between 0.0 and 100.0")
+	}
+	// get distribution
+	if ok := util.SliceContains(delayDistribution, distribution); !ok {
+		return errors.New("Invalid delay distribution: must be one of {uniform | normal | pareto |  paretonormal}")
+	}
+	return nil
+}
+
+// netem delay arguments: 'delay <time>ms [<jitter>ms [<correlation>]] [distribution <distribution>]'
+func delayArgs(time int, jitter int, correlation float64, distribution string) []string {
+	args := []string{"delay", strconv.Itoa(time) + "ms"}
+	if jitter > 0 {
+		args = append(args, strconv.Itoa(jitter)+"ms")
+	}
+	if correlation > 0 {
+		args = append(args, strconv.FormatFloat(correlation, 'f', 2, 64))
+	}
+	if distribution != "" {
+		args = append(args, "distribution", distribution)
+	}
+	return args
+}
+
 // DelayCommand `netem delay` command
 type DelayCommand struct {
 	client       container.Client
 	names        []string
 	pattern      string
+	labels       []string
 	iface        string
-	ips          []*net.IPNet
-	port         uint16
+	network      string
+	direction    string
+	filter       container.NetemFilter
 	duration     time.Duration
 	time         int
 	jitter       int
 	correlation  float64
 	distribution string
+	schedule     []delayPoint
 	image        string
 	pull         bool
 	limit        int
+	perService   bool
 	dryRun       bool
 }
 
@@ -45,18 +89,24 @@ type DelayCommand struct {
 func NewDelayCommand(client container.Client,
 	names []string, // containers
 	pattern string, // re2 regex pattern
+	labels []string, // label selectors
 	iface string, // network interface
-	ipsList []string, // list of target ips
-	port uint16,
+	network string, // Docker network to resolve network interface on; implies 'auto' interface
+	direction string, // traffic direction: egress, ingress or both
+	filter Filter, // traffic filter: target and source IPs, ports and protocol
 	durationStr string, // chaos duration
 	intervalStr string, // repeatable chaos interval
 	time int, // delay time
 	jitter int, // delay jitter
 	correlation float64, // delay correlation
 	distribution string, // delay distribution
+	rampTo int, // ramp delay time to target delay time during chaos duration
+	rampStepStr string, // ramp step: change delay time every step
+	scheduleStr string, // delay schedule: comma separated list of '<time>@<offset>'
 	image string, // traffic control image
 	pull bool, // pull tc image option
 	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
 	dryRun bool, // dry-run do not delay just log
 ) (chaos.Command, error) {
 	// log error
@@ -84,52 +134,56 @@ func NewDelayCommand(client container.Client,
 		err = fmt.Errorf("bad network interface name: must match '%s'", reInterface.String())
 		return nil, err
 	}
-	// validate ips
-	var ips []*net.IPNet
-	for _, str := range ipsList {
-		ip := util.ParseCIDR(str)
-		if ip == nil {
-			err = fmt.Errorf("bad target: '%s' is not a valid IP", str)
-			return nil, err
-		}
-		ips = append(ips, ip)
+	// resolve network interface inside container on Docker network
+	if network != "" {
+		iface = container.AutoInterface
 	}
-	// check delay time
-	if time <= 0 {
-		err = errors.New("non-positive delay time")
+	// validate traffic direction
+	direction, err = parseDirection(direction)
+	if err != nil {
 		return nil, err
 	}
-	// get delay variation
-	if jitter < 0 || jitter > time {
-		err = errors.New("invalid delay jitter: must be non-negative and smaller than delay time")
+	// validate traffic filter
+	netemFilter, err := container.ParseNetemFilter(filter.Targets, filter.Sources, filter.Ports, filter.SrcPorts, filter.Protocol)
+	if err != nil {
 		return nil, err
 	}
-	// get delay variation
-	if correlation < 0.0 || correlation > 100.0 {
-		err = errors.New("invalid delay correlation: must be between 0.0 and 100.0")
+	// validate delay parameters
+	if err = validateDelay(time, jitter, correlation, distribution); err != nil {
 		return nil, err
 	}
-	// get distribution
-	if ok := util.SliceContains(delayDistribution, distribution); !ok {
-		err = errors.New("Invalid delay distribution: must be one of {uniform | normal | pareto |  paretonormal}")
+	// get delay schedule: ramp or explicit schedule
+	schedule, err := delaySchedule(time, rampTo, rampStepStr, scheduleStr, duration)
+	if err != nil {
 		return nil, err
 	}
+	// validate every scheduled delay time with the same jitter, correlation and distribution
+	for _, p := range schedule {
+		if err = validateDelay(p.time, jitter, correlation, distribution); err != nil {
+			err = fmt.Errorf("invalid delay at %s: %s", p.offset, err)
+			return nil, err
+		}
+	}
 
 	return &DelayCommand{
 		client:       client,
 		names:        names,
 		pattern:      pattern,
+		labels:       labels,
 		iface:        iface,
-		ips:          ips,
-		port:		  port,
+		network:      network,
+		direction:    direction,
+		filter:       netemFilter,
 		duration:     duration,
 		time:         time,
 		jitter:       jitter,
 		correlation:  correlation,
 		distribution: distribution,
+		schedule:     schedule,
 		image:        image,
 		pull:         pull,
 		limit:        limit,
+		perService:   perService,
 		dryRun:       dryRun,
 	}, nil
 }
@@ -138,11 +192,13 @@ func NewDelayCommand(client container.Client,
 func (n *DelayCommand) Run(ctx context.Context, random bool) error {
 	log.Debug("adding network delay to all matching containers")
 	log.WithFields(log.Fields{
-		"names":   n.names,
-		"pattern": n.pattern,
-		"limit":   n.limit,
+		"names":      n.names,
+		"pattern":    n.pattern,
+		"labels":     n.labels,
+		"limit":      n.limit,
+		"perService": n.perService,
 	}).Debug("listing matching containers")
-	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.limit)
+	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.labels, n.limit, n.perService)
 	if err != nil {
 		log.WithError(err).Error("failed to list containers")
 		return err
@@ -161,15 +217,11 @@ func (n *DelayCommand) Run(ctx context.Context, random bool) error {
 	}
 
 	// prepare netem command
-	netemCmd := []string{"delay", strconv.Itoa(n.time) + "ms"}
-	if n.jitter > 0 {
-		netemCmd = append(netemCmd, strconv.Itoa(n.jitter)+"ms")
-	}
-	if n.correlation > 0 {
-		netemCmd = append(netemCmd, strconv.FormatFloat(n.correlation, 'f', 2, 64))
-	}
-	if n.distribution != "" {
-		netemCmd = append(netemCmd, []string{"distribution", n.distribution}...)
+	netemCmd := delayArgs(n.time, n.jitter, n.correlation, n.distribution)
+	// prepare netem delay changes for delay schedule
+	var steps []netemStep
+	for _, p := range n.schedule {
+		steps = append(steps, netemStep{offset: p.offset, cmd: delayArgs(p.time, n.jitter, n.correlation, n.distribution)})
 	}
 
 	// run netem delay command for selected containers
@@ -185,7 +237,7 @@ func (n *DelayCommand) Run(ctx context.Context, random bool) error {
 		wg.Add(1)
 		go func(i int, c container.Container) {
 			defer wg.Done()
-			errors[i] = runNetem(netemCtx, n.client, c, n.iface, netemCmd, n.ips, n.port, n.duration, n.image, n.pull, n.dryRun)
+			errors[i] = runNetemSteps(netemCtx, n.client, c, n.iface, n.network, n.direction, netemCmd, steps, n.filter, n.duration, n.image, n.pull, n.dryRun)
 			if errors[i] != nil {
 				log.WithField("container", c).WithError(errors[i]).Error("failed to delay network for container")
 			}
@@ -18,18 +18,23 @@ func TestNewDelayCommand(t *testing.T) {
 	type args struct {
 		names        []string
 		pattern      string
+		labels       []string
 		iface        string
-		ipsList      []string
-		port 		 uint16
+		direction    string
+		filter       Filter
 		durationStr  string
 		intervalStr  string
 		time         int
 		jitter       int
 		correlation  float64
 		distribution string
+		rampTo       int
+		rampStep     string
+		schedule     string
 		image        string
 		pull         bool
 		limit        int
+		perService   bool
 		dryRun       bool
 	}
 	tests := []struct {
@@ -44,7 +49,7 @@ func TestNewDelayCommand(t *testing.T) {
 				names:        []string{"n1", "n2"},
 				pattern:      "re2:test",
 				iface:        "testIface",
-				ipsList:      []string{"1.2.3.4", "5.6.7.8"},
+				filter:       Filter{Targets: []string{"1.2.3.4", "5.6.7.8"}},
 				intervalStr:  "1m",
 				durationStr:  "30s",
 				time:         10,
@@ -59,7 +64,8 @@ func TestNewDelayCommand(t *testing.T) {
 				names:        []string{"n1", "n2"},
 				pattern:      "re2:test",
 				iface:        "testIface",
-				ips:          []*net.IPNet{util.ParseCIDR("1.2.3.4"), util.ParseCIDR("5.6.7.8")},
+				direction:    "egress",
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("1.2.3.4"), util.ParseCIDR("5.6.7.8")}},
 				duration:     30 * time.Second,
 				time:         10,
 				jitter:       2,
@@ -69,6 +75,28 @@ func TestNewDelayCommand(t *testing.T) {
 				limit:        2,
 			},
 		},
+		{
+			name: "create Delay command with mixed IPv4 and IPv6 targets",
+			args: args{
+				names:       []string{"n1"},
+				iface:       "eth0",
+				filter:      Filter{Targets: []string{"10.0.0.1", "fd00::1", "2001:db8::/64"}},
+				durationStr: "30s",
+				time:        10,
+			},
+			want: &DelayCommand{
+				names:     []string{"n1"},
+				iface:     "eth0",
+				direction: "egress",
+				filter: container.NetemFilter{Targets: []*net.IPNet{
+					{IP: net.IP{10, 0, 0, 1}, Mask: net.CIDRMask(32, 32)},
+					{IP: net.ParseIP("fd00::1"), Mask: net.CIDRMask(128, 128)},
+					{IP: net.ParseIP("2001:db8::"), Mask: net.CIDRMask(64, 128)},
+				}},
+				duration: 30 * time.Second,
+				time:     10,
+			},
+		},
 		{
 			name: "bad interval value",
 			args: args{
@@ -101,13 +129,23 @@ func TestNewDelayCommand(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "bad traffic direction",
+			args: args{
+				intervalStr: "1m",
+				durationStr: "30s",
+				iface:       "eth0",
+				direction:   "sideways",
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid CIDR IP address",
 			args: args{
 				intervalStr: "1m",
 				durationStr: "30s",
 				iface:       "eth0",
-				ipsList:     []string{"1.2.3.4/3.4.5.6..."},
+				filter:      Filter{Targets: []string{"1.2.3.4/3.4.5.6..."}},
 			},
 			wantErr: true,
 		},
@@ -117,7 +155,7 @@ func TestNewDelayCommand(t *testing.T) {
 				intervalStr: "1m",
 				durationStr: "30s",
 				iface:       "eth0",
-				ipsList:     []string{"1.2.3.4.5.6..."},
+				filter:      Filter{Targets: []string{"1.2.3.4.5.6..."}},
 			},
 			wantErr: true,
 		},
@@ -127,7 +165,7 @@ func TestNewDelayCommand(t *testing.T) {
 				intervalStr: "1m",
 				durationStr: "30s",
 				iface:       "eth0",
-				ipsList:     []string{"1.2.3.4"},
+				filter:      Filter{Targets: []string{"1.2.3.4"}},
 				time:        -1,
 			},
 			wantErr: true,
@@ -138,7 +176,7 @@ func TestNewDelayCommand(t *testing.T) {
 				intervalStr: "1m",
 				durationStr: "30s",
 				iface:       "eth0",
-				ipsList:     []string{"1.2.3.4"},
+				filter:      Filter{Targets: []string{"1.2.3.4"}},
 				time:        1,
 				jitter:      -1,
 			},
@@ -150,7 +188,7 @@ func TestNewDelayCommand(t *testing.T) {
 				intervalStr: "1m",
 				durationStr: "30s",
 				iface:       "eth0",
-				ipsList:     []string{"1.2.3.4"},
+				filter:      Filter{Targets: []string{"1.2.3.4"}},
 				time:        1,
 				jitter:      2,
 			},
@@ -162,7 +200,7 @@ func TestNewDelayCommand(t *testing.T) {
 				intervalStr: "1m",
 				durationStr: "30s",
 				iface:       "eth0",
-				ipsList:     []string{"1.2.3.4"},
+				filter:      Filter{Targets: []string{"1.2.3.4"}},
 				time:        10,
 				jitter:      2,
 				correlation: 101.0,
@@ -175,7 +213,7 @@ func TestNewDelayCommand(t *testing.T) {
 				intervalStr:  "1m",
 				durationStr:  "30s",
 				iface:        "eth0",
-				ipsList:      []string{"1.2.3.4"},
+				filter:       Filter{Targets: []string{"1.2.3.4"}},
 				time:         10,
 				jitter:       2,
 				correlation:  11.0,
@@ -183,11 +221,71 @@ func TestNewDelayCommand(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "create Delay command with delay ramp",
+			args: args{
+				names:       []string{"n1"},
+				iface:       "eth0",
+				durationStr: "1m",
+				time:        10,
+				rampTo:      500,
+				rampStep:    "20s",
+			},
+			want: &DelayCommand{
+				names:     []string{"n1"},
+				iface:     "eth0",
+				direction: "egress",
+				duration:  time.Minute,
+				time:      10,
+				schedule:  []delayPoint{{offset: 20 * time.Second, time: 255}, {offset: 40 * time.Second, time: 500}},
+			},
+		},
+		{
+			name: "create Delay command with delay schedule",
+			args: args{
+				names:       []string{"n1"},
+				iface:       "eth0",
+				durationStr: "5m",
+				time:        10,
+				schedule:    "100@1m, 500@3m",
+			},
+			want: &DelayCommand{
+				names:     []string{"n1"},
+				iface:     "eth0",
+				direction: "egress",
+				duration:  5 * time.Minute,
+				time:      10,
+				schedule:  []delayPoint{{offset: time.Minute, time: 100}, {offset: 3 * time.Minute, time: 500}},
+			},
+		},
+		{
+			name: "delay ramp and schedule together",
+			args: args{
+				iface:       "eth0",
+				durationStr: "5m",
+				time:        10,
+				rampTo:      500,
+				rampStep:    "10s",
+				schedule:    "100@1m",
+			},
+			wantErr: true,
+		},
+		{
+			name: "scheduled delay smaller than jitter",
+			args: args{
+				iface:       "eth0",
+				durationStr: "5m",
+				time:        10,
+				jitter:      5,
+				schedule:    "2@1m",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// invoke
-			got, err := NewDelayCommand(nil, tt.args.names, tt.args.pattern, tt.args.iface, tt.args.ipsList, tt.args.port, tt.args.durationStr, tt.args.intervalStr, tt.args.time, tt.args.jitter, tt.args.correlation, tt.args.distribution, tt.args.image, tt.args.pull, tt.args.limit, tt.args.dryRun)
+			got, err := NewDelayCommand(nil, tt.args.names, tt.args.pattern, tt.args.labels, tt.args.iface, "", tt.args.direction, tt.args.filter, tt.args.durationStr, tt.args.intervalStr, tt.args.time, tt.args.jitter, tt.args.correlation, tt.args.distribution, tt.args.rampTo, tt.args.rampStep, tt.args.schedule, tt.args.image, tt.args.pull, tt.args.limit, tt.args.perService, tt.args.dryRun)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewDelayCommand() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -207,9 +305,10 @@ func TestDelayCommand_Run(t *testing.T) {
 	type fields struct {
 		names        []string
 		pattern      string
+		labels       []string
 		iface        string
-		ips          []*net.IPNet
-		port		 uint16
+		direction    string
+		filter       container.NetemFilter
 		duration     time.Duration
 		time         int
 		jitter       int
@@ -218,6 +317,7 @@ func TestDelayCommand_Run(t *testing.T) {
 		image        string
 		pull         bool
 		limit        int
+		perService   bool
 		dryRun       bool
 	}
 	type args struct {
@@ -237,7 +337,7 @@ func TestDelayCommand_Run(t *testing.T) {
 			fields: fields{
 				names:        []string{"c1"},
 				iface:        "eth0",
-				ips:          []*net.IPNet{util.ParseCIDR("10.10.10.0/24")},
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.10.0/24")}},
 				duration:     10 * time.Microsecond,
 				time:         2,
 				jitter:       1,
@@ -252,7 +352,7 @@ func TestDelayCommand_Run(t *testing.T) {
 			fields: fields{
 				names:        []string{"c1"},
 				iface:        "eth0",
-				ips:          []*net.IPNet{util.ParseCIDR("10.10.10.10")},
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.10.10")}},
 				duration:     10 * time.Microsecond,
 				time:         2,
 				jitter:       1,
@@ -267,7 +367,7 @@ func TestDelayCommand_Run(t *testing.T) {
 			fields: fields{
 				names:        []string{"c1", "c2", "c3"},
 				iface:        "eth0",
-				ips:          []*net.IPNet{util.ParseCIDR("10.10.10.10")},
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.10.10")}},
 				duration:     10 * time.Microsecond,
 				time:         2,
 				jitter:       1,
@@ -282,7 +382,7 @@ func TestDelayCommand_Run(t *testing.T) {
 			fields: fields{
 				names:        []string{"c1", "c2", "c3"},
 				iface:        "eth0",
-				ips:          []*net.IPNet{util.ParseCIDR("10.10.10.10")},
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.10.10")}},
 				duration:     10 * time.Microsecond,
 				time:         2,
 				jitter:       1,
@@ -312,7 +412,7 @@ func TestDelayCommand_Run(t *testing.T) {
 			fields: fields{
 				names:        []string{"c1", "c2", "c3"},
 				iface:        "eth0",
-				ips:          []*net.IPNet{util.ParseCIDR("10.10.10.10")},
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.10.10")}},
 				duration:     10 * time.Microsecond,
 				time:         2,
 				jitter:       1,
@@ -332,9 +432,10 @@ func TestDelayCommand_Run(t *testing.T) {
 				client:       mockClient,
 				names:        tt.fields.names,
 				pattern:      tt.fields.pattern,
+				labels:       tt.fields.labels,
 				iface:        tt.fields.iface,
-				ips:          tt.fields.ips,
-				port:		  tt.fields.port,
+				direction:    tt.fields.direction,
+				filter:       tt.fields.filter,
 				duration:     tt.fields.duration,
 				time:         tt.fields.time,
 				jitter:       tt.fields.jitter,
@@ -342,10 +443,11 @@ func TestDelayCommand_Run(t *testing.T) {
 				distribution: tt.fields.distribution,
 				image:        tt.fields.image,
 				limit:        tt.fields.limit,
+				perService:   tt.fields.perService,
 				dryRun:       tt.fields.dryRun,
 			}
 			// mock calls
-			call := mockClient.On("ListContainers", mock.AnythingOfType("*context.emptyCtx"), mock.AnythingOfType("container.Filter"))
+			call := mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter"))
 			if tt.errs.listError {
 				call.Return(tt.expected, errors.New("ERROR"))
 				goto Invoke
@@ -356,19 +458,19 @@ func TestDelayCommand_Run(t *testing.T) {
 				}
 			}
 			if tt.args.random {
-				mockClient.On("NetemContainer", mock.AnythingOfType("*context.cancelCtx"), mock.AnythingOfType("container.Container"), tt.fields.iface, tt.cmd, tt.fields.ips, tt.fields.port, tt.fields.duration, tt.fields.image, tt.fields.pull, tt.fields.dryRun).Return(nil)
-				mockClient.On("StopNetemContainer", mock.AnythingOfType("*context.emptyCtx"), mock.AnythingOfType("container.Container"), tt.fields.iface, tt.fields.ips, tt.fields.port, tt.fields.image, tt.fields.pull, tt.fields.dryRun).Return(nil)
+				mockClient.On("NetemContainer", mock.AnythingOfType("*context.cancelCtx"), mock.AnythingOfType("container.Container"), tt.fields.iface, tt.fields.direction, tt.cmd, tt.fields.filter, tt.fields.duration, tt.fields.image, tt.fields.pull, tt.fields.dryRun).Return(nil)
+				mockClient.On("StopNetemContainer", mock.Anything, mock.AnythingOfType("container.Container"), tt.fields.iface, tt.fields.direction, tt.fields.filter, tt.fields.image, tt.fields.pull, tt.fields.dryRun).Return(nil)
 			} else {
 				for i := range tt.expected {
 					if tt.fields.limit == 0 || i < tt.fields.limit {
-						call = mockClient.On("NetemContainer", mock.AnythingOfType("*context.cancelCtx"), mock.AnythingOfType("container.Container"), tt.fields.iface, tt.cmd, tt.fields.ips, tt.fields.port, tt.fields.duration, tt.fields.image, tt.fields.pull, tt.fields.dryRun)
+						call = mockClient.On("NetemContainer", mock.AnythingOfType("*context.cancelCtx"), mock.AnythingOfType("container.Container"), tt.fields.iface, tt.fields.direction, tt.cmd, tt.fields.filter, tt.fields.duration, tt.fields.image, tt.fields.pull, tt.fields.dryRun)
 						if tt.errs.netemError {
 							call.Return(errors.New("ERROR"))
 							goto Invoke
 						} else {
 							call.Return(nil)
 						}
-						mockClient.On("StopNetemContainer", mock.AnythingOfType("*context.emptyCtx"), mock.AnythingOfType("container.Container"), tt.fields.iface, tt.fields.ips, tt.fields.port, tt.fields.image, tt.fields.pull, tt.fields.dryRun).Return(nil)
+						mockClient.On("StopNetemContainer", mock.Anything, mock.AnythingOfType("container.Container"), tt.fields.iface, tt.fields.direction, tt.fields.filter, tt.fields.image, tt.fields.pull, tt.fields.dryRun).Return(nil)
 					}
 				}
 			}
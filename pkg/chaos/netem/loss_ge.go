@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net"
 	"regexp"
 	"strconv"
 	"sync"
@@ -19,30 +18,35 @@ import (
 
 // LossGECommand `netem loss gemodel` (Gilbert-Elliot model) command
 type LossGECommand struct {
-	client   container.Client
-	names    []string
-	pattern  string
-	iface    string
-	ips      []*net.IPNet
-	port     uint16
-	duration time.Duration
-	pg       float64
-	pb       float64
-	oneH     float64
-	oneK     float64
-	image    string
-	pull     bool
-	limit    int
-	dryRun   bool
+	client     container.Client
+	names      []string
+	pattern    string
+	labels     []string
+	iface      string
+	network    string
+	direction  string
+	filter     container.NetemFilter
+	duration   time.Duration
+	pg         float64
+	pb         float64
+	oneH       float64
+	oneK       float64
+	image      string
+	pull       bool
+	limit      int
+	perService bool
+	dryRun     bool
 }
 
 // NewLossGECommand create new netem loss gemodel (Gilbert-Elliot) command
 func NewLossGECommand(client container.Client,
 	names []string, // containers
 	pattern string, // re2 regex pattern
+	labels []string, // label selectors
 	iface string, // network interface
-	ipsList []string, // list of target ips
-	port    uint16,
+	network string, // Docker network to resolve network interface on; implies 'auto' interface
+	direction string, // traffic direction: egress, ingress or both
+	filter Filter, // traffic filter: target and source IPs, ports and protocol
 	durationStr string, // chaos duration
 	intervalStr string, // repeatable chaos interval
 	pg float64, // Good State transition probability
@@ -52,6 +56,7 @@ func NewLossGECommand(client container.Client,
 	image string, // traffic control image
 	pull bool, // pull tc image
 	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
 	dryRun bool, // dry-run do not netem just log
 ) (chaos.Command, error) {
 	// log error
@@ -79,15 +84,19 @@ func NewLossGECommand(client container.Client,
 		err = fmt.Errorf("bad network interface name: must match '%s'", reInterface.String())
 		return nil, err
 	}
-	// validate ips
-	var ips []*net.IPNet
-	for _, str := range ipsList {
-		ip := util.ParseCIDR(str)
-		if ip == nil {
-			err = fmt.Errorf("bad target: '%s' is not a valid IP", str)
-			return nil, err
-		}
-		ips = append(ips, ip)
+	// resolve network interface inside container on Docker network
+	if network != "" {
+		iface = container.AutoInterface
+	}
+	// validate traffic direction
+	direction, err = parseDirection(direction)
+	if err != nil {
+		return nil, err
+	}
+	// validate traffic filter
+	netemFilter, err := container.ParseNetemFilter(filter.Targets, filter.Sources, filter.Ports, filter.SrcPorts, filter.Protocol)
+	if err != nil {
+		return nil, err
 	}
 	// get pg - Good State transition probability
 	if pg < 0.0 || pg > 100.0 {
@@ -115,21 +124,24 @@ func NewLossGECommand(client container.Client,
 	}
 
 	return &LossGECommand{
-		client:   client,
-		names:    names,
-		pattern:  pattern,
-		iface:    iface,
-		ips:      ips,
-		port:	  port,
-		duration: duration,
-		pg:       pg,
-		pb:       pb,
-		oneH:     oneH,
-		oneK:     oneK,
-		image:    image,
-		pull:     pull,
-		limit:    limit,
-		dryRun:   dryRun,
+		client:     client,
+		names:      names,
+		pattern:    pattern,
+		labels:     labels,
+		iface:      iface,
+		network:    network,
+		direction:  direction,
+		filter:     netemFilter,
+		duration:   duration,
+		pg:         pg,
+		pb:         pb,
+		oneH:       oneH,
+		oneK:       oneK,
+		image:      image,
+		pull:       pull,
+		limit:      limit,
+		perService: perService,
+		dryRun:     dryRun,
 	}, nil
 }
 
@@ -137,11 +149,13 @@ func NewLossGECommand(client container.Client,
 func (n *LossGECommand) Run(ctx context.Context, random bool) error {
 	log.Debug("adding network packet loss according Gilbert-Elliot model to all matching containers")
 	log.WithFields(log.Fields{
-		"names":   n.names,
-		"pattern": n.pattern,
-		"limit":   n.limit,
+		"names":      n.names,
+		"pattern":    n.pattern,
+		"labels":     n.labels,
+		"limit":      n.limit,
+		"perService": n.perService,
 	}).Debug("listing matching containers")
-	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.limit)
+	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.labels, n.limit, n.perService)
 	if err != nil {
 		log.WithError(err).Error("failed to list containers")
 		return err
@@ -178,7 +192,7 @@ func (n *LossGECommand) Run(ctx context.Context, random bool) error {
 		wg.Add(1)
 		go func(i int, c container.Container) {
 			defer wg.Done()
-			errors[i] = runNetem(netemCtx, n.client, c, n.iface, netemCmd, n.ips, n.port, n.duration, n.image, n.pull, n.dryRun)
+			errors[i] = runNetem(netemCtx, n.client, c, n.iface, n.network, n.direction, netemCmd, n.filter, n.duration, n.image, n.pull, n.dryRun)
 			if errors[i] != nil {
 				log.WithError(errors[i]).Error("failed to set packet loss for container")
 			}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net"
 	"regexp"
 	"strconv"
 	"sync"
@@ -19,31 +18,36 @@ import (
 
 // LossStateCommand `netem loss state` command
 type LossStateCommand struct {
-	client   container.Client
-	names    []string
-	pattern  string
-	iface    string
-	ips      []*net.IPNet
-	port     uint16
-	duration time.Duration
-	p13      float64
-	p31      float64
-	p32      float64
-	p23      float64
-	p14      float64
-	image    string
-	pull     bool
-	limit    int
-	dryRun   bool
+	client     container.Client
+	names      []string
+	pattern    string
+	labels     []string
+	iface      string
+	network    string
+	direction  string
+	filter     container.NetemFilter
+	duration   time.Duration
+	p13        float64
+	p31        float64
+	p32        float64
+	p23        float64
+	p14        float64
+	image      string
+	pull       bool
+	limit      int
+	perService bool
+	dryRun     bool
 }
 
 // NewLossStateCommand create new netem loss state command
 func NewLossStateCommand(client container.Client,
 	names []string, // containers
 	pattern string, // re2 regex pattern
+	labels []string, // label selectors
 	iface string, // network interface
-	ipsList []string, // list of target ips
-	port    uint16,
+	network string, // Docker network to resolve network interface on; implies 'auto' interface
+	direction string, // traffic direction: egress, ingress or both
+	filter Filter, // traffic filter: target and source IPs, ports and protocol
 	durationStr string, // chaos duration
 	intervalStr string, // repeatable chaos interval
 	p13 float64, // probability to go from state (1) to state (3)
@@ -54,6 +58,7 @@ func NewLossStateCommand(client container.Client,
 	image string, // traffic control image
 	pull bool, // pull tc image
 	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
 	dryRun bool, // dry-run do not netem just log
 ) (chaos.Command, error) {
 	// log error
@@ -81,15 +86,19 @@ func NewLossStateCommand(client container.Client,
 		err = fmt.Errorf("bad network interface name: must match '%s'", reInterface.String())
 		return nil, err
 	}
-	// validate ips
-	var ips []*net.IPNet
-	for _, str := range ipsList {
-		ip := util.ParseCIDR(str)
-		if ip == nil {
-			err = fmt.Errorf("bad target: '%s' is not a valid IP", str)
-			return nil, err
-		}
-		ips = append(ips, ip)
+	// resolve network interface inside container on Docker network
+	if network != "" {
+		iface = container.AutoInterface
+	}
+	// validate traffic direction
+	direction, err = parseDirection(direction)
+	if err != nil {
+		return nil, err
+	}
+	// validate traffic filter
+	netemFilter, err := container.ParseNetemFilter(filter.Targets, filter.Sources, filter.Ports, filter.SrcPorts, filter.Protocol)
+	if err != nil {
+		return nil, err
 	}
 
 	// validate p13
@@ -124,22 +133,25 @@ func NewLossStateCommand(client container.Client,
 	}
 
 	return &LossStateCommand{
-		client:   client,
-		names:    names,
-		pattern:  pattern,
-		iface:    iface,
-		ips:      ips,
-		port:     port,
-		duration: duration,
-		p13:      p13,
-		p31:      p31,
-		p32:      p32,
-		p23:      p23,
-		p14:      p14,
-		image:    image,
-		pull:     pull,
-		limit:    limit,
-		dryRun:   dryRun,
+		client:     client,
+		names:      names,
+		pattern:    pattern,
+		labels:     labels,
+		iface:      iface,
+		network:    network,
+		direction:  direction,
+		filter:     netemFilter,
+		duration:   duration,
+		p13:        p13,
+		p31:        p31,
+		p32:        p32,
+		p23:        p23,
+		p14:        p14,
+		image:      image,
+		pull:       pull,
+		limit:      limit,
+		perService: perService,
+		dryRun:     dryRun,
 	}, nil
 }
 
@@ -147,11 +159,13 @@ func NewLossStateCommand(client container.Client,
 func (n *LossStateCommand) Run(ctx context.Context, random bool) error {
 	log.Debug("adding network packet loss according 4-state Markov model to all matching containers")
 	log.WithFields(log.Fields{
-		"names":   n.names,
-		"pattern": n.pattern,
-		"limit":   n.limit,
+		"names":      n.names,
+		"pattern":    n.pattern,
+		"labels":     n.labels,
+		"limit":      n.limit,
+		"perService": n.perService,
 	}).Debug("listing matching containers")
-	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.limit)
+	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.labels, n.limit, n.perService)
 	if err != nil {
 		log.WithError(err).Error("failed to list containers")
 		return err
@@ -189,7 +203,7 @@ func (n *LossStateCommand) Run(ctx context.Context, random bool) error {
 		wg.Add(1)
 		go func(i int, c container.Container) {
 			defer wg.Done()
-			errors[i] = runNetem(netemCtx, n.client, c, n.iface, netemCmd, n.ips, n.port, n.duration, n.image, n.pull, n.dryRun)
+			errors[i] = runNetem(netemCtx, n.client, c, n.iface, n.network, n.direction, netemCmd, n.filter, n.duration, n.image, n.pull, n.dryRun)
 			if errors[i] != nil {
 				log.WithError(errors[i]).Error("failed to set packet loss for container")
 			}
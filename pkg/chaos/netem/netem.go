@@ -2,60 +2,150 @@ package netem
 
 import (
 	"context"
-	"net"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/metrics"
 	log "github.com/sirupsen/logrus"
 )
 
+// parse traffic direction: egress (default), ingress or both
+func parseDirection(direction string) (string, error) {
+	switch direction {
+	case "":
+		return container.DirectionEgress, nil
+	case container.DirectionEgress, container.DirectionIngress, container.DirectionBoth:
+		return direction, nil
+	}
+	return "", fmt.Errorf("invalid traffic direction: must be one of {%s | %s | %s}", container.DirectionEgress, container.DirectionIngress, container.DirectionBoth)
+}
+
+// validate netem percent and correlation for loss, duplicate and corrupt
+func validatePercent(kind string, percent float64, correlation float64) error {
+	if percent < 0.0 || percent > 100.0 {
+		return fmt.Errorf("invalid %s percent: must be between 0.0 and 100.0", kind)
+	}
+	if correlation < 0.0 || correlation > 100.0 {
+		return fmt.Errorf("invalid %s correlation: must be between 0.0 and 100.0", kind)
+	}
+	return nil
+}
+
+// netem percent arguments: '<kind> <percent> [<correlation>]'
+func percentArgs(kind string, percent float64, correlation float64) []string {
+	args := []string{kind, strconv.FormatFloat(percent, 'f', 2, 64)}
+	if correlation > 0 {
+		args = append(args, strconv.FormatFloat(correlation, 'f', 2, 64))
+	}
+	return args
+}
+
+// Filter netem traffic filter options: only matching traffic is affected by netem
+type Filter struct {
+	Targets  []string // target IPs (supports CIDR notation) or containers: 'container:<name>' or 're2:<pattern>'
+	Sources  []string // source IPs; supports CIDR notation
+	Ports    string   // target ports: comma separated list of ports and port ranges
+	SrcPorts string   // source ports: comma separated list of ports and port ranges
+	Protocol string   // L4 protocol: tcp, udp or icmp
+}
+
 // run network emulation command, stop netem on timeout or abort
-func runNetem(ctx context.Context, client container.Client, container container.Container, netInterface string, cmd []string, ips []*net.IPNet, port uint16, duration time.Duration, tcimage string, pull bool, dryRun bool) error {
+func runNetem(ctx context.Context, client container.Client, container container.Container, netInterface string, network string, direction string, cmd []string, filter container.NetemFilter, duration time.Duration, tcimage string, pull bool, dryRun bool) error {
+	return runNetemSteps(ctx, client, container, netInterface, network, direction, cmd, nil, filter, duration, tcimage, pull, dryRun)
+}
+
+// run network emulation command, change netem parameters on schedule steps, stop netem on timeout or abort
+func runNetemSteps(ctx context.Context, client container.Client, c container.Container, netInterface string, network string, direction string, cmd []string, steps []netemStep, filter container.NetemFilter, duration time.Duration, tcimage string, pull bool, dryRun bool) error {
+	// resolve target containers into IPs just before installing filters: container IPs change on restart
+	filter, err := container.ResolveNetemFilter(ctx, client, c, filter)
+	if err != nil {
+		log.WithError(err).Error("failed to resolve netem target containers")
+		return err
+	}
+	// resolve network interface inside container network namespace
+	if netInterface == container.AutoInterface {
+		netInterface, err = client.NetworkInterface(ctx, c, network, filter.Targets, tcimage, pull)
+		if err != nil {
+			log.WithError(err).Error("failed to resolve network interface")
+			return err
+		}
+	}
 	log.WithFields(log.Fields{
-		"id":       container.ID(),
-		"name":     container.Name(),
-		"iface":    netInterface,
-		"netem":    cmd,
-		"ips":      ips,
-		"port":		port,
-		"duration": duration,
-		"tc-image": tcimage,
-		"pull":     pull,
+		"id":        c.ID(),
+		"name":      c.Name(),
+		"iface":     netInterface,
+		"direction": direction,
+		"netem":     cmd,
+		"steps":     len(steps),
+		"filter":    filter.String(),
+		"duration":  duration,
+		"tc-image":  tcimage,
+		"pull":      pull,
 	}).Debug("running netem command")
-	var err error
-	err = client.NetemContainer(ctx, container, netInterface, cmd, ips, port, duration, tcimage, pull, dryRun)
+	err = client.NetemContainer(ctx, c, netInterface, direction, cmd, filter, duration, tcimage, pull, dryRun)
 	if err != nil {
 		log.WithError(err).Error("failed to start netem for container")
 		return err
 	}
+	metrics.NetemSessions.Inc()
+	defer metrics.NetemSessions.Dec()
 
 	// create new context with timeout for canceling
+	start := time.Now()
 	stopCtx, cancel := context.WithTimeout(context.Background(), duration)
 	defer cancel()
-	// wait for specified duration and then stop netem (where it applied) or stop on ctx.Done()
-	select {
-	case <-ctx.Done():
-		log.WithFields(log.Fields{
-			"id":       container.ID(),
-			"name":     container.Name(),
-			"iface":    netInterface,
-			"ips":      ips,
-			"port":		port,
-			"tc-image": tcimage,
-		}).Debug("stopping netem command on abort")
-		// use different context to stop netem since parent context is canceled
-		err = client.StopNetemContainer(context.Background(), container, netInterface, ips, port, tcimage, pull, dryRun)
-	case <-stopCtx.Done():
-		log.WithFields(log.Fields{
-			"id":       container.ID(),
-			"name":     container.Name(),
-			"iface":    netInterface,
-			"ips":      ips,
-			"port":		port,
-			"tc-image": tcimage,
-		}).Debug("stopping netem command on timout")
-		// use parent context to stop netem in container
-		err = client.StopNetemContainer(context.Background(), container, netInterface, ips, port, tcimage, pull, dryRun)
-	}
-	return err
+	// wait for specified duration and then stop netem (where it applied) or stop on ctx.Done();
+	// meanwhile change netem parameters of installed qdisc on every schedule step
+	timer := time.NewTimer(0)
+	<-timer.C
+	defer timer.Stop()
+	for {
+		var stepC <-chan time.Time
+		if len(steps) > 0 {
+			timer.Reset(steps[0].offset - time.Since(start))
+			stepC = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			log.WithFields(log.Fields{
+				"id":        c.ID(),
+				"name":      c.Name(),
+				"iface":     netInterface,
+				"direction": direction,
+				"filter":    filter.String(),
+				"tc-image":  tcimage,
+			}).Debug("stopping netem command on abort")
+			// use different context to stop netem since parent context is canceled
+			return client.StopNetemContainer(context.Background(), c, netInterface, direction, filter, tcimage, pull, dryRun)
+		case <-stopCtx.Done():
+			log.WithFields(log.Fields{
+				"id":        c.ID(),
+				"name":      c.Name(),
+				"iface":     netInterface,
+				"direction": direction,
+				"filter":    filter.String(),
+				"tc-image":  tcimage,
+			}).Debug("stopping netem command on timout")
+			// use parent context to stop netem in container
+			return client.StopNetemContainer(context.Background(), c, netInterface, direction, filter, tcimage, pull, dryRun)
+		case <-stepC:
+			log.WithFields(log.Fields{
+				"id":     c.ID(),
+				"name":   c.Name(),
+				"netem":  steps[0].cmd,
+				"offset": steps[0].offset,
+			}).Debug("changing netem command on schedule")
+			if err = client.ChangeNetemContainer(ctx, c, netInterface, direction, steps[0].cmd, filter, tcimage, pull, dryRun); err != nil {
+				log.WithError(err).Error("failed to change netem for container")
+				// do not leave netem behind: stop it and report change error
+				if e := client.StopNetemContainer(context.Background(), c, netInterface, direction, filter, tcimage, pull, dryRun); e != nil {
+					log.WithError(e).Error("failed to stop netem for container")
+				}
+				return err
+			}
+			steps = steps[1:]
+		}
+	}
 }
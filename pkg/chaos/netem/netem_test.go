@@ -20,9 +20,9 @@ func Test_runNetem(t *testing.T) {
 	type args struct {
 		container    container.Container
 		netInterface string
+		direction    string
 		cmd          []string
-		ips          []*net.IPNet
-		port		 uint16
+		filter       container.NetemFilter
 		duration     time.Duration
 		tcimage      string
 		pull         bool
@@ -44,7 +44,7 @@ func Test_runNetem(t *testing.T) {
 				),
 				netInterface: "testIface",
 				cmd:          []string{"test", "--test"},
-				ips:          []*net.IPNet{util.ParseCIDR("10.10.10.10")},
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.10.10")}},
 				duration:     time.Microsecond * 10,
 				tcimage:      "test/image",
 			},
@@ -58,7 +58,7 @@ func Test_runNetem(t *testing.T) {
 				),
 				netInterface: "testIface",
 				cmd:          []string{"test", "--test"},
-				ips:          []*net.IPNet{util.ParseCIDR("10.10.0.0/16")},
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.0.0/16")}},
 				duration:     time.Microsecond * 10,
 				tcimage:      "test/image",
 			},
@@ -72,8 +72,7 @@ func Test_runNetem(t *testing.T) {
 				),
 				netInterface: "testIface",
 				cmd:          []string{"test", "--test"},
-				ips:          []*net.IPNet{util.ParseCIDR("10.10.0.0/16")},
-				port:         8080,
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.0.0/16")}, Ports: []container.PortRange{{From: 8080, To: 8080}}},
 				duration:     time.Microsecond * 10,
 				tcimage:      "test/image",
 			},
@@ -87,7 +86,7 @@ func Test_runNetem(t *testing.T) {
 				),
 				netInterface: "testIface",
 				cmd:          []string{"test", "--test"},
-				ips:          []*net.IPNet{util.ParseCIDR("10.10.10.10")},
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.10.10")}},
 				duration:     time.Microsecond * 10,
 				tcimage:      "test/image",
 			},
@@ -102,7 +101,7 @@ func Test_runNetem(t *testing.T) {
 				),
 				netInterface: "testIface",
 				cmd:          []string{"test", "--test"},
-				ips:          []*net.IPNet{util.ParseCIDR("10.10.10.10")},
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.10.10")}},
 				duration:     time.Microsecond * 10,
 				tcimage:      "test/image",
 			},
@@ -118,7 +117,7 @@ func Test_runNetem(t *testing.T) {
 				),
 				netInterface: "testIface",
 				cmd:          []string{"test", "--test"},
-				ips:          []*net.IPNet{util.ParseCIDR("10.10.10.10")},
+				filter:       container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.10.10")}},
 				duration:     time.Microsecond * 10,
 				tcimage:      "test/image",
 			},
@@ -133,7 +132,7 @@ func Test_runNetem(t *testing.T) {
 			// create timeout context
 			ctx, cancel := context.WithCancel(context.TODO())
 			// set NetemContainer mock call
-			call := mockClient.On("NetemContainer", ctx, tt.args.container, tt.args.netInterface, tt.args.cmd, tt.args.ips, tt.args.port, tt.args.duration, tt.args.tcimage, tt.args.pull, tt.args.dryRun)
+			call := mockClient.On("NetemContainer", ctx, tt.args.container, tt.args.netInterface, tt.args.direction, tt.args.cmd, tt.args.filter, tt.args.duration, tt.args.tcimage, tt.args.pull, tt.args.dryRun)
 			if tt.errs.startErr {
 				call.Return(errors.New("test error"))
 				goto Invoke
@@ -141,7 +140,7 @@ func Test_runNetem(t *testing.T) {
 				call.Return(nil)
 			}
 			// set StopNetemContainer mock call
-			call = mockClient.On("StopNetemContainer", mock.AnythingOfType("*context.emptyCtx"), tt.args.container, tt.args.netInterface, tt.args.ips, tt.args.port, tt.args.tcimage, tt.args.pull, tt.args.dryRun)
+			call = mockClient.On("StopNetemContainer", mock.Anything, tt.args.container, tt.args.netInterface, tt.args.direction, tt.args.filter, tt.args.tcimage, tt.args.pull, tt.args.dryRun)
 			if tt.errs.stopErr {
 				call.Return(errors.New("test error"))
 			} else {
@@ -149,7 +148,7 @@ func Test_runNetem(t *testing.T) {
 			}
 			// invoke
 		Invoke:
-			if err := runNetem(ctx, mockClient, tt.args.container, tt.args.netInterface, tt.args.cmd, tt.args.ips, tt.args.port, tt.args.duration, tt.args.tcimage, tt.args.pull, tt.args.dryRun); (err != nil) != tt.wantErr {
+			if err := runNetem(ctx, mockClient, tt.args.container, tt.args.netInterface, "", tt.args.direction, tt.args.cmd, tt.args.filter, tt.args.duration, tt.args.tcimage, tt.args.pull, tt.args.dryRun); (err != nil) != tt.wantErr {
 				t.Errorf("runNetem() error = %v, wantErr %v", err, tt.wantErr)
 			}
 			// abort
@@ -165,3 +164,41 @@ func Test_runNetem(t *testing.T) {
 		})
 	}
 }
+
+func Test_runNetemAutoInterface(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolved string
+		err      error
+		wantErr  bool
+	}{
+		{
+			name:     "resolved interface",
+			resolved: "eth1",
+		},
+		{
+			name:    "resolve error",
+			err:     errors.New("test error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(container.MockClient)
+			c := container.CreateTestContainers(1)[0]
+			cmd := []string{"delay", "100ms"}
+			filter := container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.0.1.5")}}
+			duration := time.Millisecond
+			mockClient.On("NetworkInterface", mock.Anything, c, "backend", filter.Targets, "", false).Return(tt.resolved, tt.err)
+			if tt.err == nil {
+				mockClient.On("NetemContainer", mock.Anything, c, tt.resolved, container.DirectionEgress, cmd, filter, duration, "", false, false).Return(nil)
+				mockClient.On("StopNetemContainer", mock.Anything, c, tt.resolved, container.DirectionEgress, filter, "", false, false).Return(nil)
+			}
+			err := runNetem(context.TODO(), mockClient, c, container.AutoInterface, "backend", container.DirectionEgress, cmd, filter, duration, "", false, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("runNetem() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
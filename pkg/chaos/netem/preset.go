@@ -0,0 +1,73 @@
+package netem
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Presets named network condition profiles
+type Presets map[string]Profile
+
+// built-in presets; delay, loss and rate are applied to traffic in single direction
+var builtinPresets = Presets{
+	// GPRS/EDGE mobile network
+	"edge": {Delay: 400, Jitter: 100, DelayCorrelation: 25, Distribution: "normal", Loss: 2, LossCorrelation: 25, Rate: "240kbit"},
+	// 3G mobile network
+	"3g": {Delay: 150, Jitter: 40, DelayCorrelation: 25, Distribution: "normal", Loss: 1, LossCorrelation: 25, Rate: "1mbit"},
+	// geostationary satellite link
+	"satellite": {Delay: 300, Jitter: 20, Distribution: "normal", Loss: 0.5, Rate: "5mbit"},
+	// congested Wi-Fi with bursty losses
+	"flaky-wifi": {Delay: 20, Jitter: 15, Distribution: "normal", Loss: 5, LossCorrelation: 50, Duplicate: 0.5, Rate: "10mbit"},
+}
+
+// LoadPresets get built-in presets, extended or overridden by user presets from YAML file (if path is not empty)
+func LoadPresets(path string) (Presets, error) {
+	presets := Presets{}
+	for name, profile := range builtinPresets {
+		presets[name] = profile
+	}
+	if path == "" {
+		return presets, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.WithError(err).WithField("file", path).Error("failed to read presets file")
+		return nil, err
+	}
+	var custom Presets
+	if err = yaml.UnmarshalStrict(data, &custom); err != nil {
+		return nil, fmt.Errorf("bad presets file %s: %v", path, err)
+	}
+	for name, profile := range custom {
+		// validate user presets, using the same validation as netem commands
+		if _, err = profile.netemArgs(); err != nil {
+			return nil, fmt.Errorf("bad preset '%s': %v", name, err)
+		}
+		presets[name] = profile
+	}
+	return presets, nil
+}
+
+// Profile get network condition profile by preset name
+func (p Presets) Profile(name string) (Profile, error) {
+	profile, ok := p[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown preset '%s': must be one of {%s}", name, strings.Join(p.Names(), " | "))
+	}
+	return profile, nil
+}
+
+// Names sorted preset names
+func (p Presets) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,59 @@
+package netem
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltinPresets(t *testing.T) {
+	for _, name := range []string{"edge", "3g", "satellite", "flaky-wifi"} {
+		profile, err := builtinPresets.Profile(name)
+		if assert.NoError(t, err, name) {
+			_, err = profile.netemArgs()
+			assert.NoError(t, err, name)
+		}
+	}
+}
+
+func TestLoadPresets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "presets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, ioutil.WriteFile(path, []byte(data), 0644))
+		return path
+	}
+
+	// no presets file: built-in presets only
+	presets, err := LoadPresets("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3g", "edge", "flaky-wifi", "satellite"}, presets.Names())
+
+	// add custom preset and override built-in preset
+	presets, err = LoadPresets(write("custom.yaml", "vpn:\n  delay: 80\n  jitter: 10\n  loss: 0.5\n  rate: 20mbit\n3g:\n  delay: 100\n"))
+	assert.NoError(t, err)
+	vpn, err := presets.Profile("vpn")
+	assert.NoError(t, err)
+	assert.Equal(t, Profile{Delay: 80, Jitter: 10, Loss: 0.5, Rate: "20mbit"}, vpn)
+	p3g, err := presets.Profile("3g")
+	assert.NoError(t, err)
+	assert.Equal(t, Profile{Delay: 100}, p3g)
+	// built-in presets are not changed
+	assert.Equal(t, 150, builtinPresets["3g"].Delay)
+
+	_, err = presets.Profile("dial-up")
+	assert.EqualError(t, err, "unknown preset 'dial-up': must be one of {3g | edge | flaky-wifi | satellite | vpn}")
+
+	// custom presets are validated
+	_, err = LoadPresets(write("bad.yaml", "lossy:\n  loss: 120\n"))
+	assert.EqualError(t, err, "bad preset 'lossy': invalid loss percent: must be between 0.0 and 100.0")
+	_, err = LoadPresets(write("typo.yaml", "lossy:\n  los: 10\n"))
+	assert.Error(t, err)
+	_, err = LoadPresets(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
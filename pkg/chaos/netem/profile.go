@@ -0,0 +1,247 @@
+package netem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Profile combined network conditions, applied with single netem qdisc; zero values are not applied
+type Profile struct {
+	// delay time, jitter (in milliseconds), correlation and distribution
+	Delay            int     `yaml:"delay"`
+	Jitter           int     `yaml:"jitter"`
+	DelayCorrelation float64 `yaml:"delay-correlation"`
+	Distribution     string  `yaml:"distribution"`
+	// loss percent and correlation
+	Loss            float64 `yaml:"loss"`
+	LossCorrelation float64 `yaml:"loss-correlation"`
+	// duplicate percent and correlation
+	Duplicate            float64 `yaml:"duplicate"`
+	DuplicateCorrelation float64 `yaml:"duplicate-correlation"`
+	// corrupt percent and correlation
+	Corrupt            float64 `yaml:"corrupt"`
+	CorruptCorrelation float64 `yaml:"corrupt-correlation"`
+	// rate limit
+	Rate string `yaml:"rate"`
+}
+
+// validate profile and build netem arguments: 'delay ... loss ... duplicate ... corrupt ... rate ...'
+func (p Profile) netemArgs() ([]string, error) {
+	var args []string
+	if p.Delay != 0 || p.Jitter != 0 || p.DelayCorrelation != 0 || p.Distribution != "" {
+		if err := validateDelay(p.Delay, p.Jitter, p.DelayCorrelation, p.Distribution); err != nil {
+			return nil, err
+		}
+		args = append(args, delayArgs(p.Delay, p.Jitter, p.DelayCorrelation, p.Distribution)...)
+	}
+	percents := []struct {
+		kind        string
+		percent     float64
+		correlation float64
+	}{
+		{"loss", p.Loss, p.LossCorrelation},
+		{"duplicate", p.Duplicate, p.DuplicateCorrelation},
+		{"corrupt", p.Corrupt, p.CorruptCorrelation},
+	}
+	for _, pc := range percents {
+		if pc.percent != 0 || pc.correlation != 0 {
+			if err := validatePercent(pc.kind, pc.percent, pc.correlation); err != nil {
+				return nil, err
+			}
+			args = append(args, percentArgs(pc.kind, pc.percent, pc.correlation)...)
+		}
+	}
+	if p.Rate != "" {
+		rate, err := parseRate(p.Rate)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, rateArgs(rate, 0, 0, 0)...)
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty netem profile: define at least one of delay, loss, duplicate, corrupt or rate")
+	}
+	return args, nil
+}
+
+// ProfileCommand `netem profile` command
+type ProfileCommand struct {
+	client     container.Client
+	names      []string
+	pattern    string
+	labels     []string
+	iface      string
+	network    string
+	direction  string
+	filter     container.NetemFilter
+	duration   time.Duration
+	netemCmd   []string
+	image      string
+	pull       bool
+	limit      int
+	perService bool
+	dryRun     bool
+}
+
+// NewProfileCommand create new netem profile command
+func NewProfileCommand(client container.Client,
+	names []string, // containers
+	pattern string, // re2 regex pattern
+	labels []string, // label selectors
+	iface string, // network interface
+	network string, // Docker network to resolve network interface on; implies 'auto' interface
+	direction string, // traffic direction: egress, ingress or both
+	filter Filter, // traffic filter: target and source IPs, ports and protocol
+	durationStr string, // chaos duration
+	intervalStr string, // repeatable chaos interval
+	profile Profile, // combined network conditions
+	image string, // traffic control image
+	pull bool, // pull tc image option
+	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
+	dryRun bool, // dry-run do not netem just log
+) (chaos.Command, error) {
+	// log error
+	var err error
+	defer func() {
+		if err != nil {
+			log.WithError(err).Error("failed to construct Netem Profile Command")
+		}
+	}()
+
+	// get interval
+	interval, err := util.GetIntervalValue(intervalStr)
+	if err != nil {
+		return nil, err
+	}
+	// get duration
+	duration, err := util.GetDurationValue(durationStr, interval)
+	if err != nil {
+		return nil, err
+	}
+	// protect from Command Injection, using Regexp
+	reInterface := regexp.MustCompile("[a-zA-Z][a-zA-Z0-9_-]*")
+	validIface := reInterface.FindString(iface)
+	if iface != validIface {
+		err = fmt.Errorf("bad network interface name: must match '%s'", reInterface.String())
+		return nil, err
+	}
+	// resolve network interface inside container on Docker network
+	if network != "" {
+		iface = container.AutoInterface
+	}
+	// validate traffic direction
+	direction, err = parseDirection(direction)
+	if err != nil {
+		return nil, err
+	}
+	// validate traffic filter
+	netemFilter, err := container.ParseNetemFilter(filter.Targets, filter.Sources, filter.Ports, filter.SrcPorts, filter.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	// validate profile, using the same validation as single netem commands
+	netemCmd, err := profile.netemArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProfileCommand{
+		client:     client,
+		names:      names,
+		pattern:    pattern,
+		labels:     labels,
+		iface:      iface,
+		network:    network,
+		direction:  direction,
+		filter:     netemFilter,
+		duration:   duration,
+		netemCmd:   netemCmd,
+		image:      image,
+		pull:       pull,
+		limit:      limit,
+		perService: perService,
+		dryRun:     dryRun,
+	}, nil
+}
+
+// Run netem profile command
+func (n *ProfileCommand) Run(ctx context.Context, random bool) error {
+	log.Debug("applying network profile to all matching containers")
+	log.WithFields(log.Fields{
+		"names":      n.names,
+		"pattern":    n.pattern,
+		"labels":     n.labels,
+		"limit":      n.limit,
+		"perService": n.perService,
+	}).Debug("listing matching containers")
+	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.labels, n.limit, n.perService)
+	if err != nil {
+		log.WithError(err).Error("failed to list containers")
+		return err
+	}
+	if len(containers) == 0 {
+		log.Warning("no containers found")
+		return nil
+	}
+
+	// select single random container from matching container and replace list with selected item
+	if random {
+		log.Debug("selecting single random container")
+		if c := container.RandomContainer(containers); c != nil {
+			containers = []container.Container{*c}
+		}
+	}
+
+	// run netem profile command for selected containers
+	var wg sync.WaitGroup
+	errors := make([]error, len(containers))
+	cancels := make([]context.CancelFunc, len(containers))
+	for i, c := range containers {
+		log.WithFields(log.Fields{
+			"container": c,
+			"command":   n.netemCmd,
+		}).Debug("applying network profile for container")
+		netemCtx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		wg.Add(1)
+		go func(i int, c container.Container) {
+			defer wg.Done()
+			errors[i] = runNetem(netemCtx, n.client, c, n.iface, n.network, n.direction, n.netemCmd, n.filter, n.duration, n.image, n.pull, n.dryRun)
+			if errors[i] != nil {
+				log.WithField("container", c).WithError(errors[i]).Error("failed to apply network profile for container")
+			}
+		}(i, c)
+	}
+
+	// Wait for all netem profile commands to complete
+	wg.Wait()
+
+	// cancel context to avoid leaks
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	// scan through all errors in goroutines
+	for _, e := range errors {
+		// take first found error
+		if e != nil {
+			err = e
+			break
+		}
+	}
+
+	return err
+}
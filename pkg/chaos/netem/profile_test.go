@@ -0,0 +1,134 @@
+package netem
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProfile_netemArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "bad 3G link",
+			profile: Profile{Delay: 100, Jitter: 50, DelayCorrelation: 25, Distribution: "normal", Loss: 1.5, Rate: "1mbit"},
+			want:    []string{"delay", "100ms", "50ms", "25.00", "distribution", "normal", "loss", "1.50", "rate", "1mbit"},
+		},
+		{
+			name:    "all conditions",
+			profile: Profile{Delay: 10, Loss: 1, LossCorrelation: 5, Duplicate: 2, DuplicateCorrelation: 6, Corrupt: 3, CorruptCorrelation: 7, Rate: "100kbit"},
+			want:    []string{"delay", "10ms", "loss", "1.00", "5.00", "duplicate", "2.00", "6.00", "corrupt", "3.00", "7.00", "rate", "100kbit"},
+		},
+		{
+			name:    "loss only",
+			profile: Profile{Loss: 10},
+			want:    []string{"loss", "10.00"},
+		},
+		{
+			name:    "empty profile",
+			wantErr: true,
+		},
+		{
+			name:    "jitter without delay",
+			profile: Profile{Jitter: 10},
+			wantErr: true,
+		},
+		{
+			name:    "jitter bigger than delay",
+			profile: Profile{Delay: 10, Jitter: 20},
+			wantErr: true,
+		},
+		{
+			name:    "bad distribution",
+			profile: Profile{Delay: 10, Distribution: "bad"},
+			wantErr: true,
+		},
+		{
+			name:    "bad loss percent",
+			profile: Profile{Loss: 101},
+			wantErr: true,
+		},
+		{
+			name:    "bad duplicate correlation",
+			profile: Profile{Duplicate: 1, DuplicateCorrelation: -1},
+			wantErr: true,
+		},
+		{
+			name:    "bad corrupt percent",
+			profile: Profile{Corrupt: -1},
+			wantErr: true,
+		},
+		{
+			name:    "bad rate",
+			profile: Profile{Delay: 10, Rate: "fast"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.profile.netemArgs()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Profile.netemArgs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Profile.netemArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewProfileCommand(t *testing.T) {
+	got, err := NewProfileCommand(nil, []string{"c1"}, "", nil, "eth0", "", "", Filter{Targets: []string{"10.0.0.1"}}, "30s", "1m", Profile{Delay: 100, Loss: 2}, "test/image", true, 0, false, false)
+	if err != nil {
+		t.Fatalf("NewProfileCommand() error = %v", err)
+	}
+	want := []string{"delay", "100ms", "loss", "2.00"}
+	if cmd := got.(*ProfileCommand); !reflect.DeepEqual(cmd.netemCmd, want) || cmd.direction != container.DirectionEgress || cmd.duration != 30*time.Second {
+		t.Errorf("NewProfileCommand() = %+v, want netem command %v", cmd, want)
+	}
+	if _, err = NewProfileCommand(nil, []string{"c1"}, "", nil, "eth0", "", "", Filter{}, "30s", "", Profile{}, "", true, 0, false, false); err == nil {
+		t.Error("NewProfileCommand() expected error for empty profile")
+	}
+	if _, err = NewProfileCommand(nil, []string{"c1"}, "", nil, "bad#iface", "", "", Filter{}, "30s", "", Profile{Loss: 1}, "", true, 0, false, false); err == nil {
+		t.Error("NewProfileCommand() expected error for bad interface")
+	}
+	got, err = NewProfileCommand(nil, []string{"c1"}, "", nil, "eth0", "backend", "", Filter{}, "30s", "", Profile{Loss: 1}, "", true, 0, false, false)
+	if err != nil {
+		t.Fatalf("NewProfileCommand() error = %v", err)
+	}
+	if cmd := got.(*ProfileCommand); cmd.iface != container.AutoInterface || cmd.network != "backend" {
+		t.Errorf("NewProfileCommand() = %+v, want 'auto' interface on network backend", cmd)
+	}
+}
+
+func TestProfileCommand_Run(t *testing.T) {
+	mockClient := new(container.MockClient)
+	cmd := []string{"delay", "100ms", "rate", "1mbit"}
+	n := &ProfileCommand{
+		client:    mockClient,
+		names:     []string{"c1", "c2"},
+		iface:     "eth0",
+		direction: container.DirectionEgress,
+		duration:  10 * time.Microsecond,
+		netemCmd:  cmd,
+	}
+	expected := container.CreateTestContainers(2)
+	mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter")).Return(expected, nil)
+	for _, c := range expected {
+		mockClient.On("NetemContainer", mock.Anything, c, "eth0", container.DirectionEgress, cmd, n.filter, n.duration, "", false, false).Return(nil)
+		mockClient.On("StopNetemContainer", mock.Anything, c, "eth0", container.DirectionEgress, n.filter, "", false, false).Return(nil)
+	}
+	if err := n.Run(context.TODO(), false); err != nil {
+		t.Errorf("ProfileCommand.Run() error = %v", err)
+	}
+	mockClient.AssertExpectations(t)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net"
 	"regexp"
 	"strconv"
 	"sync"
@@ -17,6 +16,11 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	// DefaultRate default netem rate limit
+	DefaultRate = "100kbit"
+)
+
 // Parse rate
 func parseRate(rate string) (string, error) {
 	reRate := regexp.MustCompile("[0-9]+[gmk]?bit")
@@ -29,14 +33,31 @@ func parseRate(rate string) (string, error) {
 	return rate, nil
 }
 
+// netem rate arguments: 'rate <rate> [<packetoverhead> [<cellsize> [<celloverhead>]]]'
+func rateArgs(rate string, packetOverhead int, cellSize int, cellOverhead int) []string {
+	args := []string{"rate", rate}
+	if packetOverhead != 0 {
+		args = append(args, strconv.Itoa(packetOverhead))
+	}
+	if cellSize > 0 {
+		args = append(args, strconv.Itoa(cellSize))
+	}
+	if cellOverhead != 0 {
+		args = append(args, strconv.Itoa(cellOverhead))
+	}
+	return args
+}
+
 // RateCommand `netem rate` command
 type RateCommand struct {
 	client         container.Client
 	names          []string
 	pattern        string
+	labels         []string
 	iface          string
-	ips            []*net.IPNet
-	port           uint16
+	network        string
+	direction      string
+	filter         container.NetemFilter
 	duration       time.Duration
 	rate           string
 	packetOverhead int
@@ -45,6 +66,7 @@ type RateCommand struct {
 	image          string
 	pull           bool
 	limit          int
+	perService     bool
 	dryRun         bool
 }
 
@@ -52,9 +74,11 @@ type RateCommand struct {
 func NewRateCommand(client container.Client,
 	names []string, // containers
 	pattern string, // re2 regex pattern
+	labels []string, // label selectors
 	iface string, // network interface
-	ipsList []string, // list of target ips
-	port    uint16,
+	network string, // Docker network to resolve network interface on; implies 'auto' interface
+	direction string, // traffic direction: egress, ingress or both
+	filter Filter, // traffic filter: target and source IPs, ports and protocol
 	durationStr string, // chaos duration
 	intervalStr string, // repeatable chaos interval
 	rate string, // delay outgoing packets; in common units
@@ -64,6 +88,7 @@ func NewRateCommand(client container.Client,
 	image string, // traffic control image
 	pull bool, // pull tc image
 	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
 	dryRun bool, // dry-run do not netem just log
 ) (chaos.Command, error) {
 	// log error
@@ -91,15 +116,19 @@ func NewRateCommand(client container.Client,
 		err = fmt.Errorf("bad network interface name: must match '%s'", reInterface.String())
 		return nil, err
 	}
-	// validate ips
-	var ips []*net.IPNet
-	for _, str := range ipsList {
-		ip := util.ParseCIDR(str)
-		if ip == nil {
-			err = fmt.Errorf("bad target: '%s' is not a valid IP", str)
-			return nil, err
-		}
-		ips = append(ips, ip)
+	// resolve network interface inside container on Docker network
+	if network != "" {
+		iface = container.AutoInterface
+	}
+	// validate traffic direction
+	direction, err = parseDirection(direction)
+	if err != nil {
+		return nil, err
+	}
+	// validate traffic filter
+	netemFilter, err := container.ParseNetemFilter(filter.Targets, filter.Sources, filter.Ports, filter.SrcPorts, filter.Protocol)
+	if err != nil {
+		return nil, err
 	}
 	// validate target egress rate
 	if rate == "" {
@@ -124,9 +153,11 @@ func NewRateCommand(client container.Client,
 		client:         client,
 		names:          names,
 		pattern:        pattern,
+		labels:         labels,
 		iface:          iface,
-		ips:            ips,
-		port:           port,
+		network:        network,
+		direction:      direction,
+		filter:         netemFilter,
 		duration:       duration,
 		rate:           rate,
 		packetOverhead: packetOverhead,
@@ -135,6 +166,7 @@ func NewRateCommand(client container.Client,
 		image:          image,
 		pull:           pull,
 		limit:          limit,
+		perService:     perService,
 		dryRun:         dryRun,
 	}, nil
 }
@@ -143,11 +175,13 @@ func NewRateCommand(client container.Client,
 func (n *RateCommand) Run(ctx context.Context, random bool) error {
 	log.Debug("setting network rate to all matching containers")
 	log.WithFields(log.Fields{
-		"names":   n.names,
-		"pattern": n.pattern,
-		"limit":   n.limit,
+		"names":      n.names,
+		"pattern":    n.pattern,
+		"labels":     n.labels,
+		"limit":      n.limit,
+		"perService": n.perService,
 	}).Debug("listing matching containers")
-	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.limit)
+	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.labels, n.limit, n.perService)
 	if err != nil {
 		log.WithError(err).Error("failed to list containers")
 		return err
@@ -166,16 +200,7 @@ func (n *RateCommand) Run(ctx context.Context, random bool) error {
 	}
 
 	// prepare netem rate command
-	netemCmd := []string{"rate", n.rate}
-	if n.packetOverhead != 0 {
-		netemCmd = append(netemCmd, strconv.Itoa(n.packetOverhead))
-	}
-	if n.cellSize > 0 {
-		netemCmd = append(netemCmd, strconv.Itoa(n.cellSize))
-	}
-	if n.cellOverhead != 0 {
-		netemCmd = append(netemCmd, strconv.Itoa(n.cellOverhead))
-	}
+	netemCmd := rateArgs(n.rate, n.packetOverhead, n.cellSize, n.cellOverhead)
 
 	// run netem loss command for selected containers
 	var wg sync.WaitGroup
@@ -191,7 +216,7 @@ func (n *RateCommand) Run(ctx context.Context, random bool) error {
 		wg.Add(1)
 		go func(i int, c container.Container) {
 			defer wg.Done()
-			errors[i] = runNetem(netemCtx, n.client, c, n.iface, netemCmd, n.ips, n.port, n.duration, n.image, n.pull, n.dryRun)
+			errors[i] = runNetem(netemCtx, n.client, c, n.iface, n.network, n.direction, netemCmd, n.filter, n.duration, n.image, n.pull, n.dryRun)
 			if errors[i] != nil {
 				log.WithError(errors[i]).Error("failed to set network rate for container")
 			}
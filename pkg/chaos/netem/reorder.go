@@ -0,0 +1,212 @@
+package netem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ReorderCommand `netem reorder` command
+type ReorderCommand struct {
+	client      container.Client
+	names       []string
+	pattern     string
+	labels      []string
+	iface       string
+	network     string
+	direction   string
+	filter      container.NetemFilter
+	duration    time.Duration
+	time        int
+	percent     float64
+	correlation float64
+	gap         int
+	image       string
+	pull        bool
+	limit       int
+	perService  bool
+	dryRun      bool
+}
+
+// NewReorderCommand create new netem reorder command
+func NewReorderCommand(client container.Client,
+	names []string, // containers
+	pattern string, // re2 regex pattern
+	labels []string, // label selectors
+	iface string, // network interface
+	network string, // Docker network to resolve network interface on; implies 'auto' interface
+	direction string, // traffic direction: egress, ingress or both
+	filter Filter, // traffic filter: target and source IPs, ports and protocol
+	durationStr string, // chaos duration
+	intervalStr string, // repeatable chaos interval
+	time int, // delay time of not reordered packets
+	percent float64, // reorder percent
+	correlation float64, // reorder correlation
+	gap int, // reorder every gap-th packet
+	image string, // traffic control image
+	pull bool, // pull tc image
+	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
+	dryRun bool, // dry-run do not netem just log
+) (chaos.Command, error) {
+	// log error
+	var err error
+	defer func() {
+		if err != nil {
+			log.WithError(err).Error("failed to construct Netem Reorder Command")
+		}
+	}()
+
+	// get interval
+	interval, err := util.GetIntervalValue(intervalStr)
+	if err != nil {
+		return nil, err
+	}
+	// get duration
+	duration, err := util.GetDurationValue(durationStr, interval)
+	if err != nil {
+		return nil, err
+	}
+	// protect from Command Injection, using Regexp
+	reInterface := regexp.MustCompile("[a-zA-Z][a-zA-Z0-9_-]*")
+	validIface := reInterface.FindString(iface)
+	if iface != validIface {
+		err = fmt.Errorf("bad network interface name: must match '%s'", reInterface.String())
+		return nil, err
+	}
+	// resolve network interface inside container on Docker network
+	if network != "" {
+		iface = container.AutoInterface
+	}
+	// validate traffic direction
+	direction, err = parseDirection(direction)
+	if err != nil {
+		return nil, err
+	}
+	// validate traffic filter
+	netemFilter, err := container.ParseNetemFilter(filter.Targets, filter.Sources, filter.Ports, filter.SrcPorts, filter.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	// reorder requires delay: reordered packets are sent immediately, others are delayed
+	if time <= 0 {
+		err = errors.New("non-positive delay time: reorder requires delay")
+		return nil, err
+	}
+	// validate netem reorder percent and correlation
+	if err = validatePercent("reorder", percent, correlation); err != nil {
+		return nil, err
+	}
+	// validate reorder gap
+	if gap < 0 {
+		err = errors.New("invalid reorder gap: must be a non-negative integer")
+		return nil, err
+	}
+
+	return &ReorderCommand{
+		client:      client,
+		names:       names,
+		pattern:     pattern,
+		labels:      labels,
+		iface:       iface,
+		network:     network,
+		direction:   direction,
+		filter:      netemFilter,
+		duration:    duration,
+		time:        time,
+		percent:     percent,
+		correlation: correlation,
+		gap:         gap,
+		image:       image,
+		pull:        pull,
+		limit:       limit,
+		perService:  perService,
+		dryRun:      dryRun,
+	}, nil
+}
+
+// Run netem reorder command
+func (n *ReorderCommand) Run(ctx context.Context, random bool) error {
+	log.Debug("reordering network packets of all matching containers")
+	log.WithFields(log.Fields{
+		"names":      n.names,
+		"pattern":    n.pattern,
+		"labels":     n.labels,
+		"limit":      n.limit,
+		"perService": n.perService,
+	}).Debug("listing matching containers")
+	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.labels, n.limit, n.perService)
+	if err != nil {
+		log.WithError(err).Error("failed to list containers")
+		return err
+	}
+	if len(containers) == 0 {
+		log.Warning("no containers found")
+		return nil
+	}
+
+	// select single random container from matching container and replace list with selected item
+	if random {
+		log.Debug("selecting single random container")
+		if c := container.RandomContainer(containers); c != nil {
+			containers = []container.Container{*c}
+		}
+	}
+
+	// prepare netem reorder command: 'delay <time>ms reorder <percent> [<correlation>] [gap <gap>]'
+	netemCmd := append(delayArgs(n.time, 0, 0, ""), percentArgs("reorder", n.percent, n.correlation)...)
+	if n.gap > 0 {
+		netemCmd = append(netemCmd, "gap", strconv.Itoa(n.gap))
+	}
+
+	// run netem reorder command for selected containers
+	var wg sync.WaitGroup
+	errors := make([]error, len(containers))
+	cancels := make([]context.CancelFunc, len(containers))
+	for i, c := range containers {
+		log.WithFields(log.Fields{
+			"container": c,
+		}).Debug("reordering network packets for container")
+		netemCtx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		wg.Add(1)
+		go func(i int, c container.Container) {
+			defer wg.Done()
+			errors[i] = runNetem(netemCtx, n.client, c, n.iface, n.network, n.direction, netemCmd, n.filter, n.duration, n.image, n.pull, n.dryRun)
+			if errors[i] != nil {
+				log.WithField("container", c).WithError(errors[i]).Error("failed to reorder network packets for container")
+			}
+		}(i, c)
+	}
+
+	// Wait for all netem reorder commands to complete
+	wg.Wait()
+
+	// cancel context to avoid leaks
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	// scan through all errors in goroutines
+	for _, e := range errors {
+		// take first found error
+		if e != nil {
+			err = e
+			break
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,153 @@
+package netem
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/util"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewReorderCommand(t *testing.T) {
+	type args struct {
+		iface       string
+		filter      Filter
+		durationStr string
+		intervalStr string
+		time        int
+		percent     float64
+		correlation float64
+		gap         int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    chaos.Command
+		wantErr bool
+	}{
+		{
+			name: "create Reorder command",
+			args: args{
+				iface:       "eth0",
+				filter:      Filter{Targets: []string{"10.0.0.1"}, Ports: "53"},
+				durationStr: "30s",
+				intervalStr: "1m",
+				time:        10,
+				percent:     25,
+				correlation: 50,
+				gap:         5,
+			},
+			want: &ReorderCommand{
+				iface:       "eth0",
+				direction:   "egress",
+				filter:      container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.0.0.1")}, Ports: []container.PortRange{{From: 53, To: 53}}},
+				duration:    30 * time.Second,
+				time:        10,
+				percent:     25,
+				correlation: 50,
+				gap:         5,
+			},
+		},
+		{
+			name:    "bad duration",
+			args:    args{iface: "eth0", durationStr: "2m", intervalStr: "1m", time: 10, percent: 25},
+			wantErr: true,
+		},
+		{
+			name:    "invalid IP address",
+			args:    args{iface: "eth0", filter: Filter{Targets: []string{"1.2.3.4.5"}}, durationStr: "30s", time: 10, percent: 25},
+			wantErr: true,
+		},
+		{
+			name:    "no delay",
+			args:    args{iface: "eth0", durationStr: "30s", percent: 25},
+			wantErr: true,
+		},
+		{
+			name:    "bad percent",
+			args:    args{iface: "eth0", durationStr: "30s", time: 10, percent: 101},
+			wantErr: true,
+		},
+		{
+			name:    "bad correlation",
+			args:    args{iface: "eth0", durationStr: "30s", time: 10, percent: 25, correlation: -1},
+			wantErr: true,
+		},
+		{
+			name:    "negative gap",
+			args:    args{iface: "eth0", durationStr: "30s", time: 10, percent: 25, gap: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewReorderCommand(nil, nil, "", nil, tt.args.iface, "", "", tt.args.filter, tt.args.durationStr, tt.args.intervalStr, tt.args.time, tt.args.percent, tt.args.correlation, tt.args.gap, "", false, 0, false, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewReorderCommand() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewReorderCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReorderCommand_Run(t *testing.T) {
+	tests := []struct {
+		name       string
+		cmd        *ReorderCommand
+		netemCmd   []string
+		netemError bool
+		wantErr    bool
+	}{
+		{
+			name:     "reorder with gap",
+			cmd:      &ReorderCommand{time: 10, percent: 25, correlation: 50, gap: 5},
+			netemCmd: []string{"delay", "10ms", "reorder", "25.00", "50.00", "gap", "5"},
+		},
+		{
+			name:     "reorder without correlation and gap",
+			cmd:      &ReorderCommand{time: 100, percent: 10},
+			netemCmd: []string{"delay", "100ms", "reorder", "10.00"},
+		},
+		{
+			name:       "error reordering packets",
+			cmd:        &ReorderCommand{time: 100, percent: 10},
+			netemCmd:   []string{"delay", "100ms", "reorder", "10.00"},
+			netemError: true,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(container.MockClient)
+			n := tt.cmd
+			n.client = mockClient
+			n.names = []string{"c1"}
+			n.iface = "eth0"
+			n.direction = container.DirectionEgress
+			n.filter = container.NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.0.0.1")}, Ports: []container.PortRange{{From: 53, To: 53}}}
+			n.duration = 10 * time.Microsecond
+			c := container.CreateTestContainers(1)
+			mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter")).Return(c, nil)
+			call := mockClient.On("NetemContainer", mock.Anything, c[0], "eth0", container.DirectionEgress, tt.netemCmd, n.filter, n.duration, "", false, false)
+			if tt.netemError {
+				call.Return(errors.New("ERROR"))
+			} else {
+				call.Return(nil)
+				mockClient.On("StopNetemContainer", mock.Anything, c[0], "eth0", container.DirectionEgress, n.filter, "", false, false).Return(nil)
+			}
+			if err := n.Run(context.TODO(), false); (err != nil) != tt.wantErr {
+				t.Errorf("ReorderCommand.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
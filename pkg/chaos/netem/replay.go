@@ -0,0 +1,298 @@
+package netem
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// trace point: network conditions, recorded at offset from trace start
+type tracePoint struct {
+	offset time.Duration
+	delay  float64 // delay time; in milliseconds
+	jitter float64 // delay jitter; in milliseconds
+	loss   float64 // loss percent
+}
+
+// netem replay arguments: 'delay <delay>ms [<jitter>ms] loss <loss>'
+// delay and loss are always set, since 'tc qdisc change' replaces all netem parameters
+func (p tracePoint) netemArgs() []string {
+	args := []string{"delay", strconv.FormatFloat(p.delay, 'f', -1, 64) + "ms"}
+	if p.jitter > 0 {
+		args = append(args, strconv.FormatFloat(p.jitter, 'f', -1, 64)+"ms")
+	}
+	return append(args, percentArgs("loss", p.loss, 0)...)
+}
+
+// parse trace timestamp: seconds (relative or Unix time; fraction is allowed) or RFC 3339 time
+func parseTimestamp(str string) (time.Time, error) {
+	if seconds, err := strconv.ParseFloat(str, 64); err == nil {
+		return time.Unix(0, 0).Add(time.Duration(seconds * float64(time.Second))), nil
+	}
+	return time.Parse(time.RFC3339Nano, str)
+}
+
+// load trace from CSV file: 'timestamp, delay_ms, jitter_ms, loss_pct' records, with optional header;
+// trace point offsets are relative to first record timestamp
+func loadTrace(path string) ([]tracePoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %s", err)
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var trace []tracePoint
+	var start, last time.Time
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bad trace file: %s", err)
+		}
+		line, _ := reader.FieldPos(0)
+		ts, err := parseTimestamp(strings.TrimSpace(record[0]))
+		if err != nil {
+			// skip header
+			if first {
+				continue
+			}
+			return nil, fmt.Errorf("bad trace record on line %d: invalid timestamp '%s'", line, record[0])
+		}
+		var values [3]float64
+		for i, field := range record[1:] {
+			if values[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil || values[i] < 0 {
+				return nil, fmt.Errorf("bad trace record on line %d: '%s' is not a non-negative number", line, field)
+			}
+		}
+		if values[2] > 100.0 {
+			return nil, fmt.Errorf("bad trace record on line %d: loss must be between 0.0 and 100.0", line)
+		}
+		if len(trace) == 0 {
+			start = ts
+		} else if ts.Before(last) {
+			return nil, fmt.Errorf("bad trace record on line %d: timestamps must not decrease", line)
+		}
+		last = ts
+		trace = append(trace, tracePoint{offset: ts.Sub(start), delay: values[0], jitter: values[1], loss: values[2]})
+	}
+	if len(trace) == 0 {
+		return nil, errors.New("empty trace file")
+	}
+	return trace, nil
+}
+
+// ReplayCommand `netem replay` command
+type ReplayCommand struct {
+	client     container.Client
+	names      []string
+	pattern    string
+	labels     []string
+	iface      string
+	network    string
+	direction  string
+	filter     container.NetemFilter
+	duration   time.Duration
+	trace      []tracePoint
+	speed      float64
+	image      string
+	pull       bool
+	limit      int
+	perService bool
+	dryRun     bool
+}
+
+// NewReplayCommand create new netem replay command
+func NewReplayCommand(client container.Client,
+	names []string, // containers
+	pattern string, // re2 regex pattern
+	labels []string, // label selectors
+	iface string, // network interface
+	network string, // Docker network to resolve network interface on; implies 'auto' interface
+	direction string, // traffic direction: egress, ingress or both
+	filter Filter, // traffic filter: target and source IPs, ports and protocol
+	durationStr string, // chaos duration
+	intervalStr string, // repeatable chaos interval
+	tracePath string, // CSV trace file
+	speed float64, // replay speed multiplier
+	image string, // traffic control image
+	pull bool, // pull tc image
+	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
+	dryRun bool, // dry-run do not netem just log
+) (chaos.Command, error) {
+	// log error
+	var err error
+	defer func() {
+		if err != nil {
+			log.WithError(err).Error("failed to construct Netem Replay Command")
+		}
+	}()
+
+	// get interval
+	interval, err := util.GetIntervalValue(intervalStr)
+	if err != nil {
+		return nil, err
+	}
+	// get duration
+	duration, err := util.GetDurationValue(durationStr, interval)
+	if err != nil {
+		return nil, err
+	}
+	// protect from Command Injection, using Regexp
+	reInterface := regexp.MustCompile("[a-zA-Z][a-zA-Z0-9_-]*")
+	validIface := reInterface.FindString(iface)
+	if iface != validIface {
+		err = fmt.Errorf("bad network interface name: must match '%s'", reInterface.String())
+		return nil, err
+	}
+	// resolve network interface inside container on Docker network
+	if network != "" {
+		iface = container.AutoInterface
+	}
+	// validate traffic direction
+	direction, err = parseDirection(direction)
+	if err != nil {
+		return nil, err
+	}
+	// validate traffic filter
+	netemFilter, err := container.ParseNetemFilter(filter.Targets, filter.Sources, filter.Ports, filter.SrcPorts, filter.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	// validate replay speed
+	if speed <= 0 {
+		err = errors.New("non-positive replay speed")
+		return nil, err
+	}
+	// load trace
+	if tracePath == "" {
+		err = errors.New("undefined trace file")
+		return nil, err
+	}
+	trace, err := loadTrace(tracePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReplayCommand{
+		client:     client,
+		names:      names,
+		pattern:    pattern,
+		labels:     labels,
+		iface:      iface,
+		network:    network,
+		direction:  direction,
+		filter:     netemFilter,
+		duration:   duration,
+		trace:      trace,
+		speed:      speed,
+		image:      image,
+		pull:       pull,
+		limit:      limit,
+		perService: perService,
+		dryRun:     dryRun,
+	}, nil
+}
+
+// Run netem replay command
+func (n *ReplayCommand) Run(ctx context.Context, random bool) error {
+	log.Debug("replaying network trace on all matching containers")
+	log.WithFields(log.Fields{
+		"names":      n.names,
+		"pattern":    n.pattern,
+		"labels":     n.labels,
+		"limit":      n.limit,
+		"perService": n.perService,
+	}).Debug("listing matching containers")
+	containers, err := container.ListNContainers(ctx, n.client, n.names, n.pattern, n.labels, n.limit, n.perService)
+	if err != nil {
+		log.WithError(err).Error("failed to list containers")
+		return err
+	}
+	if len(containers) == 0 {
+		log.Warning("no containers found")
+		return nil
+	}
+
+	// select single random container from matching container and replace list with selected item
+	if random {
+		log.Debug("selecting single random container")
+		if c := container.RandomContainer(containers); c != nil {
+			containers = []container.Container{*c}
+		}
+	}
+
+	// prepare netem command from first trace point and netem changes from following trace points;
+	// trace is replayed with speed multiplier and cut at netem duration
+	netemCmd := n.trace[0].netemArgs()
+	var steps []netemStep
+	for _, p := range n.trace[1:] {
+		offset := time.Duration(float64(p.offset) / n.speed)
+		if offset >= n.duration {
+			break
+		}
+		steps = append(steps, netemStep{offset: offset, cmd: p.netemArgs()})
+	}
+
+	// run netem replay command for selected containers
+	var wg sync.WaitGroup
+	errors := make([]error, len(containers))
+	cancels := make([]context.CancelFunc, len(containers))
+	for i, c := range containers {
+		log.WithFields(log.Fields{
+			"container": c,
+			"steps":     len(steps),
+		}).Debug("replaying network trace for container")
+		netemCtx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		wg.Add(1)
+		go func(i int, c container.Container) {
+			defer wg.Done()
+			errors[i] = runNetemSteps(netemCtx, n.client, c, n.iface, n.network, n.direction, netemCmd, steps, n.filter, n.duration, n.image, n.pull, n.dryRun)
+			if errors[i] != nil {
+				log.WithField("container", c).WithError(errors[i]).Error("failed to replay network trace for container")
+			}
+		}(i, c)
+	}
+
+	// Wait for all netem replay commands to complete
+	wg.Wait()
+
+	// cancel context to avoid leaks
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	// scan through all errors in goroutines
+	for _, e := range errors {
+		// take first found error
+		if e != nil {
+			err = e
+			break
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,153 @@
+package netem
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/stretchr/testify/mock"
+)
+
+func writeTrace(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "trace.csv")
+	if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func Test_loadTrace(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []tracePoint
+		wantErr bool
+	}{
+		{
+			name:    "relative seconds with header",
+			content: "timestamp,delay_ms,jitter_ms,loss_pct\n0,20,5,0\n0.5,35.5,10,1.5\n2,120,0,10\n",
+			want: []tracePoint{
+				{offset: 0, delay: 20, jitter: 5},
+				{offset: 500 * time.Millisecond, delay: 35.5, jitter: 10, loss: 1.5},
+				{offset: 2 * time.Second, delay: 120, loss: 10},
+			},
+		},
+		{
+			name:    "RFC 3339 timestamps with comments",
+			content: "# customer network\n2019-05-01T10:00:00Z, 20, 5, 0\n2019-05-01T10:01:00Z, 40, 5, 2\n",
+			want: []tracePoint{
+				{offset: 0, delay: 20, jitter: 5},
+				{offset: time.Minute, delay: 40, jitter: 5, loss: 2},
+			},
+		},
+		{
+			name:    "Unix time seconds",
+			content: "1556704800,20,5,0\n1556704810,40,5,2\n",
+			want: []tracePoint{
+				{offset: 0, delay: 20, jitter: 5},
+				{offset: 10 * time.Second, delay: 40, jitter: 5, loss: 2},
+			},
+		},
+		{
+			name:    "empty trace",
+			content: "timestamp,delay_ms,jitter_ms,loss_pct\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing field",
+			content: "0,20,5\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad timestamp",
+			content: "0,20,5,0\nlater,20,5,0\n",
+			wantErr: true,
+		},
+		{
+			name:    "negative delay",
+			content: "0,-20,5,0\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad loss",
+			content: "0,20,5,101\n",
+			wantErr: true,
+		},
+		{
+			name:    "decreasing timestamps",
+			content: "10,20,5,0\n5,20,5,0\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, cleanup := writeTrace(t, tt.content)
+			defer cleanup()
+			got, err := loadTrace(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loadTrace() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadTrace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewReplayCommand(t *testing.T) {
+	path, cleanup := writeTrace(t, "0,20,5,0\n1,40,0,2\n")
+	defer cleanup()
+	got, err := NewReplayCommand(nil, []string{"c1"}, "", nil, "eth0", "", "", Filter{}, "30s", "1m", path, 2, "", false, 0, false, false)
+	if err != nil {
+		t.Fatalf("NewReplayCommand() error = %v", err)
+	}
+	if cmd := got.(*ReplayCommand); len(cmd.trace) != 2 || cmd.speed != 2 || cmd.duration != 30*time.Second {
+		t.Errorf("NewReplayCommand() = %+v", cmd)
+	}
+	if _, err = NewReplayCommand(nil, []string{"c1"}, "", nil, "eth0", "", "", Filter{}, "30s", "", "", 1, "", false, 0, false, false); err == nil {
+		t.Error("NewReplayCommand() expected error for undefined trace file")
+	}
+	if _, err = NewReplayCommand(nil, []string{"c1"}, "", nil, "eth0", "", "", Filter{}, "30s", "", path, 0, "", false, 0, false, false); err == nil {
+		t.Error("NewReplayCommand() expected error for non-positive speed")
+	}
+	if _, err = NewReplayCommand(nil, []string{"c1"}, "", nil, "eth0", "", "", Filter{}, "30s", "", path+".missing", 1, "", false, 0, false, false); err == nil {
+		t.Error("NewReplayCommand() expected error for missing trace file")
+	}
+}
+
+func TestReplayCommand_Run(t *testing.T) {
+	mockClient := new(container.MockClient)
+	n := &ReplayCommand{
+		client:    mockClient,
+		names:     []string{"c1"},
+		iface:     "eth0",
+		direction: container.DirectionEgress,
+		duration:  50 * time.Millisecond,
+		trace: []tracePoint{
+			{offset: 0, delay: 20, jitter: 5},
+			{offset: 10 * time.Millisecond, delay: 35.5, loss: 1.5},
+			// replayed after netem duration: skipped
+			{offset: time.Second, delay: 100},
+		},
+		speed: 2,
+	}
+	c := container.CreateTestContainers(1)
+	mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter")).Return(c, nil)
+	mockClient.On("NetemContainer", mock.Anything, c[0], "eth0", container.DirectionEgress, []string{"delay", "20ms", "5ms", "loss", "0.00"}, container.NetemFilter{}, n.duration, "", false, false).Return(nil)
+	mockClient.On("ChangeNetemContainer", mock.Anything, c[0], "eth0", container.DirectionEgress, []string{"delay", "35.5ms", "loss", "1.50"}, container.NetemFilter{}, "", false, false).Return(nil)
+	mockClient.On("StopNetemContainer", mock.Anything, c[0], "eth0", container.DirectionEgress, container.NetemFilter{}, "", false, false).Return(nil)
+	if err := n.Run(context.TODO(), false); err != nil {
+		t.Errorf("ReplayCommand.Run() error = %v", err)
+	}
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,92 @@
+package netem
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// netem step: netem parameters, replacing parameters of installed netem qdisc ('tc qdisc change') at offset from netem start
+type netemStep struct {
+	offset time.Duration
+	cmd    []string
+}
+
+// delay schedule point: delay time (in milliseconds), applied at offset from netem start
+type delayPoint struct {
+	offset time.Duration
+	time   int
+}
+
+// get delay schedule: linear ramp to target delay time or explicit delay schedule; none if both are not set
+func delaySchedule(delay int, rampTo int, rampStepStr string, scheduleStr string, duration time.Duration) ([]delayPoint, error) {
+	if rampTo != 0 && scheduleStr != "" {
+		return nil, errors.New("delay ramp and delay schedule cannot be used together")
+	}
+	if rampTo != 0 {
+		if rampTo < 0 {
+			return nil, errors.New("non-positive ramp target delay time")
+		}
+		step, err := time.ParseDuration(rampStepStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ramp step: %s", err)
+		}
+		return delayRamp(delay, rampTo, step, duration)
+	}
+	if scheduleStr != "" {
+		return parseDelaySchedule(scheduleStr, duration)
+	}
+	return nil, nil
+}
+
+// parse delay schedule: comma separated list of '<time>@<offset>' items, example: '100@1m,500@3m';
+// offsets must be increasing and shorter than netem duration
+func parseDelaySchedule(schedule string, duration time.Duration) ([]delayPoint, error) {
+	var points []delayPoint
+	var last time.Duration
+	for _, item := range strings.Split(schedule, ",") {
+		parts := strings.Split(strings.TrimSpace(item), "@")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("bad schedule item '%s': must be '<time>@<offset>'", item)
+		}
+		delay, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("bad schedule item '%s': invalid delay time: %s", item, err)
+		}
+		offset, err := time.ParseDuration(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("bad schedule item '%s': invalid offset: %s", item, err)
+		}
+		if offset <= last {
+			return nil, fmt.Errorf("bad schedule item '%s': offsets must be positive and increasing", item)
+		}
+		if offset >= duration {
+			return nil, fmt.Errorf("bad schedule item '%s': offset must be shorter than duration", item)
+		}
+		points = append(points, delayPoint{offset: offset, time: delay})
+		last = offset
+	}
+	return points, nil
+}
+
+// linear delay ramp from delay time to target delay time, changed every step;
+// target delay time is reached on last step, before netem duration ends
+func delayRamp(from int, to int, step time.Duration, duration time.Duration) ([]delayPoint, error) {
+	if step <= 0 {
+		return nil, errors.New("non-positive ramp step")
+	}
+	steps := int((duration - 1) / step)
+	if steps == 0 {
+		return nil, errors.New("ramp step must be shorter than duration")
+	}
+	points := make([]delayPoint, steps)
+	for i := range points {
+		points[i] = delayPoint{
+			offset: time.Duration(i+1) * step,
+			time:   from + (to-from)*(i+1)/steps,
+		}
+	}
+	return points, nil
+}
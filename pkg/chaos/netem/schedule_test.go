@@ -0,0 +1,145 @@
+package netem
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_delaySchedule(t *testing.T) {
+	type args struct {
+		delay    int
+		rampTo   int
+		rampStep string
+		schedule string
+		duration time.Duration
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []delayPoint
+		wantErr bool
+	}{
+		{
+			name: "no schedule",
+			args: args{delay: 10, rampStep: "10s", duration: time.Minute},
+		},
+		{
+			name: "ramp up",
+			args: args{delay: 10, rampTo: 500, rampStep: "1m", duration: 5 * time.Minute},
+			want: []delayPoint{
+				{offset: 1 * time.Minute, time: 132},
+				{offset: 2 * time.Minute, time: 255},
+				{offset: 3 * time.Minute, time: 377},
+				{offset: 4 * time.Minute, time: 500},
+			},
+		},
+		{
+			name: "ramp down",
+			args: args{delay: 300, rampTo: 100, rampStep: "10s", duration: 30 * time.Second},
+			want: []delayPoint{{offset: 10 * time.Second, time: 200}, {offset: 20 * time.Second, time: 100}},
+		},
+		{
+			name:    "ramp step longer than duration",
+			args:    args{delay: 10, rampTo: 500, rampStep: "1m", duration: time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "bad ramp step",
+			args:    args{delay: 10, rampTo: 500, rampStep: "fast", duration: time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "negative ramp target",
+			args:    args{delay: 10, rampTo: -1, rampStep: "10s", duration: time.Minute},
+			wantErr: true,
+		},
+		{
+			name: "step schedule",
+			args: args{delay: 10, schedule: "100@1m,500@3m", duration: 5 * time.Minute},
+			want: []delayPoint{{offset: time.Minute, time: 100}, {offset: 3 * time.Minute, time: 500}},
+		},
+		{
+			name:    "bad schedule item",
+			args:    args{delay: 10, schedule: "100", duration: 5 * time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "bad schedule delay",
+			args:    args{delay: 10, schedule: "slow@1m", duration: 5 * time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "bad schedule offset",
+			args:    args{delay: 10, schedule: "100@soon", duration: 5 * time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "decreasing schedule offsets",
+			args:    args{delay: 10, schedule: "100@3m,500@1m", duration: 5 * time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "schedule offset longer than duration",
+			args:    args{delay: 10, schedule: "100@5m", duration: 5 * time.Minute},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := delaySchedule(tt.args.delay, tt.args.rampTo, tt.args.rampStep, tt.args.schedule, tt.args.duration)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("delaySchedule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("delaySchedule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_runNetemSteps(t *testing.T) {
+	tests := []struct {
+		name      string
+		changeErr error
+		wantErr   bool
+	}{
+		{
+			name: "change netem on every step and stop on timeout",
+		},
+		{
+			name:      "stop netem on change error",
+			changeErr: errors.New("ERROR"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(container.MockClient)
+			c := container.CreateTestContainers(1)[0]
+			cmd := []string{"delay", "10ms"}
+			steps := []netemStep{
+				{offset: 1 * time.Millisecond, cmd: []string{"delay", "100ms"}},
+				{offset: 2 * time.Millisecond, cmd: []string{"delay", "200ms"}},
+			}
+			duration := 50 * time.Millisecond
+			mockClient.On("NetemContainer", mock.Anything, c, "eth0", container.DirectionEgress, cmd, container.NetemFilter{}, duration, "", false, false).Return(nil)
+			mockClient.On("ChangeNetemContainer", mock.Anything, c, "eth0", container.DirectionEgress, steps[0].cmd, container.NetemFilter{}, "", false, false).Return(tt.changeErr)
+			if tt.changeErr == nil {
+				mockClient.On("ChangeNetemContainer", mock.Anything, c, "eth0", container.DirectionEgress, steps[1].cmd, container.NetemFilter{}, "", false, false).Return(nil)
+			}
+			mockClient.On("StopNetemContainer", mock.Anything, c, "eth0", container.DirectionEgress, container.NetemFilter{}, "", false, false).Return(nil)
+			err := runNetemSteps(context.TODO(), mockClient, c, "eth0", "", container.DirectionEgress, cmd, steps, container.NetemFilter{}, duration, "", false, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("runNetemSteps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package netem
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewSlotCommand(t *testing.T) {
+	type args struct {
+		durationStr string
+		minDelay    int
+		maxDelay    int
+		packets     int
+		bytes       int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    chaos.Command
+		wantErr bool
+	}{
+		{
+			name: "create Slot command",
+			args: args{durationStr: "30s", minDelay: 10, maxDelay: 50, packets: 20, bytes: 4096},
+			want: &SlotCommand{
+				iface:     "eth0",
+				direction: "egress",
+				duration:  30 * time.Second,
+				minDelay:  10,
+				maxDelay:  50,
+				packets:   20,
+				bytes:     4096,
+			},
+		},
+		{
+			name:    "undefined duration",
+			args:    args{minDelay: 10},
+			wantErr: true,
+		},
+		{
+			name:    "no min delay",
+			args:    args{durationStr: "30s"},
+			wantErr: true,
+		},
+		{
+			name:    "max delay smaller than min delay",
+			args:    args{durationStr: "30s", minDelay: 10, maxDelay: 5},
+			wantErr: true,
+		},
+		{
+			name:    "negative packets",
+			args:    args{durationStr: "30s", minDelay: 10, packets: -1},
+			wantErr: true,
+		},
+		{
+			name:    "negative bytes",
+			args:    args{durationStr: "30s", minDelay: 10, bytes: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSlotCommand(nil, nil, "", nil, "eth0", "", "", Filter{}, tt.args.durationStr, "", tt.args.minDelay, tt.args.maxDelay, tt.args.packets, tt.args.bytes, "", false, 0, false, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSlotCommand() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewSlotCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlotCommand_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmd      *SlotCommand
+		netemCmd []string
+	}{
+		{
+			name:     "fixed slot delay",
+			cmd:      &SlotCommand{minDelay: 10},
+			netemCmd: []string{"slot", "10ms"},
+		},
+		{
+			name:     "random slot delay with size limits",
+			cmd:      &SlotCommand{minDelay: 10, maxDelay: 50, packets: 20, bytes: 4096},
+			netemCmd: []string{"slot", "10ms", "50ms", "packets", "20", "bytes", "4096"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(container.MockClient)
+			n := tt.cmd
+			n.client = mockClient
+			n.names = []string{"c1"}
+			n.iface = "eth0"
+			n.direction = container.DirectionIngress
+			n.duration = 10 * time.Microsecond
+			c := container.CreateTestContainers(1)
+			mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter")).Return(c, nil)
+			mockClient.On("NetemContainer", mock.Anything, c[0], "eth0", container.DirectionIngress, tt.netemCmd, n.filter, n.duration, "", false, false).Return(nil)
+			mockClient.On("StopNetemContainer", mock.Anything, c[0], "eth0", container.DirectionIngress, n.filter, "", false, false).Return(nil)
+			if err := n.Run(context.TODO(), false); err != nil {
+				t.Errorf("SlotCommand.Run() error = %v", err)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/partition"
+)
+
+type partitionContext struct {
+	context context.Context
+}
+
+// NewPartitionCLICommand initialize CLI partition command and bind it to the partitionContext
+func NewPartitionCLICommand(ctx context.Context) *cli.Command {
+	cmdContext := &partitionContext{context: ctx}
+	return &cli.Command{
+		Name: "partition",
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{
+				Name:  "group, g",
+				Usage: fmt.Sprintf("group of containers: comma separated list of names, or RE2 regex if prefixed with %q; at least two groups are required", chaos.Re2Prefix),
+			},
+			cli.StringFlag{
+				Name:  "duration, d",
+				Usage: "network partition duration; should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+			},
+			cli.StringFlag{
+				Name:  "interface, i",
+				Usage: "network interface to apply partition on",
+				Value: chaos.DefaultInterface,
+			},
+			cli.StringFlag{
+				Name:  "tc-image",
+				Usage: "Docker image with tc (iproute2 package); try 'gaiadocker/iproute2'",
+			},
+			cli.BoolTFlag{
+				Name:  "pull-image",
+				Usage: "try to pull tc-image",
+			},
+		},
+		Usage:       "partition network between groups of containers",
+		Description: "drop all traffic between containers of different groups, in both directions, for specified duration; containers inside the same group can talk to each other",
+		Action:      cmdContext.partition,
+	}
+}
+
+// PARTITION Command
+func (cmd *partitionContext) partition(c *cli.Context) error {
+	// get random flag
+	random := c.GlobalBool("random")
+	// get dry-run mode
+	dryRun := c.GlobalBool("dry-run")
+	// get global chaos interval
+	interval := c.GlobalString("interval")
+	// get container groups
+	groups := c.StringSlice("group")
+	// get network interface
+	iface := c.String("interface")
+	// get partition duration
+	duration := c.String("duration")
+	// get traffic control image
+	image := c.String("tc-image")
+	// get pull tc image flag
+	pull := c.BoolT("pull-image")
+	// init partition command
+	partitionCommand, err := partition.NewPartitionCommand(chaos.DockerClient, groups, iface, duration, interval, image, pull, dryRun)
+	if err != nil {
+		return err
+	}
+	// run partition command
+	return chaos.RunChaosCommand(cmd.context, partitionCommand, interval, random)
+}
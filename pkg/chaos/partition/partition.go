@@ -0,0 +1,253 @@
+package partition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/metrics"
+	"github.com/shinespb/pumba/pkg/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// drop all packets, sent to other group members
+var lossCmd = []string{"loss", "100%"}
+
+// group of containers: list of names or re2 regex pattern
+type group struct {
+	names   []string
+	pattern string
+}
+
+// partition member: container and IPs of all containers in other groups
+type member struct {
+	container container.Container
+	targets   []*net.IPNet
+}
+
+// PartitionCommand `partition` command
+type PartitionCommand struct {
+	client   container.Client
+	groups   []group
+	iface    string
+	duration time.Duration
+	image    string
+	pull     bool
+	dryRun   bool
+}
+
+// NewPartitionCommand create new partition command
+func NewPartitionCommand(client container.Client,
+	groups []string, // container groups: comma separated names or re2 regex pattern
+	iface string, // network interface
+	durationStr string, // chaos duration
+	intervalStr string, // repeatable chaos interval
+	image string, // traffic control image
+	pull bool, // pull tc image
+	dryRun bool, // dry-run do not partition just log
+) (chaos.Command, error) {
+	// log error
+	var err error
+	defer func() {
+		if err != nil {
+			log.WithError(err).Error("failed to construct Partition Command")
+		}
+	}()
+
+	// get interval
+	interval, err := util.GetIntervalValue(intervalStr)
+	if err != nil {
+		return nil, err
+	}
+	// get duration
+	duration, err := util.GetDurationValue(durationStr, interval)
+	if err != nil {
+		return nil, err
+	}
+	// protect from Command Injection, using Regexp
+	reInterface := regexp.MustCompile("[a-zA-Z][a-zA-Z0-9_-]*")
+	validIface := reInterface.FindString(iface)
+	if iface != validIface {
+		err = fmt.Errorf("bad network interface name: must match '%s'", reInterface.String())
+		return nil, err
+	}
+	// parse groups
+	if len(groups) < 2 {
+		err = errors.New("at least two container groups are required")
+		return nil, err
+	}
+	var gs []group
+	for _, g := range groups {
+		if strings.HasPrefix(g, chaos.Re2Prefix) {
+			gs = append(gs, group{pattern: strings.TrimPrefix(g, chaos.Re2Prefix)})
+			continue
+		}
+		var names []string
+		for _, name := range strings.Split(g, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			err = fmt.Errorf("bad group: '%s' is empty", g)
+			return nil, err
+		}
+		gs = append(gs, group{names: names})
+	}
+
+	return &PartitionCommand{
+		client:   client,
+		groups:   gs,
+		iface:    iface,
+		duration: duration,
+		image:    image,
+		pull:     pull,
+		dryRun:   dryRun,
+	}, nil
+}
+
+// Run partition command
+// random flag is ignored: all group members are always partitioned
+func (p *PartitionCommand) Run(ctx context.Context, random bool) error {
+	log.Debug("partitioning network between container groups")
+	members, err := p.resolveMembers(ctx)
+	if err != nil {
+		return err
+	}
+
+	// apply partition on all members together
+	errs := forEach(members, func(m member) error {
+		return p.client.NetemContainer(ctx, m.container, p.iface, container.DirectionEgress, lossCmd, container.NetemFilter{Targets: m.targets}, p.duration, p.image, p.pull, p.dryRun)
+	})
+	if err = firstError(errs); err != nil {
+		log.WithError(err).Error("failed to partition network, restoring already partitioned containers")
+		// restore members with applied partition and return original error
+		var partitioned []member
+		for i, m := range members {
+			if errs[i] == nil {
+				partitioned = append(partitioned, m)
+			}
+		}
+		p.restore(partitioned)
+		return err
+	}
+
+	metrics.NetemSessions.Add(float64(len(members)))
+	defer metrics.NetemSessions.Sub(float64(len(members)))
+
+	// wait for specified duration and then restore all members together or restore on ctx.Done()
+	select {
+	case <-ctx.Done():
+		log.Debug("restoring network partition on abort")
+	case <-time.After(p.duration):
+		log.Debug("restoring network partition on timout")
+	}
+	return p.restore(members)
+}
+
+// list containers of every group and resolve IPs of other groups for every member
+func (p *PartitionCommand) resolveMembers(ctx context.Context) ([]member, error) {
+	groupContainers := make([][]container.Container, len(p.groups))
+	groupIPs := make([][]*net.IPNet, len(p.groups))
+	seen := map[string]int{}
+	for i, g := range p.groups {
+		log.WithFields(log.Fields{
+			"group":   i,
+			"names":   g.names,
+			"pattern": g.pattern,
+		}).Debug("listing group containers")
+		containers, err := container.ListRunningContainers(ctx, p.client, g.names, g.pattern, nil)
+		if err != nil {
+			log.WithError(err).Error("failed to list containers")
+			return nil, err
+		}
+		if len(containers) == 0 {
+			return nil, fmt.Errorf("no containers found for group %d", i+1)
+		}
+		for _, c := range containers {
+			// container cannot be on both sides of partition
+			if j, ok := seen[c.ID()]; ok {
+				return nil, fmt.Errorf("container %s belongs to groups %d and %d", c.Name(), j+1, i+1)
+			}
+			seen[c.ID()] = i
+			for _, ip := range c.IPAddresses() {
+				if ipNet := util.ParseCIDR(ip); ipNet != nil {
+					groupIPs[i] = append(groupIPs[i], ipNet)
+				}
+			}
+		}
+		if len(groupIPs[i]) == 0 {
+			return nil, fmt.Errorf("no IP addresses found for group %d containers", i+1)
+		}
+		groupContainers[i] = containers
+	}
+
+	// block traffic to all other groups on every member: symmetric, since all groups do the same
+	var members []member
+	for i, containers := range groupContainers {
+		var targets []*net.IPNet
+		for j, ips := range groupIPs {
+			if i != j {
+				targets = append(targets, ips...)
+			}
+		}
+		for _, c := range containers {
+			log.WithFields(log.Fields{
+				"group":   i,
+				"name":    c.Name(),
+				"targets": targets,
+			}).Debug("partition member")
+			members = append(members, member{container: c, targets: targets})
+		}
+	}
+	return members, nil
+}
+
+// stop netem on all members; returns first error
+func (p *PartitionCommand) restore(members []member) error {
+	// use different context to restore since parent context can be canceled
+	errs := forEach(members, func(m member) error {
+		return p.client.StopNetemContainer(context.Background(), m.container, p.iface, container.DirectionEgress, container.NetemFilter{Targets: m.targets}, p.image, p.pull, p.dryRun)
+	})
+	err := firstError(errs)
+	if err != nil {
+		log.WithError(err).Error("failed to restore network partition")
+	}
+	return err
+}
+
+// run function for every member in parallel and wait for all to complete
+func forEach(members []member, fn func(member) error) []error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(members))
+	for i, m := range members {
+		wg.Add(1)
+		go func(i int, m member) {
+			defer wg.Done()
+			errs[i] = fn(m)
+			if errs[i] != nil {
+				log.WithField("container", m.container).WithError(errs[i]).Error("partition command failed for container")
+			}
+		}(i, m)
+	}
+	wg.Wait()
+	return errs
+}
+
+// take first found error
+func firstError(errs []error) error {
+	for _, e := range errs {
+		if e != nil {
+			return e
+		}
+	}
+	return nil
+}
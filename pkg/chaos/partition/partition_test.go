@@ -0,0 +1,171 @@
+package partition
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/util"
+	"github.com/stretchr/testify/mock"
+)
+
+func testContainer(id, ip string) container.Container {
+	return *container.NewContainer(
+		container.ContainerDetailsResponse(container.AsMap("ID", id, "Name", id, "IPAddress", ip)),
+		container.ImageDetailsResponse(container.AsMap()),
+	)
+}
+
+func TestNewPartitionCommand(t *testing.T) {
+	type args struct {
+		groups      []string
+		iface       string
+		durationStr string
+		intervalStr string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    chaos.Command
+		wantErr bool
+	}{
+		{
+			name: "create partition command",
+			args: args{
+				groups:      []string{"re2:^node[12]$", "node3, node4"},
+				iface:       "eth0",
+				durationStr: "30s",
+				intervalStr: "1m",
+			},
+			want: &PartitionCommand{
+				groups: []group{
+					{pattern: "^node[12]$"},
+					{names: []string{"node3", "node4"}},
+				},
+				iface:    "eth0",
+				duration: 30 * time.Second,
+			},
+		},
+		{
+			name: "single group",
+			args: args{
+				groups:      []string{"re2:node"},
+				iface:       "eth0",
+				durationStr: "30s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty group",
+			args: args{
+				groups:      []string{"node1", " , "},
+				iface:       "eth0",
+				durationStr: "30s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "bad network interface name",
+			args: args{
+				groups:      []string{"node1", "node2"},
+				iface:       "bad#interface",
+				durationStr: "30s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "duration is bigger than interval value",
+			args: args{
+				groups:      []string{"node1", "node2"},
+				iface:       "eth0",
+				intervalStr: "1m",
+				durationStr: "2m",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPartitionCommand(nil, tt.args.groups, tt.args.iface, tt.args.durationStr, tt.args.intervalStr, "", false, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewPartitionCommand() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewPartitionCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartitionCommand_Run(t *testing.T) {
+	node1 := testContainer("node1", "10.0.0.1")
+	node2 := testContainer("node2", "10.0.0.2")
+	node3 := testContainer("node3", "10.0.0.3")
+	sideA := []*net.IPNet{util.ParseCIDR("10.0.0.1"), util.ParseCIDR("10.0.0.2")}
+	sideB := []*net.IPNet{util.ParseCIDR("10.0.0.3")}
+	tests := []struct {
+		name     string
+		groups   [][]container.Container
+		listErr  bool
+		netemErr bool
+		abort    bool
+		wantErr  bool
+	}{
+		{name: "partition two groups", groups: [][]container.Container{{node1, node2}, {node3}}},
+		{name: "partition on abort", groups: [][]container.Container{{node1, node2}, {node3}}, abort: true},
+		{name: "error listing containers", groups: [][]container.Container{{node1, node2}}, listErr: true, wantErr: true},
+		{name: "empty group", groups: [][]container.Container{{node1, node2}, {}}, wantErr: true},
+		{name: "container in both groups", groups: [][]container.Container{{node1, node2}, {node2}}, wantErr: true},
+		{name: "group without IPs", groups: [][]container.Container{{node1, node2}, {testContainer("node4", "")}}, wantErr: true},
+		{name: "restore partitioned containers on error", groups: [][]container.Container{{node1, node2}, {node3}}, netemErr: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(container.MockClient)
+			p := &PartitionCommand{
+				client:   mockClient,
+				groups:   []group{{pattern: "^node[12]$"}, {names: []string{"node3"}}},
+				iface:    "eth0",
+				duration: 10 * time.Microsecond,
+			}
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			if tt.abort {
+				p.duration = time.Hour
+				cancel()
+			}
+			if tt.listErr {
+				mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter")).Return(nil, errors.New("ERROR"))
+				goto Invoke
+			}
+			for _, g := range tt.groups {
+				mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter")).Return(g, nil).Once()
+			}
+			if tt.wantErr && !tt.netemErr {
+				goto Invoke
+			}
+			for _, c := range []container.Container{node1, node2} {
+				mockClient.On("NetemContainer", mock.Anything, c, "eth0", "egress", lossCmd, container.NetemFilter{Targets: sideB}, p.duration, "", false, false).Return(nil)
+				mockClient.On("StopNetemContainer", mock.Anything, c, "eth0", "egress", container.NetemFilter{Targets: sideB}, "", false, false).Return(nil)
+			}
+			if tt.netemErr {
+				// node3 failed: restore node1 and node2 only
+				mockClient.On("NetemContainer", mock.Anything, node3, "eth0", "egress", lossCmd, container.NetemFilter{Targets: sideA}, p.duration, "", false, false).Return(errors.New("ERROR"))
+				goto Invoke
+			}
+			mockClient.On("NetemContainer", mock.Anything, node3, "eth0", "egress", lossCmd, container.NetemFilter{Targets: sideA}, p.duration, "", false, false).Return(nil)
+			mockClient.On("StopNetemContainer", mock.Anything, node3, "eth0", "egress", container.NetemFilter{Targets: sideA}, "", false, false).Return(nil)
+		Invoke:
+			if err := p.Run(ctx, false); (err != nil) != tt.wantErr {
+				t.Errorf("PartitionCommand.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/scenario"
+)
+
+type runContext struct {
+	context context.Context
+}
+
+// NewRunCLICommand initialize CLI run command and bind it to the runContext
+func NewRunCLICommand(ctx context.Context) *cli.Command {
+	cmdContext := &runContext{context: ctx}
+	return &cli.Command{
+		Name:        "run",
+		Usage:       "run chaos scenario from YAML file",
+		ArgsUsage:   "scenario.yaml",
+		Description: "run chaos scenario: list of steps (kill, netem delay, pause, ...), executed one by one, with optional delays between steps and groups of parallel or serial steps; abort restores all running steps",
+		Action:      cmdContext.run,
+	}
+}
+
+// RUN Command
+func (cmd *runContext) run(c *cli.Context) error {
+	// get dry-run mode
+	dryRun := c.GlobalBool("dry-run")
+	// get global chaos interval: repeat scenario
+	interval := c.GlobalString("interval")
+	// get scenario file
+	if !c.Args().Present() {
+		return errors.New("scenario file is required")
+	}
+	path := c.Args().First()
+	// load scenario and init commands for all steps
+	scenarioCommand, err := scenario.NewScenarioCommand(chaos.DockerClient, path, dryRun)
+	if err != nil {
+		return err
+	}
+	// run scenario; steps select random container with own 'random' field
+	return chaos.RunChaosCommand(cmd.context, scenarioCommand, interval, false)
+}
@@ -0,0 +1,234 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Scenario chaos experiment: list of steps, executed one by one
+type Scenario struct {
+	Name  string `yaml:"name"`
+	Steps []Step `yaml:"steps"`
+}
+
+// Step scenario step: single chaos command or group of parallel or serial steps
+type Step struct {
+	// step name; used for logging
+	Name string `yaml:"name"`
+	// delay before step execution; use with optional unit suffix: 'ms/s/m/h'
+	Delay string `yaml:"delay"`
+	// chaos command: 'kill', 'netem delay', 'iptables drop', ...
+	Command string `yaml:"command"`
+	// target containers: list of names or single RE2 regex, prefixed with 're2:'
+	Containers []string `yaml:"containers"`
+	// target container groups; used by 'partition' command only
+	Groups []string `yaml:"groups"`
+	// target Swarm services; used by 'service scale' and 'service restart' commands only
+	Services []string `yaml:"services"`
+	// label selectors
+	Labels []string `yaml:"labels"`
+	// limit number of target containers
+	Limit int `yaml:"limit"`
+	// apply limit to every Docker Compose service
+	PerService bool `yaml:"per-service"`
+	// randomly select single matching container
+	Random bool `yaml:"random"`
+	// chaos duration; use with optional unit suffix: 'ms/s/m/h'
+	Duration string `yaml:"duration"`
+	// command specific options: same as command line flags
+	Options map[string]string `yaml:"options"`
+	// steps to execute in parallel
+	Parallel []Step `yaml:"parallel"`
+	// steps to execute one by one
+	Serial []Step `yaml:"serial"`
+
+	// delay and chaos command, initialized on scenario load
+	delay time.Duration
+	cmd   chaos.Command
+}
+
+// ScenarioCommand `run` command: runs scenario steps
+type ScenarioCommand struct {
+	scenario *Scenario
+}
+
+// NewScenarioCommand load scenario file and create new scenario command
+func NewScenarioCommand(client container.Client, path string, dryRun bool) (chaos.Command, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.WithError(err).WithField("file", path).Error("failed to read scenario file")
+		return nil, err
+	}
+	s, err := Parse(client, data, dryRun)
+	if err != nil {
+		log.WithError(err).WithField("file", path).Error("failed to load scenario")
+		return nil, err
+	}
+	return &ScenarioCommand{scenario: s}, nil
+}
+
+// Parse parse YAML scenario and initialize chaos commands for all steps
+func Parse(client container.Client, data []byte, dryRun bool) (*Scenario, error) {
+	var s Scenario
+	if err := yaml.UnmarshalStrict(data, &s); err != nil {
+		return nil, err
+	}
+	if len(s.Steps) == 0 {
+		return nil, errors.New("scenario has no steps")
+	}
+	for i := range s.Steps {
+		if err := initStep(client, &s.Steps[i], fmt.Sprintf("steps[%d]", i), dryRun); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// NewStepCommand create chaos command for single command step; options are the same as command line flags
+func NewStepCommand(client container.Client, step *Step, dryRun bool) (chaos.Command, error) {
+	if step.Command == "" {
+		return nil, errors.New("undefined command")
+	}
+	return buildCommand(client, step, dryRun)
+}
+
+// validate step and create chaos command, so all errors are reported before scenario starts
+func initStep(client container.Client, step *Step, path string, dryRun bool) error {
+	if step.Name != "" {
+		path = fmt.Sprintf("%s (%s)", path, step.Name)
+	}
+	if step.Delay != "" {
+		delay, err := time.ParseDuration(step.Delay)
+		if err != nil {
+			return fmt.Errorf("%s: bad delay: %v", path, err)
+		}
+		step.delay = delay
+	}
+	// step must be either command or group of steps
+	kinds := 0
+	for _, defined := range []bool{step.Command != "", len(step.Parallel) > 0, len(step.Serial) > 0} {
+		if defined {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("%s: step must define exactly one of 'command', 'parallel' or 'serial'", path)
+	}
+	for i := range step.Parallel {
+		if err := initStep(client, &step.Parallel[i], fmt.Sprintf("%s.parallel[%d]", path, i), dryRun); err != nil {
+			return err
+		}
+	}
+	for i := range step.Serial {
+		if err := initStep(client, &step.Serial[i], fmt.Sprintf("%s.serial[%d]", path, i), dryRun); err != nil {
+			return err
+		}
+	}
+	if step.Command != "" {
+		cmd, err := buildCommand(client, step, dryRun)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		step.cmd = cmd
+	}
+	return nil
+}
+
+// Run scenario steps
+// random flag is ignored: use step 'random' field instead
+func (s *ScenarioCommand) Run(ctx context.Context, random bool) error {
+	log.WithField("scenario", s.scenario.Name).Info("running scenario")
+	err := runSerial(ctx, s.scenario.Steps)
+	if err != nil {
+		log.WithError(err).WithField("scenario", s.scenario.Name).Error("scenario failed")
+		return err
+	}
+	log.WithField("scenario", s.scenario.Name).Info("scenario completed")
+	return nil
+}
+
+// run steps one by one, stop on first error or abort
+func runSerial(ctx context.Context, steps []Step) error {
+	for i := range steps {
+		if err := runStep(ctx, &steps[i]); err != nil {
+			return err
+		}
+		// do not start next step on abort
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// run steps in parallel, wait for all to complete and return first error;
+// failed step aborts its siblings, which undo their chaos as on abort
+func runParallel(ctx context.Context, steps []Step) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var err error
+	for i := range steps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if e := runStep(ctx, &steps[i]); e != nil {
+				once.Do(func() {
+					err = e
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	return err
+}
+
+// run single step: wait for delay and run command or group of steps
+func runStep(ctx context.Context, step *Step) error {
+	if step.delay > 0 {
+		log.WithFields(log.Fields{
+			"step":  step.Name,
+			"delay": step.delay,
+		}).Debug("waiting before step")
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(step.delay):
+		}
+	}
+	switch {
+	case len(step.Parallel) > 0:
+		return runParallel(ctx, step.Parallel)
+	case len(step.Serial) > 0:
+		return runSerial(ctx, step.Serial)
+	}
+	log.WithFields(log.Fields{
+		"step":       step.Name,
+		"command":    step.Command,
+		"containers": step.Containers,
+	}).Info("running scenario step")
+	if err := step.cmd.Run(ctx, step.Random); err != nil {
+		return fmt.Errorf("step '%s' failed: %v", stepTitle(step), err)
+	}
+	return nil
+}
+
+func stepTitle(step *Step) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	return strings.TrimSpace(step.Command + " " + strings.Join(step.Containers, " "))
+}
@@ -0,0 +1,270 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testScenario = `
+name: failover
+steps:
+  - name: slow down db
+    command: netem delay
+    containers: ["re2:^db"]
+    duration: 30s
+    options:
+      time: 300
+      jitter: 30
+      target: 10.0.0.1, 10.0.0.2
+  - delay: 10s
+    parallel:
+      - command: kill
+        containers: [api1, api2]
+        limit: 1
+        random: true
+      - command: iptables reject
+        containers: [cache]
+        duration: 20s
+        options:
+          protocol: tcp
+          dst-port: 6379
+          reject-with: tcp-reset
+  - command: netem profile
+    containers: [api1]
+    duration: 1m
+    options:
+      preset: 3g
+      loss: 5
+  - serial:
+      - command: pause
+        labels: ["app=web"]
+        duration: 5s
+      - command: partition
+        groups: ["re2:^node[12]$", "node3"]
+        duration: 1m
+  - parallel:
+      - command: service scale
+        services: [web]
+        duration: 1m
+        options:
+          replicas: 1
+      - command: service restart
+        services: [api, worker]
+        random: true
+        duration: 2m
+`
+
+func TestParse(t *testing.T) {
+	s, err := Parse(nil, []byte(testScenario), false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "failover", s.Name)
+	assert.Len(t, s.Steps, 5)
+	assert.NotNil(t, s.Steps[0].cmd)
+	assert.Equal(t, 10*time.Second, s.Steps[1].delay)
+	assert.Nil(t, s.Steps[1].cmd)
+	assert.NotNil(t, s.Steps[1].Parallel[0].cmd)
+	assert.NotNil(t, s.Steps[1].Parallel[1].cmd)
+	assert.NotNil(t, s.Steps[2].cmd)
+	assert.NotNil(t, s.Steps[3].Serial[0].cmd)
+	assert.NotNil(t, s.Steps[3].Serial[1].cmd)
+	assert.NotNil(t, s.Steps[4].Parallel[0].cmd)
+	assert.NotNil(t, s.Steps[4].Parallel[1].cmd)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		err      string
+	}{
+		{
+			name:     "no steps",
+			scenario: "name: empty",
+			err:      "scenario has no steps",
+		},
+		{
+			name:     "unknown field",
+			scenario: "steps:\n  - command: kill\n    target: [c1]",
+			err:      "field target not found",
+		},
+		{
+			name:     "unknown command",
+			scenario: "steps:\n  - command: explode",
+			err:      "unknown command 'explode'",
+		},
+		{
+			name:     "command and group",
+			scenario: "steps:\n  - command: kill\n    serial:\n      - command: kill",
+			err:      "steps[0]: step must define exactly one of",
+		},
+		{
+			name:     "empty step",
+			scenario: "steps:\n  - name: nothing",
+			err:      "steps[0] (nothing): step must define exactly one of",
+		},
+		{
+			name:     "bad delay",
+			scenario: "steps:\n  - delay: soon\n    command: kill",
+			err:      "steps[0]: bad delay",
+		},
+		{
+			name:     "bad option value",
+			scenario: "steps:\n  - command: netem delay\n    duration: 10s\n    options:\n      time: long",
+			err:      "bad option 'time': 'long' is not a valid integer",
+		},
+		{
+			name:     "unknown option",
+			scenario: "steps:\n  - command: kill\n    options:\n      signal: SIGTERM\n      sginal: SIGTERM",
+			err:      "unknown options: sginal",
+		},
+		{
+			name:     "unknown netem preset",
+			scenario: "steps:\n  - command: netem profile\n    duration: 10s\n    options:\n      preset: dial-up",
+			err:      "unknown preset 'dial-up'",
+		},
+		{
+			name:     "regex in containers list",
+			scenario: "steps:\n  - command: kill\n    containers: [c1, 're2:^c']",
+			err:      "steps[0]: containers: 're2:^c' regex must be the only entry",
+		},
+		{
+			name:     "service without services",
+			scenario: "steps:\n  - command: service restart\n    duration: 10s",
+			err:      "steps[0]: undefined Swarm service",
+		},
+		{
+			name:     "negative service replicas",
+			scenario: "steps:\n  - command: service scale\n    services: [web]\n    duration: 10s\n    options:\n      replicas: -1",
+			err:      "steps[0]: replicas must not be negative",
+		},
+		{
+			name:     "command error in nested step",
+			scenario: "steps:\n  - parallel:\n      - command: pause",
+			err:      "steps[0].parallel[0]: undefined duration",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(nil, []byte(tt.scenario), false)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.err)
+			}
+		})
+	}
+}
+
+func TestNewScenarioCommand_File(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scenario")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "scenario.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(testScenario), 0644))
+
+	cmd, err := NewScenarioCommand(nil, path, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, cmd)
+
+	_, err = NewScenarioCommand(nil, filepath.Join(dir, "missing.yaml"), true)
+	assert.Error(t, err)
+}
+
+func TestScenarioCommand_Run(t *testing.T) {
+	scenario := `
+steps:
+  - command: kill
+    containers: [c1]
+  - parallel:
+      - command: kill
+        containers: [c2]
+        options:
+          signal: SIGTERM
+      - command: kill
+        containers: [c3]
+        options:
+          signal: SIGINT
+`
+	tests := []struct {
+		name    string
+		killErr bool
+		wantErr bool
+	}{
+		{name: "run all steps"},
+		{name: "stop on failed step", killErr: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(container.MockClient)
+			s, err := Parse(mockClient, []byte(scenario), false)
+			assert.NoError(t, err)
+			c := container.CreateTestContainers(1)
+			mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter")).Return(c, nil)
+			if tt.killErr {
+				mockClient.On("KillContainer", mock.Anything, c[0], "SIGKILL", false).Return(errors.New("ERROR"))
+			} else {
+				mockClient.On("KillContainer", mock.Anything, c[0], "SIGKILL", false).Return(nil)
+				mockClient.On("KillContainer", mock.Anything, c[0], "SIGTERM", false).Return(nil)
+				mockClient.On("KillContainer", mock.Anything, c[0], "SIGINT", false).Return(nil)
+			}
+			cmd := &ScenarioCommand{scenario: s}
+			if err := cmd.Run(context.TODO(), false); (err != nil) != tt.wantErr {
+				t.Errorf("ScenarioCommand.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestScenarioCommand_RunParallelCancel(t *testing.T) {
+	scenario := `
+steps:
+  - parallel:
+      - command: kill
+        containers: [c1]
+      - command: pause
+        containers: [c2]
+        duration: 1h
+`
+	mockClient := new(container.MockClient)
+	s, err := Parse(mockClient, []byte(scenario), false)
+	assert.NoError(t, err)
+	c := container.CreateTestContainers(1)
+	mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter")).Return(c, nil)
+	mockClient.On("KillContainer", mock.Anything, c[0], "SIGKILL", false).Return(errors.New("ERROR"))
+	// failed kill step aborts pause step: container is unpaused without waiting for pause duration
+	mockClient.On("PauseContainer", mock.Anything, c[0], false).Return(nil)
+	mockClient.On("UnpauseContainer", mock.Anything, c[0], false).Return(nil)
+
+	cmd := &ScenarioCommand{scenario: s}
+	assert.EqualError(t, cmd.Run(context.TODO(), false), "step 'kill c1' failed: ERROR")
+	mockClient.AssertExpectations(t)
+}
+
+func TestScenarioCommand_RunAbort(t *testing.T) {
+	scenario := `
+steps:
+  - delay: 1h
+    command: kill
+    containers: [c1]
+  - command: kill
+    containers: [c2]
+`
+	mockClient := new(container.MockClient)
+	s, err := Parse(mockClient, []byte(scenario), false)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	cmd := &ScenarioCommand{scenario: s}
+	assert.NoError(t, cmd.Run(ctx, false))
+	mockClient.AssertNotCalled(t, "ListContainers", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,435 @@
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/docker"
+	"github.com/shinespb/pumba/pkg/chaos/iptables"
+	"github.com/shinespb/pumba/pkg/chaos/netem"
+	"github.com/shinespb/pumba/pkg/chaos/partition"
+	"github.com/shinespb/pumba/pkg/chaos/service"
+	"github.com/shinespb/pumba/pkg/chaos/stress"
+	"github.com/shinespb/pumba/pkg/container"
+)
+
+// step builder: creates chaos command from step definition, using the same constructor as CLI command
+type builder func(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error)
+
+// supported scenario commands
+var builders = map[string]builder{
+	"kill":               buildKill,
+	"stop":               buildStop,
+	"pause":              buildPause,
+	"rm":                 buildRemove,
+	"stress":             buildStress,
+	"netem delay":        buildNetemDelay,
+	"netem loss":         buildNetemLoss,
+	"netem loss-state":   buildNetemLossState,
+	"netem loss-gemodel": buildNetemLossGE,
+	"netem rate":         buildNetemRate,
+	"netem duplicate":    buildNetemDuplicate,
+	"netem corrupt":      buildNetemCorrupt,
+	"netem reorder":      buildNetemReorder,
+	"netem slot":         buildNetemSlot,
+	"netem replay":       buildNetemReplay,
+	"netem profile":      buildNetemProfile,
+	"iptables drop":      buildIPTablesDrop,
+	"iptables reject":    buildIPTablesReject,
+	"partition":          buildPartition,
+	"service scale":      buildServiceScale,
+	"service restart":    buildServiceRestart,
+}
+
+// Commands list of supported scenario commands
+func Commands() []string {
+	commands := make([]string, 0, len(builders))
+	for name := range builders {
+		commands = append(commands, name)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+func buildCommand(client container.Client, step *Step, dryRun bool) (chaos.Command, error) {
+	build, ok := builders[strings.Join(strings.Fields(step.Command), " ")]
+	if !ok {
+		return nil, fmt.Errorf("unknown command '%s': must be one of {%s}", step.Command, strings.Join(Commands(), " | "))
+	}
+	// regex must be single containers entry: it cannot be mixed with names
+	if len(step.Containers) > 1 {
+		for _, name := range step.Containers {
+			if strings.HasPrefix(name, chaos.Re2Prefix) {
+				return nil, fmt.Errorf("containers: '%s' regex must be the only entry", name)
+			}
+		}
+	}
+	opts := &options{values: step.Options, used: map[string]bool{}}
+	cmd, err := build(client, step, opts, dryRun)
+	// report bad or unknown options first: command error can be caused by them
+	if optsErr := opts.check(); optsErr != nil {
+		return nil, optsErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// get names or pattern from step containers
+func namesOrPattern(step *Step) ([]string, string) {
+	if len(step.Containers) == 1 && strings.HasPrefix(step.Containers[0], chaos.Re2Prefix) {
+		return []string{}, strings.TrimPrefix(step.Containers[0], chaos.Re2Prefix)
+	}
+	return append([]string{}, step.Containers...), ""
+}
+
+func buildKill(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	signal := opts.str("signal", docker.DefaultKillSignal)
+	return docker.NewKillCommand(client, names, pattern, step.Labels, signal, step.Limit, step.PerService, dryRun)
+}
+
+func buildStop(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	waitTime := opts.integer("time", docker.DeafultWaitTime)
+	restart := opts.boolean("restart", false)
+	duration := step.Duration
+	if duration == "" {
+		duration = docker.DefaultStopDuration
+	}
+	return docker.NewStopCommand(client, names, pattern, step.Labels, restart, "", duration, waitTime, step.Limit, step.PerService, dryRun)
+}
+
+func buildPause(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	return docker.NewPauseCommand(client, names, pattern, step.Labels, "", step.Duration, step.Limit, step.PerService, dryRun)
+}
+
+func buildRemove(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	force := opts.boolean("force", true)
+	links := opts.boolean("links", false)
+	volumes := opts.boolean("volumes", true)
+	return docker.NewRemoveCommand(client, names, pattern, step.Labels, force, links, volumes, step.Limit, step.PerService, dryRun)
+}
+
+func buildStress(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	stressors := opts.str("stressors", stress.DefaultStressors)
+	image := opts.str("stress-image", stress.DefaultStressImage)
+	pull := opts.boolean("pull-image", true)
+	hostCgroup := opts.boolean("host-cgroup", false)
+	return stress.NewStressCommand(client, names, pattern, step.Labels, stressors, image, pull, hostCgroup, step.Duration, "", step.Limit, step.PerService, dryRun)
+}
+
+// netem options, shared by all netem commands (parent `netem` command flags)
+type netemOptions struct {
+	iface     string
+	network   string
+	direction string
+	filter    netem.Filter
+	image     string
+	pull      bool
+}
+
+func getNetemOptions(opts *options) netemOptions {
+	return netemOptions{
+		iface:     opts.str("interface", chaos.DefaultInterface),
+		network:   opts.str("network", ""),
+		direction: opts.str("direction", container.DirectionEgress),
+		filter: netem.Filter{
+			Targets:  opts.list("target"),
+			Sources:  opts.list("source"),
+			Ports:    opts.str("port", ""),
+			SrcPorts: opts.str("src-port", ""),
+			Protocol: opts.str("protocol", ""),
+		},
+		image: opts.str("tc-image", ""),
+		pull:  opts.boolean("pull-image", true),
+	}
+}
+
+func buildNetemDelay(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	n := getNetemOptions(opts)
+	time := opts.integer("time", 100)
+	jitter := opts.integer("jitter", 10)
+	correlation := opts.float("correlation", 20)
+	distribution := opts.str("distribution", "")
+	rampTo := opts.integer("ramp-to", 0)
+	rampStep := opts.str("ramp-step", netem.DefaultRampStep)
+	schedule := opts.str("schedule", "")
+	return netem.NewDelayCommand(client, names, pattern, step.Labels, n.iface, n.network, n.direction, n.filter, step.Duration, "", time, jitter, correlation, distribution, rampTo, rampStep, schedule, n.image, n.pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildNetemLoss(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	n := getNetemOptions(opts)
+	percent := opts.float("percent", 0)
+	correlation := opts.float("correlation", 0)
+	return netem.NewLossCommand(client, names, pattern, step.Labels, n.iface, n.network, n.direction, n.filter, step.Duration, "", percent, correlation, n.image, n.pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildNetemLossState(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	n := getNetemOptions(opts)
+	p13 := opts.float("p13", 0)
+	p31 := opts.float("p31", 100)
+	p32 := opts.float("p32", 0)
+	p23 := opts.float("p23", 100)
+	p14 := opts.float("p14", 0)
+	return netem.NewLossStateCommand(client, names, pattern, step.Labels, n.iface, n.network, n.direction, n.filter, step.Duration, "", p13, p31, p32, p23, p14, n.image, n.pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildNetemLossGE(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	n := getNetemOptions(opts)
+	pg := opts.float("pg", 0)
+	pb := opts.float("pb", 100)
+	oneH := opts.float("one-h", 100)
+	oneK := opts.float("one-k", 0)
+	return netem.NewLossGECommand(client, names, pattern, step.Labels, n.iface, n.network, n.direction, n.filter, step.Duration, "", pg, pb, oneH, oneK, n.image, n.pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildNetemRate(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	n := getNetemOptions(opts)
+	rate := opts.str("rate", netem.DefaultRate)
+	packetOverhead := opts.integer("packetoverhead", 0)
+	cellSize := opts.integer("cellsize", 0)
+	cellOverhead := opts.integer("celloverhead", 0)
+	return netem.NewRateCommand(client, names, pattern, step.Labels, n.iface, n.network, n.direction, n.filter, step.Duration, "", rate, packetOverhead, cellSize, cellOverhead, n.image, n.pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildNetemDuplicate(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	n := getNetemOptions(opts)
+	percent := opts.float("percent", 0)
+	correlation := opts.float("correlation", 0)
+	return netem.NewDuplicateCommand(client, names, pattern, step.Labels, n.iface, n.network, n.direction, n.filter, step.Duration, "", percent, correlation, n.image, n.pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildNetemCorrupt(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	n := getNetemOptions(opts)
+	percent := opts.float("percent", 0)
+	correlation := opts.float("correlation", 0)
+	return netem.NewCorruptCommand(client, names, pattern, step.Labels, n.iface, n.network, n.direction, n.filter, step.Duration, "", percent, correlation, n.image, n.pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildNetemReorder(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	n := getNetemOptions(opts)
+	time := opts.integer("time", 10)
+	percent := opts.float("percent", 0)
+	correlation := opts.float("correlation", 0)
+	gap := opts.integer("gap", 0)
+	return netem.NewReorderCommand(client, names, pattern, step.Labels, n.iface, n.network, n.direction, n.filter, step.Duration, "", time, percent, correlation, gap, n.image, n.pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildNetemSlot(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	n := getNetemOptions(opts)
+	minDelay := opts.integer("min-delay", 10)
+	maxDelay := opts.integer("max-delay", 0)
+	packets := opts.integer("packets", 0)
+	bytes := opts.integer("bytes", 0)
+	return netem.NewSlotCommand(client, names, pattern, step.Labels, n.iface, n.network, n.direction, n.filter, step.Duration, "", minDelay, maxDelay, packets, bytes, n.image, n.pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildNetemReplay(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	n := getNetemOptions(opts)
+	trace := opts.str("trace", "")
+	speed := opts.float("speed", 1)
+	return netem.NewReplayCommand(client, names, pattern, step.Labels, n.iface, n.network, n.direction, n.filter, step.Duration, "", trace, speed, n.image, n.pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildNetemProfile(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	n := getNetemOptions(opts)
+	// preset values are defaults for explicit options; report preset error after all options are read
+	p, err := getPreset(opts)
+	profile := netem.Profile{
+		Delay:                opts.integer("delay", p.Delay),
+		Jitter:               opts.integer("jitter", p.Jitter),
+		DelayCorrelation:     opts.float("delay-correlation", p.DelayCorrelation),
+		Distribution:         opts.str("distribution", p.Distribution),
+		Loss:                 opts.float("loss", p.Loss),
+		LossCorrelation:      opts.float("loss-correlation", p.LossCorrelation),
+		Duplicate:            opts.float("duplicate", p.Duplicate),
+		DuplicateCorrelation: opts.float("duplicate-correlation", p.DuplicateCorrelation),
+		Corrupt:              opts.float("corrupt", p.Corrupt),
+		CorruptCorrelation:   opts.float("corrupt-correlation", p.CorruptCorrelation),
+		Rate:                 opts.str("rate", p.Rate),
+	}
+	if err != nil {
+		return nil, err
+	}
+	return netem.NewProfileCommand(client, names, pattern, step.Labels, n.iface, n.network, n.direction, n.filter, step.Duration, "", profile, n.image, n.pull, step.Limit, step.PerService, dryRun)
+}
+
+// get netem profile preset from 'preset' and 'presets-file' options
+func getPreset(opts *options) (netem.Profile, error) {
+	presetsFile := opts.str("presets-file", "")
+	preset := opts.str("preset", "")
+	if preset == "" {
+		return netem.Profile{}, nil
+	}
+	presets, err := netem.LoadPresets(presetsFile)
+	if err != nil {
+		return netem.Profile{}, err
+	}
+	return presets.Profile(preset)
+}
+
+// iptables filter, shared by all iptables commands (parent `iptables` command flags)
+func getIPTablesFilter(opts *options) iptables.Filter {
+	return iptables.Filter{
+		Direction:    opts.str("direction", container.DirectionIngress),
+		Protocol:     opts.str("protocol", iptables.DefaultProtocol),
+		Sources:      opts.list("source"),
+		Destinations: opts.list("destination"),
+		SrcPort:      opts.str("src-port", ""),
+		DstPort:      opts.str("dst-port", ""),
+	}
+}
+
+func buildIPTablesDrop(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	filter := getIPTablesFilter(opts)
+	image := opts.str("iptables-image", "")
+	pull := opts.boolean("pull-image", true)
+	return iptables.NewDropCommand(client, names, pattern, step.Labels, filter, step.Duration, "", image, pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildIPTablesReject(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	names, pattern := namesOrPattern(step)
+	filter := getIPTablesFilter(opts)
+	rejectWith := opts.str("reject-with", "")
+	image := opts.str("iptables-image", "")
+	pull := opts.boolean("pull-image", true)
+	return iptables.NewRejectCommand(client, names, pattern, step.Labels, filter, rejectWith, step.Duration, "", image, pull, step.Limit, step.PerService, dryRun)
+}
+
+func buildPartition(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	iface := opts.str("interface", chaos.DefaultInterface)
+	image := opts.str("tc-image", "")
+	pull := opts.boolean("pull-image", true)
+	return partition.NewPartitionCommand(client, step.Groups, iface, step.Duration, "", image, pull, dryRun)
+}
+
+func buildServiceScale(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	replicas := opts.integer("replicas", 0)
+	if replicas < 0 {
+		return nil, errors.New("replicas must not be negative")
+	}
+	return service.NewScaleCommand(client, step.Services, uint64(replicas), "", step.Duration, dryRun)
+}
+
+func buildServiceRestart(client container.Client, step *Step, opts *options, dryRun bool) (chaos.Command, error) {
+	return service.NewRestartCommand(client, step.Services, "", step.Duration, dryRun)
+}
+
+// step options with typed getters; remembers used options and first parse error
+type options struct {
+	values map[string]string
+	used   map[string]bool
+	err    error
+}
+
+func (o *options) get(name string) (string, bool) {
+	o.used[name] = true
+	value, ok := o.values[name]
+	return value, ok
+}
+
+func (o *options) fail(name, value, kind string) {
+	if o.err == nil {
+		o.err = fmt.Errorf("bad option '%s': '%s' is not a valid %s", name, value, kind)
+	}
+}
+
+func (o *options) str(name string, def string) string {
+	if value, ok := o.get(name); ok {
+		return value
+	}
+	return def
+}
+
+// comma separated list
+func (o *options) list(name string) []string {
+	value, ok := o.get(name)
+	if !ok {
+		return nil
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func (o *options) integer(name string, def int) int {
+	value, ok := o.get(name)
+	if !ok {
+		return def
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		o.fail(name, value, "integer")
+		return def
+	}
+	return i
+}
+
+func (o *options) float(name string, def float64) float64 {
+	value, ok := o.get(name)
+	if !ok {
+		return def
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		o.fail(name, value, "number")
+		return def
+	}
+	return f
+}
+
+func (o *options) boolean(name string, def bool) bool {
+	value, ok := o.get(name)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		o.fail(name, value, "boolean")
+		return def
+	}
+	return b
+}
+
+// return first parse error or error for unknown option
+func (o *options) check() error {
+	if o.err != nil {
+		return o.err
+	}
+	var unknown []string
+	for name := range o.values {
+		if !o.used[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown options: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
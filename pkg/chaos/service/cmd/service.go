@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/service"
+)
+
+type serviceContext struct {
+	context context.Context
+}
+
+// NewServiceCLICommand initialize CLI service command and bind it to the serviceContext
+func NewServiceCLICommand(ctx context.Context) *cli.Command {
+	cmdContext := &serviceContext{context: ctx}
+	return &cli.Command{
+		Name:        "service",
+		Usage:       "Docker Swarm service chaos",
+		Description: "scale down and restart (rolling update) Docker Swarm services; requires Swarm manager node",
+		Subcommands: []cli.Command{
+			{
+				Name: "scale",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "duration, d",
+						Usage: "scale down duration: must be shorter than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+					},
+					cli.IntFlag{
+						Name:  "replicas",
+						Usage: "number of service replicas to scale down to",
+						Value: 0,
+					},
+				},
+				Usage:       "scale service down and restore it",
+				ArgsUsage:   "services (name or list of names); default: global 'service' option",
+				Description: "scale replicated service down to number of replicas and scale it back to original replicas after duration",
+				Action:      cmdContext.scale,
+			},
+			{
+				Name: "restart",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "duration, d",
+						Usage: "restart duration: must be shorter than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+					},
+				},
+				Usage:       "restart service tasks",
+				ArgsUsage:   "services (name or list of names); default: global 'service' option",
+				Description: "force rolling update (as 'docker service update --force') of service, again and again, for duration",
+				Action:      cmdContext.restart,
+			},
+		},
+	}
+}
+
+// service names from command arguments or global service flag
+func getServices(c *cli.Context) []string {
+	if c.Args().Present() {
+		return c.Args()
+	}
+	if name := c.GlobalString("service"); name != "" {
+		return []string{name}
+	}
+	return nil
+}
+
+// SERVICE SCALE Command
+func (cmd *serviceContext) scale(c *cli.Context) error {
+	// get random flag
+	random := c.GlobalBool("random")
+	// get dry-run mode
+	dryRun := c.GlobalBool("dry-run")
+	// get global chaos interval
+	interval := c.GlobalString("interval")
+	// get target services
+	services := getServices(c)
+	// get replicas to scale down to
+	replicas := c.Int("replicas")
+	// get chaos command duration
+	duration := c.String("duration")
+	if replicas < 0 {
+		return errors.New("replicas must not be negative")
+	}
+	// init scale command
+	scaleCommand, err := service.NewScaleCommand(chaos.DockerClient, services, uint64(replicas), interval, duration, dryRun)
+	if err != nil {
+		return err
+	}
+	// run scale command
+	return chaos.RunChaosCommand(cmd.context, scaleCommand, interval, random)
+}
+
+// SERVICE RESTART Command
+func (cmd *serviceContext) restart(c *cli.Context) error {
+	// get random flag
+	random := c.GlobalBool("random")
+	// get dry-run mode
+	dryRun := c.GlobalBool("dry-run")
+	// get global chaos interval
+	interval := c.GlobalString("interval")
+	// get target services
+	services := getServices(c)
+	// get chaos command duration
+	duration := c.String("duration")
+	// init restart command
+	restartCommand, err := service.NewRestartCommand(chaos.DockerClient, services, interval, duration, dryRun)
+	if err != nil {
+		return err
+	}
+	// run restart command
+	return chaos.RunChaosCommand(cmd.context, restartCommand, interval, random)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RestartCommand `service restart` command: force rolling update (restart) of Swarm service tasks, again and again, for duration
+type RestartCommand struct {
+	client   container.Client
+	services []string
+	duration time.Duration
+	dryRun   bool
+}
+
+// NewRestartCommand create new service restart command
+func NewRestartCommand(client container.Client, services []string, intervalStr string, durationStr string, dryRun bool) (chaos.Command, error) {
+	if len(services) == 0 {
+		return nil, errors.New("undefined Swarm service: set service names or global 'service' option")
+	}
+	// get interval
+	interval, err := util.GetIntervalValue(intervalStr)
+	if err != nil {
+		return nil, err
+	}
+	// get duration
+	duration, err := util.GetDurationValue(durationStr, interval)
+	if err != nil {
+		return nil, err
+	}
+	return &RestartCommand{client, services, duration, dryRun}, nil
+}
+
+// Run restart command: restart services till duration elapses; dry-run restarts services once
+func (r *RestartCommand) Run(ctx context.Context, random bool) error {
+	services := r.services
+	// select single random service
+	if random {
+		services = []string{randomService(services)}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.duration)
+	defer cancel()
+	for {
+		for _, service := range services {
+			log.WithField("service", service).Debug("restarting service tasks")
+			if err := r.client.RestartService(ctx, service, r.dryRun); err != nil {
+				// rolling update, interrupted by duration timeout or stop event, is not a failure
+				if ctx.Err() != nil {
+					return nil
+				}
+				log.WithError(err).Error("failed to restart service")
+				return err
+			}
+		}
+		if r.dryRun {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+	}
+}
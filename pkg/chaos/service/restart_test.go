@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRestartCommand_Run(t *testing.T) {
+	mockClient := new(container.MockClient)
+	// rolling update lasts longer than duration
+	mockClient.On("RestartService", mock.Anything, "web", false).Return(func(ctx context.Context, name string, dryrun bool) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	r := &RestartCommand{client: mockClient, services: []string{"web"}, duration: 10 * time.Millisecond}
+
+	assert.NoError(t, r.Run(context.TODO(), false))
+	mockClient.AssertNumberOfCalls(t, "RestartService", 1)
+}
+
+func TestRestartCommand_RunDryRun(t *testing.T) {
+	mockClient := new(container.MockClient)
+	mockClient.On("RestartService", mock.Anything, "web", true).Return(nil)
+	mockClient.On("RestartService", mock.Anything, "db", true).Return(nil)
+	r := &RestartCommand{client: mockClient, services: []string{"web", "db"}, duration: time.Hour, dryRun: true}
+
+	assert.NoError(t, r.Run(context.TODO(), false))
+	mockClient.AssertExpectations(t)
+}
+
+func TestRestartCommand_RunError(t *testing.T) {
+	mockClient := new(container.MockClient)
+	mockClient.On("RestartService", mock.Anything, "web", false).Return(errors.New("no such service: web"))
+	r := &RestartCommand{client: mockClient, services: []string{"web"}, duration: time.Hour}
+
+	assert.EqualError(t, r.Run(context.TODO(), false), "no such service: web")
+}
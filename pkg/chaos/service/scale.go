@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ScaleCommand `service scale` command: scale Swarm services down and restore their replicas after duration
+type ScaleCommand struct {
+	client   container.Client
+	services []string
+	replicas uint64
+	duration time.Duration
+	dryRun   bool
+}
+
+// NewScaleCommand create new service scale command
+func NewScaleCommand(client container.Client, services []string, replicas uint64, intervalStr string, durationStr string, dryRun bool) (chaos.Command, error) {
+	if len(services) == 0 {
+		return nil, errors.New("undefined Swarm service: set service names or global 'service' option")
+	}
+	// get interval
+	interval, err := util.GetIntervalValue(intervalStr)
+	if err != nil {
+		return nil, err
+	}
+	// get duration
+	duration, err := util.GetDurationValue(durationStr, interval)
+	if err != nil {
+		return nil, err
+	}
+	return &ScaleCommand{client, services, replicas, duration, dryRun}, nil
+}
+
+// Run scale command
+func (s *ScaleCommand) Run(ctx context.Context, random bool) error {
+	services := s.services
+	// select single random service
+	if random {
+		services = []string{randomService(services)}
+	}
+
+	// original replicas of scaled services
+	scaled := map[string]uint64{}
+	var err error
+	for _, service := range services {
+		// journal original replicas before scaling: scaled down service is restored by recovery
+		var previous uint64
+		previous, err = s.client.ServiceReplicas(ctx, service)
+		if err != nil {
+			log.WithError(err).Error("failed to inspect service")
+			break
+		}
+		if !s.dryRun {
+			if err = container.RecordFault(container.ScaleFault(service, previous)); err != nil {
+				break
+			}
+		}
+		log.WithFields(log.Fields{
+			"service":  service,
+			"previous": previous,
+			"replicas": s.replicas,
+			"duration": s.duration,
+		}).Debug("scaling service for duration")
+		// failed service update can still be applied by Swarm: restore service anyway
+		scaled[service] = previous
+		if _, err = s.client.ScaleService(ctx, service, s.replicas, s.dryRun); err != nil {
+			log.WithError(err).Error("failed to scale service")
+			break
+		}
+	}
+
+	// if there are scaled services restore them
+	if len(scaled) > 0 {
+		var e error
+		// wait for specified duration and then restore replicas or restore on ctx.Done()
+		select {
+		case <-ctx.Done():
+			log.Debug("restore services by stop event")
+			// NOTE: use different context to restore services since parent context is canceled
+			e = s.restoreServices(context.Background(), scaled)
+		case <-time.After(s.duration):
+			log.WithField("duration", s.duration).Debug("restore services after duration")
+			e = s.restoreServices(ctx, scaled)
+		}
+		if e != nil {
+			err = e
+		}
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to restore scaled services")
+	}
+	return err
+}
+
+// scale services back to original replicas
+func (s *ScaleCommand) restoreServices(ctx context.Context, scaled map[string]uint64) error {
+	var err error
+	for service, replicas := range scaled {
+		log.WithFields(log.Fields{
+			"service":  service,
+			"replicas": replicas,
+		}).Debug("restore service replicas")
+		if _, e := s.client.ScaleService(ctx, service, replicas, s.dryRun); e != nil {
+			log.WithError(e).Error("failed to restore service replicas")
+			err = e
+			continue
+		}
+		if !s.dryRun {
+			container.ClearFault(container.ScaleFault(service, replicas))
+		}
+	}
+	return err // last non nil error
+}
+
+func randomService(services []string) string {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return services[r.Intn(len(services))]
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewScaleCommand(t *testing.T) {
+	got, err := NewScaleCommand(nil, []string{"web"}, 1, "20s", "10s", false)
+	assert.NoError(t, err)
+	want := &ScaleCommand{services: []string{"web"}, replicas: 1, duration: 10 * time.Second}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewScaleCommand() = %v, want %v", got, want)
+	}
+
+	_, err = NewScaleCommand(nil, nil, 0, "", "10s", false)
+	assert.Error(t, err)
+	_, err = NewScaleCommand(nil, []string{"web"}, 0, "10s", "20s", false)
+	assert.Error(t, err)
+}
+
+func TestScaleCommand_Run(t *testing.T) {
+	mockClient := new(container.MockClient)
+	mockClient.On("ServiceReplicas", mock.Anything, "web").Return(uint64(3), nil)
+	mockClient.On("ServiceReplicas", mock.Anything, "db").Return(uint64(2), nil)
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(0), false).Return(uint64(3), nil)
+	mockClient.On("ScaleService", mock.Anything, "db", uint64(0), false).Return(uint64(2), nil)
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(3), false).Return(uint64(0), nil)
+	mockClient.On("ScaleService", mock.Anything, "db", uint64(2), false).Return(uint64(0), nil)
+	s := &ScaleCommand{client: mockClient, services: []string{"web", "db"}, duration: time.Millisecond}
+
+	assert.NoError(t, s.Run(context.TODO(), false))
+	mockClient.AssertExpectations(t)
+}
+
+func TestScaleCommand_RunJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, container.OpenJournal(filepath.Join(dir, "journal.json")))
+	defer container.CloseJournal()
+
+	mockClient := new(container.MockClient)
+	mockClient.On("ServiceReplicas", mock.Anything, "web").Return(uint64(3), nil)
+	// original replicas are journaled before service is scaled down
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(0), false).Return(uint64(3), nil).Run(func(mock.Arguments) {
+		faults := container.JournalFaults()
+		if assert.Len(t, faults, 1) {
+			assert.Equal(t, container.FaultScale, faults[0].Kind)
+			assert.Equal(t, "web", faults[0].Service)
+			assert.Equal(t, uint64(3), faults[0].Replicas)
+		}
+	})
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(3), false).Return(uint64(0), nil)
+	s := &ScaleCommand{client: mockClient, services: []string{"web"}, duration: time.Millisecond}
+
+	assert.NoError(t, s.Run(context.TODO(), false))
+	mockClient.AssertExpectations(t)
+	assert.Empty(t, container.JournalFaults())
+}
+
+func TestScaleCommand_RunAbort(t *testing.T) {
+	mockClient := new(container.MockClient)
+	mockClient.On("ServiceReplicas", mock.Anything, "web").Return(uint64(3), nil)
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(1), false).Return(uint64(3), nil)
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(3), false).Return(uint64(1), nil)
+	s := &ScaleCommand{client: mockClient, services: []string{"web"}, replicas: 1, duration: time.Hour}
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	assert.NoError(t, s.Run(ctx, false))
+	mockClient.AssertExpectations(t)
+}
+
+func TestScaleCommand_RunError(t *testing.T) {
+	mockClient := new(container.MockClient)
+	mockClient.On("ServiceReplicas", mock.Anything, "web").Return(uint64(3), nil)
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(0), false).Return(uint64(3), nil)
+	mockClient.On("ServiceReplicas", mock.Anything, "db").Return(uint64(0), errors.New("not a replicated service"))
+	// scaled service is restored
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(3), false).Return(uint64(0), nil)
+	s := &ScaleCommand{client: mockClient, services: []string{"web", "db"}, duration: time.Millisecond}
+
+	assert.EqualError(t, s.Run(context.TODO(), false), "not a replicated service")
+	mockClient.AssertExpectations(t)
+}
+
+func TestScaleCommand_RunScaleError(t *testing.T) {
+	mockClient := new(container.MockClient)
+	mockClient.On("ServiceReplicas", mock.Anything, "web").Return(uint64(3), nil)
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(0), false).Return(uint64(0), errors.New("update out of sequence"))
+	// service update can be applied, even if it failed: service is restored
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(3), false).Return(uint64(3), nil)
+	s := &ScaleCommand{client: mockClient, services: []string{"web"}, duration: time.Millisecond}
+
+	assert.EqualError(t, s.Run(context.TODO(), false), "update out of sequence")
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/stress"
+)
+
+type stressContext struct {
+	context context.Context
+}
+
+// NewStressCLICommand initialize CLI stress command and bind it to the stressContext
+func NewStressCLICommand(ctx context.Context) *cli.Command {
+	cmdContext := &stressContext{context: ctx}
+	return &cli.Command{
+		Name: "stress",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "duration, d",
+				Usage: "stress duration: should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+			},
+			cli.StringFlag{
+				Name:  "stressors",
+				Usage: "stress-ng stressors; see https://kernel.ubuntu.com/~cking/stress-ng/",
+				Value: stress.DefaultStressors,
+			},
+			cli.StringFlag{
+				Name:  "stress-image",
+				Usage: "Docker image with stress-ng tool (and sh and nsenter tools for host-cgroup)",
+				Value: stress.DefaultStressImage,
+			},
+			cli.BoolTFlag{
+				Name:  "pull-image",
+				Usage: "try to pull stress-image",
+			},
+			cli.BoolFlag{
+				Name:  "host-cgroup",
+				Usage: "run privileged stress-ng container in host PID namespace, with host cgroup filesystem mounted, and move it into target container cgroups",
+			},
+			cli.IntFlag{
+				Name:  "limit, l",
+				Usage: "limit number of target containers (0: all matching)",
+				Value: 0,
+			},
+		},
+		Usage:       "stress test a specified containers",
+		ArgsUsage:   fmt.Sprintf("containers (name, list of names, or RE2 regex if prefixed with %q", chaos.Re2Prefix),
+		Description: "stress test target container(s) with CPU, memory and IO pressure, running stress-ng in a sidecar container, that shares target container PID namespace, cgroup parent and resource limits",
+		Action:      cmdContext.stress,
+	}
+}
+
+// STRESS Command
+func (cmd *stressContext) stress(c *cli.Context) error {
+	// get random flag
+	random := c.GlobalBool("random")
+	// get dry-run mode
+	dryRun := c.GlobalBool("dry-run")
+	// get global chaos interval
+	interval := c.GlobalString("interval")
+	// get names or pattern
+	names, pattern := chaos.GetNamesOrPattern(c)
+	// get label selectors
+	labels := chaos.GetLabels(c)
+	// apply limit to every Docker Compose service
+	perService := c.GlobalBool("per-service")
+	// get stress duration
+	duration := c.String("duration")
+	// get stress-ng stressors
+	stressors := c.String("stressors")
+	// get stress-ng image
+	image := c.String("stress-image")
+	// get pull stress-ng image flag
+	pull := c.BoolT("pull-image")
+	// get join target cgroups (privileged) flag
+	hostCgroup := c.Bool("host-cgroup")
+	// get limit for number of containers to stress
+	limit := c.Int("limit")
+	// init stress command
+	stressCommand, err := stress.NewStressCommand(chaos.DockerClient, names, pattern, labels, stressors, image, pull, hostCgroup, duration, interval, limit, perService, dryRun)
+	if err != nil {
+		return err
+	}
+	// run stress command
+	return chaos.RunChaosCommand(cmd.context, stressCommand, interval, random)
+}
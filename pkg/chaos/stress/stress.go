@@ -0,0 +1,207 @@
+package stress
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultStressImage default Docker image with stress-ng entrypoint
+	DefaultStressImage = "alexeiled/stress-ng:latest-ubuntu"
+	// DefaultStressors default stress-ng stressors
+	DefaultStressors = "--cpu 4 --timeout 60s"
+)
+
+// StressCommand `stress` command
+type StressCommand struct {
+	client     container.Client
+	names      []string
+	pattern    string
+	labels     []string
+	stressors  []string
+	image      string
+	pull       bool
+	hostCgroup bool
+	duration   time.Duration
+	limit      int
+	perService bool
+	dryRun     bool
+}
+
+// NewStressCommand create new stress command
+func NewStressCommand(client container.Client,
+	names []string, // containers
+	pattern string, // re2 regex pattern
+	labels []string, // label selectors
+	stressors string, // stress-ng stressors
+	image string, // stress-ng image
+	pull bool, // pull stress-ng image
+	hostCgroup bool, // run privileged stress-ng in host PID namespace and join target cgroups
+	durationStr string, // chaos duration
+	intervalStr string, // repeatable chaos interval
+	limit int, // limit chaos to containers
+	perService bool, // apply limit to every Docker Compose service
+	dryRun bool, // dry-run do not stress just log
+) (chaos.Command, error) {
+	// log error
+	var err error
+	defer func() {
+		if err != nil {
+			log.WithError(err).Error("failed to construct Stress Command")
+		}
+	}()
+
+	// get interval
+	interval, err := util.GetIntervalValue(intervalStr)
+	if err != nil {
+		return nil, err
+	}
+	// get duration
+	duration, err := util.GetDurationValue(durationStr, interval)
+	if err != nil {
+		return nil, err
+	}
+	// stress-ng arguments are passed directly to container command (no shell involved)
+	args := strings.Fields(stressors)
+	if len(args) == 0 {
+		err = errors.New("undefined stress-ng stressors")
+		return nil, err
+	}
+	if image == "" {
+		err = errors.New("undefined stress-ng image")
+		return nil, err
+	}
+
+	return &StressCommand{
+		client:     client,
+		names:      names,
+		pattern:    pattern,
+		labels:     labels,
+		stressors:  args,
+		image:      image,
+		pull:       pull,
+		hostCgroup: hostCgroup,
+		duration:   duration,
+		limit:      limit,
+		perService: perService,
+		dryRun:     dryRun,
+	}, nil
+}
+
+// Run stress command
+func (s *StressCommand) Run(ctx context.Context, random bool) error {
+	log.Debug("stress testing all matching containers")
+	log.WithFields(log.Fields{
+		"names":      s.names,
+		"pattern":    s.pattern,
+		"labels":     s.labels,
+		"limit":      s.limit,
+		"perService": s.perService,
+	}).Debug("listing matching containers")
+	containers, err := container.ListNContainers(ctx, s.client, s.names, s.pattern, s.labels, s.limit, s.perService)
+	if err != nil {
+		log.WithError(err).Error("failed to list containers")
+		return err
+	}
+	if len(containers) == 0 {
+		log.Warning("no containers found")
+		return nil
+	}
+
+	// select single random container from matching container and replace list with selected item
+	if random {
+		log.Debug("selecting single random container")
+		if c := container.RandomContainer(containers); c != nil {
+			containers = []container.Container{*c}
+		}
+	}
+
+	// run stress command for selected containers
+	var wg sync.WaitGroup
+	errors := make([]error, len(containers))
+	cancels := make([]context.CancelFunc, len(containers))
+	for i, c := range containers {
+		log.WithFields(log.Fields{
+			"container": c,
+			"stressors": s.stressors,
+		}).Debug("stress testing container")
+		stressCtx, cancel := context.WithCancel(ctx)
+		cancels[i] = cancel
+		wg.Add(1)
+		go func(i int, c container.Container) {
+			defer wg.Done()
+			errors[i] = runStress(stressCtx, s.client, c, s.stressors, s.image, s.pull, s.hostCgroup, s.duration, s.dryRun)
+			if errors[i] != nil {
+				log.WithField("container", c).WithError(errors[i]).Error("failed to stress test container")
+			}
+		}(i, c)
+	}
+
+	// Wait for all stress commands to complete
+	wg.Wait()
+
+	// cancel context to avoid leaks
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	// scan through all errors in goroutines
+	for _, e := range errors {
+		// take first found error
+		if e != nil {
+			err = e
+			break
+		}
+	}
+
+	return err
+}
+
+// run stress-ng container, stop it on timeout or abort
+func runStress(ctx context.Context, client container.Client, c container.Container, stressors []string, image string, pull bool, hostCgroup bool, duration time.Duration, dryRun bool) error {
+	log.WithFields(log.Fields{
+		"id":         c.ID(),
+		"name":       c.Name(),
+		"stressors":  stressors,
+		"image":      image,
+		"pull":       pull,
+		"hostCgroup": hostCgroup,
+		"duration":   duration,
+	}).Debug("running stress command")
+	stressID, err := client.StressContainer(ctx, c, stressors, image, pull, hostCgroup, dryRun)
+	if err != nil {
+		log.WithError(err).Error("failed to start stress-ng for container")
+		return err
+	}
+
+	// wait for specified duration and then stop stress-ng or stop on ctx.Done()
+	select {
+	case <-ctx.Done():
+		log.WithFields(log.Fields{
+			"id":       c.ID(),
+			"name":     c.Name(),
+			"stressID": stressID,
+		}).Debug("stopping stress command on abort")
+		// use different context to stop stress-ng since parent context is canceled
+		err = client.StopStressContainer(context.Background(), c, stressID, dryRun)
+	case <-time.After(duration):
+		log.WithFields(log.Fields{
+			"id":       c.ID(),
+			"name":     c.Name(),
+			"stressID": stressID,
+		}).Debug("stopping stress command on timout")
+		err = client.StopStressContainer(context.Background(), c, stressID, dryRun)
+	}
+	return err
+}
@@ -0,0 +1,251 @@
+package stress
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewStressCommand(t *testing.T) {
+	type args struct {
+		names       []string
+		pattern     string
+		labels      []string
+		stressors   string
+		image       string
+		pull        bool
+		hostCgroup  bool
+		durationStr string
+		intervalStr string
+		limit       int
+		perService  bool
+		dryRun      bool
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    chaos.Command
+		wantErr bool
+	}{
+		{
+			name: "create stress command",
+			args: args{
+				names:       []string{"c1", "c2"},
+				labels:      []string{"app=web"},
+				stressors:   "--cpu 2  --vm 1 --vm-bytes 128M",
+				image:       "test/image",
+				pull:        true,
+				hostCgroup:  true,
+				durationStr: "30s",
+				intervalStr: "1m",
+				limit:       2,
+			},
+			want: &StressCommand{
+				names:      []string{"c1", "c2"},
+				labels:     []string{"app=web"},
+				stressors:  []string{"--cpu", "2", "--vm", "1", "--vm-bytes", "128M"},
+				image:      "test/image",
+				pull:       true,
+				hostCgroup: true,
+				duration:   30 * time.Second,
+				limit:      2,
+			},
+		},
+		{
+			name: "bad interval value",
+			args: args{
+				intervalStr: "bad-interval",
+			},
+			wantErr: true,
+		},
+		{
+			name: "duration is bigger than interval value",
+			args: args{
+				intervalStr: "1m",
+				durationStr: "2m",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty stressors",
+			args: args{
+				stressors:   "  ",
+				image:       "test/image",
+				durationStr: "30s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty image",
+			args: args{
+				stressors:   "--cpu 1",
+				durationStr: "30s",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewStressCommand(nil, tt.args.names, tt.args.pattern, tt.args.labels, tt.args.stressors, tt.args.image, tt.args.pull, tt.args.hostCgroup, tt.args.durationStr, tt.args.intervalStr, tt.args.limit, tt.args.perService, tt.args.dryRun)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewStressCommand() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewStressCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStressCommand_Run(t *testing.T) {
+	type wantErrors struct {
+		listError   bool
+		stressError bool
+	}
+	type fields struct {
+		names      []string
+		stressors  []string
+		image      string
+		hostCgroup bool
+		duration   time.Duration
+		limit      int
+		dryRun     bool
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		random   bool
+		expected []container.Container
+		wantErr  bool
+		errs     wantErrors
+	}{
+		{
+			name: "stress multiple containers",
+			fields: fields{
+				names:      []string{"c1", "c2", "c3"},
+				stressors:  []string{"--cpu", "1"},
+				image:      "test/image",
+				hostCgroup: true,
+				duration:   10 * time.Microsecond,
+			},
+			expected: container.CreateTestContainers(3),
+		},
+		{
+			name: "stress random container",
+			fields: fields{
+				names:     []string{"c1", "c2", "c3"},
+				stressors: []string{"--cpu", "1"},
+				image:     "test/image",
+				duration:  10 * time.Microsecond,
+			},
+			random:   true,
+			expected: container.CreateTestContainers(3),
+		},
+		{
+			name: "no container found",
+			fields: fields{
+				names: []string{"c1"},
+			},
+		},
+		{
+			name: "error listing containers",
+			fields: fields{
+				names: []string{"c1"},
+			},
+			wantErr: true,
+			errs:    wantErrors{listError: true},
+		},
+		{
+			name: "error stressing container",
+			fields: fields{
+				names:     []string{"c1"},
+				stressors: []string{"--cpu", "1"},
+				image:     "test/image",
+				duration:  10 * time.Microsecond,
+			},
+			expected: container.CreateTestContainers(1),
+			wantErr:  true,
+			errs:     wantErrors{stressError: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(container.MockClient)
+			s := &StressCommand{
+				client:     mockClient,
+				names:      tt.fields.names,
+				stressors:  tt.fields.stressors,
+				image:      tt.fields.image,
+				hostCgroup: tt.fields.hostCgroup,
+				duration:   tt.fields.duration,
+				limit:      tt.fields.limit,
+				dryRun:     tt.fields.dryRun,
+			}
+			call := mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter"))
+			if tt.errs.listError {
+				call.Return(tt.expected, errors.New("ERROR"))
+				goto Invoke
+			}
+			call.Return(tt.expected, nil)
+			if tt.expected == nil {
+				goto Invoke
+			}
+			if tt.errs.stressError {
+				mockClient.On("StressContainer", mock.Anything, mock.AnythingOfType("container.Container"), tt.fields.stressors, tt.fields.image, false, tt.fields.hostCgroup, tt.fields.dryRun).Return("", errors.New("ERROR"))
+				goto Invoke
+			}
+			mockClient.On("StressContainer", mock.Anything, mock.AnythingOfType("container.Container"), tt.fields.stressors, tt.fields.image, false, tt.fields.hostCgroup, tt.fields.dryRun).Return("stressID", nil)
+			mockClient.On("StopStressContainer", mock.Anything, mock.AnythingOfType("container.Container"), "stressID", tt.fields.dryRun).Return(nil)
+		Invoke:
+			if err := s.Run(context.TODO(), tt.random); (err != nil) != tt.wantErr {
+				t.Errorf("StressCommand.Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_runStress(t *testing.T) {
+	c := container.CreateTestContainers(1)[0]
+	stressors := []string{"--cpu", "1"}
+	tests := []struct {
+		name    string
+		abort   bool
+		stopErr bool
+		wantErr bool
+	}{
+		{name: "stress with duration"},
+		{name: "stress with abort", abort: true},
+		{name: "stress error in StopStressContainer", stopErr: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := new(container.MockClient)
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			duration := 10 * time.Microsecond
+			if tt.abort {
+				duration = time.Hour
+				cancel()
+			}
+			mockClient.On("StressContainer", ctx, c, stressors, "test/image", true, false, false).Return("stressID", nil)
+			call := mockClient.On("StopStressContainer", mock.Anything, c, "stressID", false)
+			if tt.stopErr {
+				call.Return(errors.New("test error"))
+			} else {
+				call.Return(nil)
+			}
+			if err := runStress(ctx, mockClient, c, stressors, "test/image", true, false, duration, false); (err != nil) != tt.wantErr {
+				t.Errorf("runStress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
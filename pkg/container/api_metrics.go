@@ -0,0 +1,128 @@
+package container
+
+import (
+	"context"
+	"io"
+	"time"
+
+	types "github.com/docker/docker/api/types"
+	ctypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	dockerapi "github.com/docker/docker/client"
+
+	"github.com/shinespb/pumba/pkg/metrics"
+)
+
+// Docker API clients, observing latency of Docker API requests, sent by Pumba;
+// Docker client HTTP transport is not wrapped: Docker client uses it for TLS and hijacked (exec attach) connections
+type instrumentedContainerAPI struct {
+	dockerapi.ContainerAPIClient
+}
+
+type instrumentedImageAPI struct {
+	dockerapi.ImageAPIClient
+}
+
+type instrumentedServiceAPI struct {
+	dockerapi.ServiceAPIClient
+}
+
+// observe Docker API request latency: 'defer observeAPI("POST", "/containers/{id}/kill", time.Now())'
+func observeAPI(method, endpoint string, start time.Time) {
+	metrics.DockerAPILatency.WithLabelValues(method, endpoint).Observe(time.Since(start).Seconds())
+}
+
+func (a instrumentedContainerAPI) ContainerCreate(ctx context.Context, config *ctypes.Config, hostConfig *ctypes.HostConfig, networkingConfig *network.NetworkingConfig, containerName string) (ctypes.ContainerCreateCreatedBody, error) {
+	defer observeAPI("POST", "/containers/create", time.Now())
+	return a.ContainerAPIClient.ContainerCreate(ctx, config, hostConfig, networkingConfig, containerName)
+}
+
+func (a instrumentedContainerAPI) ContainerExecAttach(ctx context.Context, execID string, config types.ExecStartCheck) (types.HijackedResponse, error) {
+	defer observeAPI("POST", "/exec/{id}/start", time.Now())
+	return a.ContainerAPIClient.ContainerExecAttach(ctx, execID, config)
+}
+
+func (a instrumentedContainerAPI) ContainerExecCreate(ctx context.Context, container string, config types.ExecConfig) (types.IDResponse, error) {
+	defer observeAPI("POST", "/containers/{id}/exec", time.Now())
+	return a.ContainerAPIClient.ContainerExecCreate(ctx, container, config)
+}
+
+func (a instrumentedContainerAPI) ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	defer observeAPI("GET", "/exec/{id}/json", time.Now())
+	return a.ContainerAPIClient.ContainerExecInspect(ctx, execID)
+}
+
+func (a instrumentedContainerAPI) ContainerExecStart(ctx context.Context, execID string, config types.ExecStartCheck) error {
+	defer observeAPI("POST", "/exec/{id}/start", time.Now())
+	return a.ContainerAPIClient.ContainerExecStart(ctx, execID, config)
+}
+
+func (a instrumentedContainerAPI) ContainerInspect(ctx context.Context, container string) (types.ContainerJSON, error) {
+	defer observeAPI("GET", "/containers/{id}/json", time.Now())
+	return a.ContainerAPIClient.ContainerInspect(ctx, container)
+}
+
+func (a instrumentedContainerAPI) ContainerKill(ctx context.Context, container, signal string) error {
+	defer observeAPI("POST", "/containers/{id}/kill", time.Now())
+	return a.ContainerAPIClient.ContainerKill(ctx, container, signal)
+}
+
+func (a instrumentedContainerAPI) ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	defer observeAPI("GET", "/containers/json", time.Now())
+	return a.ContainerAPIClient.ContainerList(ctx, options)
+}
+
+func (a instrumentedContainerAPI) ContainerLogs(ctx context.Context, container string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	defer observeAPI("GET", "/containers/{id}/logs", time.Now())
+	return a.ContainerAPIClient.ContainerLogs(ctx, container, options)
+}
+
+func (a instrumentedContainerAPI) ContainerPause(ctx context.Context, container string) error {
+	defer observeAPI("POST", "/containers/{id}/pause", time.Now())
+	return a.ContainerAPIClient.ContainerPause(ctx, container)
+}
+
+func (a instrumentedContainerAPI) ContainerRemove(ctx context.Context, container string, options types.ContainerRemoveOptions) error {
+	defer observeAPI("DELETE", "/containers/{id}", time.Now())
+	return a.ContainerAPIClient.ContainerRemove(ctx, container, options)
+}
+
+func (a instrumentedContainerAPI) ContainerStart(ctx context.Context, container string, options types.ContainerStartOptions) error {
+	defer observeAPI("POST", "/containers/{id}/start", time.Now())
+	return a.ContainerAPIClient.ContainerStart(ctx, container, options)
+}
+
+func (a instrumentedContainerAPI) ContainerUnpause(ctx context.Context, container string) error {
+	defer observeAPI("POST", "/containers/{id}/unpause", time.Now())
+	return a.ContainerAPIClient.ContainerUnpause(ctx, container)
+}
+
+func (a instrumentedImageAPI) ImageInspectWithRaw(ctx context.Context, image string) (types.ImageInspect, []byte, error) {
+	defer observeAPI("GET", "/images/{id}/json", time.Now())
+	return a.ImageAPIClient.ImageInspectWithRaw(ctx, image)
+}
+
+func (a instrumentedImageAPI) ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error) {
+	defer observeAPI("POST", "/images/create", time.Now())
+	return a.ImageAPIClient.ImagePull(ctx, ref, options)
+}
+
+func (a instrumentedServiceAPI) ServiceInspectWithRaw(ctx context.Context, serviceID string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+	defer observeAPI("GET", "/services/{id}", time.Now())
+	return a.ServiceAPIClient.ServiceInspectWithRaw(ctx, serviceID, options)
+}
+
+func (a instrumentedServiceAPI) ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error) {
+	defer observeAPI("POST", "/services/{id}/update", time.Now())
+	return a.ServiceAPIClient.ServiceUpdate(ctx, serviceID, version, service, options)
+}
+
+// Docker client with Docker API latency observed
+func newInstrumentedClient(apiClient dockerapi.APIClient) dockerClient {
+	return dockerClient{
+		containerAPI: instrumentedContainerAPI{apiClient},
+		imageAPI:     instrumentedImageAPI{apiClient},
+		serviceAPI:   instrumentedServiceAPI{apiClient},
+	}
+}
@@ -0,0 +1,44 @@
+package container
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// number of observed Docker API requests with method and endpoint
+func apiRequests(t *testing.T, method, endpoint string) uint64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "pumba_docker_api_request_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["method"] == method && labels["endpoint"] == endpoint {
+				return m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestInstrumentedClient_ObserveLatency(t *testing.T) {
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerKill", mock.Anything, "abc123", "SIGTERM").Return(nil)
+	client := newInstrumentedClient(engineClient)
+	before := apiRequests(t, "POST", "/containers/{id}/kill")
+
+	err := client.containerAPI.ContainerKill(context.TODO(), "abc123", "SIGTERM")
+
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, apiRequests(t, "POST", "/containers/{id}/kill"))
+	engineClient.AssertExpectations(t)
+}
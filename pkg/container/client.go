@@ -1,11 +1,13 @@
 package container
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"net"
 	"strings"
@@ -13,9 +15,12 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/shinespb/pumba/pkg/metrics"
+
 	types "github.com/docker/docker/api/types"
 	ctypes "github.com/docker/docker/api/types/container"
 	dockerapi "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 )
 
@@ -23,6 +28,23 @@ const (
 	defaultStopSignal = "SIGTERM"
 	defaultKillSignal = "SIGKILL"
 	dryRunPrefix      = "DRY: "
+	// host cgroup filesystem, mounted into stress-ng container
+	hostCgroupMount = "/host/sys/fs/cgroup"
+	// prefix of IFB device, used to redirect ingress traffic to, inside target container network namespace
+	ifbPrefix = "ifb-"
+	// max length of network device name (IFNAMSIZ - 1)
+	maxDeviceName = 15
+	// interval between checks, whether command, executed in container, is still running
+	execPollInterval = 100 * time.Millisecond
+)
+
+const (
+	// DirectionEgress apply network emulation to outgoing traffic
+	DirectionEgress = "egress"
+	// DirectionIngress apply network emulation to incoming traffic
+	DirectionIngress = "ingress"
+	// DirectionBoth apply network emulation to outgoing and incoming traffic
+	DirectionBoth = "both"
 )
 
 // A Filter is a prototype for a function that can be used to filter the
@@ -36,11 +58,20 @@ type Client interface {
 	StopContainer(context.Context, Container, int, bool) error
 	KillContainer(context.Context, Container, string, bool) error
 	RemoveContainer(context.Context, Container, bool, bool, bool, bool) error
-	NetemContainer(context.Context, Container, string, []string, []*net.IPNet, uint16, time.Duration, string, bool, bool) error
-	StopNetemContainer(context.Context, Container, string, []*net.IPNet, uint16, string, bool, bool) error
+	NetemContainer(context.Context, Container, string, string, []string, NetemFilter, time.Duration, string, bool, bool) error
+	StopNetemContainer(context.Context, Container, string, string, NetemFilter, string, bool, bool) error
+	ChangeNetemContainer(context.Context, Container, string, string, []string, NetemFilter, string, bool, bool) error
+	NetworkInterface(context.Context, Container, string, []*net.IPNet, string, bool) (string, error)
+	IPTablesContainer(context.Context, Container, [][]string, string, bool, bool) error
+	StopIPTablesContainer(context.Context, Container, [][]string, string, bool, bool) error
 	PauseContainer(context.Context, Container, bool) error
 	UnpauseContainer(context.Context, Container, bool) error
 	StartContainer(context.Context, Container, bool) error
+	StressContainer(context.Context, Container, []string, string, bool, bool, bool) (string, error)
+	StopStressContainer(context.Context, Container, string, bool) error
+	ServiceReplicas(context.Context, string) (uint64, error)
+	ScaleService(context.Context, string, uint64, bool) (uint64, error)
+	RestartService(context.Context, string, bool) error
 }
 
 // ImagePullResponse - response from ImagePull
@@ -67,12 +98,17 @@ func NewClient(dockerHost string, tlsConfig *tls.Config) Client {
 		log.Fatalf("Error instantiating Docker engine-api: %s", err)
 	}
 
-	return dockerClient{containerAPI: apiClient, imageAPI: apiClient}
+	return newInstrumentedClient(apiClient)
 }
 
 type dockerClient struct {
 	containerAPI dockerapi.ContainerAPIClient
 	imageAPI     dockerapi.ImageAPIClient
+	serviceAPI   dockerapi.ServiceAPIClient
+	// run network commands inside container network namespace, instead of Docker exec (containerd runtime)
+	netns netnsCommand
+	// network helper containers join target container user namespace (rootless Podman)
+	shareUserns bool
 }
 
 func (client dockerClient) ListContainers(ctx context.Context, fn Filter) ([]Container, error) {
@@ -127,7 +163,7 @@ func (client dockerClient) KillContainer(ctx context.Context, c Container, signa
 		"dryrun": dryrun,
 	}).Info("killing container")
 	if !dryrun {
-		return client.containerAPI.ContainerKill(ctx, c.ID(), signal)
+		return affected("kill", client.containerAPI.ContainerKill(ctx, c.ID(), signal))
 	}
 	return nil
 }
@@ -186,6 +222,7 @@ func (client dockerClient) StopContainer(ctx context.Context, c Container, timeo
 				return errors.New("failed waiting for container to stop")
 			}
 		}
+		return affected("stop", nil)
 	}
 	return nil
 }
@@ -218,42 +255,239 @@ func (client dockerClient) RemoveContainer(ctx context.Context, c Container, for
 			RemoveLinks:   links,
 			Force:         force,
 		}
-		return client.containerAPI.ContainerRemove(ctx, c.ID(), removeOpts)
+		return affected("remove", client.containerAPI.ContainerRemove(ctx, c.ID(), removeOpts))
 	}
 	return nil
 }
 
-func (client dockerClient) NetemContainer(ctx context.Context, c Container, netInterface string, netemCmd []string, ips []*net.IPNet, port uint16, duration time.Duration, tcimage string, pull bool, dryrun bool) error {
+func (client dockerClient) NetemContainer(ctx context.Context, c Container, netInterface string, direction string, netemCmd []string, filter NetemFilter, duration time.Duration, tcimage string, pull bool, dryrun bool) error {
 	prefix := ""
 	if dryrun {
 		prefix = dryRunPrefix
 	}
+	// snapshot pre-existing traffic control configuration: netem does not replace it
+	parent := ""
+	if !dryrun {
+		var err error
+		if parent, err = client.prepareNetem(ctx, c, netInterface, direction, filter, tcimage, pull); err != nil {
+			log.Error(err)
+			return err
+		}
+		// IFB device of other experiment (or user) is not replaced
+		if direction == DirectionIngress || direction == DirectionBoth {
+			if err = client.checkNoDevice(ctx, c, ifbDevice(netInterface), tcimage, pull); err != nil {
+				forgetNetem(c, netInterface)
+				log.Error(err)
+				return err
+			}
+		}
+	}
+	// journal netem before changing qdiscs: partially applied netem is also undone by recovery
+	if !dryrun {
+		if err := RecordFault(NetemFault(c, netInterface, direction, filter, tcimage, pull)); err != nil {
+			forgetNetem(c, netInterface)
+			return err
+		}
+	}
 	var err error
-	if len(ips) == 0 {
-		log.Infof("%sRunning netem command '%s' on container %s for %s", prefix, netemCmd, c.ID(), duration)
-		err = client.startNetemContainer(ctx, c, netInterface, netemCmd, tcimage, pull, dryrun)
-	} else {
-		log.Infof("%sRunning netem command '%s' on container %s for %s using filter %v", prefix, netemCmd, c.ID(), duration, ips)
-		err = client.startNetemContainerIPFilter(ctx, c, netInterface, netemCmd, ips, port, tcimage, pull, dryrun)
+	if direction != DirectionIngress {
+		log.Infof("%sRunning netem command '%s' on container %s egress traffic for %s using filter '%s'", prefix, netemCmd, c.ID(), duration, filter)
+		err = client.startNetem(ctx, c, netInterface, parent, netemCmd, filter, false, tcimage, pull, dryrun)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
 	}
-	if err != nil {
-		log.Error(err)
+	if direction == DirectionIngress || direction == DirectionBoth {
+		log.Infof("%sRunning netem command '%s' on container %s ingress traffic for %s using filter '%s'", prefix, netemCmd, c.ID(), duration, filter)
+		// redirect ingress traffic to IFB device and apply netem on IFB device egress
+		err = client.startIngressRedirect(ctx, c, netInterface, tcimage, pull, dryrun)
+		if err == nil {
+			err = client.startNetem(ctx, c, ifbDevice(netInterface), "", netemCmd, filter, true, tcimage, pull, dryrun)
+			if err != nil {
+				// deleting IFB device deletes its qdiscs too
+				if e := client.stopIngressRedirect(ctx, c, netInterface, tcimage, pull, dryrun); e != nil {
+					log.WithError(e).Warn("failed to rollback ingress redirect")
+				}
+			}
+		}
+		if err != nil {
+			log.Error(err)
+		}
+	}
+	if !dryrun {
+		return affected("netem", err)
 	}
 	return err
 }
 
-func (client dockerClient) StopNetemContainer(ctx context.Context, c Container, netInterface string, ip []*net.IPNet, port uint16, tcimage string, pull bool, dryrun bool) error {
+func (client dockerClient) StopNetemContainer(ctx context.Context, c Container, netInterface string, direction string, filter NetemFilter, tcimage string, pull bool, dryrun bool) error {
 	log.WithFields(log.Fields{
-		"name":     c.Name(),
-		"id":       c.ID(),
-		"IPs":      ip,
-		"Port": 	port,
-		"iface":    netInterface,
-		"tc-image": tcimage,
-		"pull":     pull,
-		"dryrun":   dryrun,
+		"name":      c.Name(),
+		"id":        c.ID(),
+		"filter":    filter.String(),
+		"iface":     netInterface,
+		"direction": direction,
+		"tc-image":  tcimage,
+		"pull":      pull,
+		"dryrun":    dryrun,
 	}).Info("stopping netem on container")
-	return client.stopNetemContainer(ctx, c, netInterface, ip, port, tcimage, pull, dryrun)
+	var err error
+	if direction != DirectionIngress {
+		parent := ""
+		if !dryrun {
+			parent = client.netemParent(ctx, c, netInterface, tcimage, pull)
+		}
+		err = client.stopNetemContainer(ctx, c, netInterface, parent, filter, tcimage, pull, dryrun)
+	}
+	if direction == DirectionIngress || direction == DirectionBoth {
+		// try to cleanup ingress, even if failed to cleanup egress
+		if e := client.stopNetemContainer(ctx, c, ifbDevice(netInterface), "", filter, tcimage, pull, dryrun); e != nil {
+			err = e
+		}
+		if e := client.stopIngressRedirect(ctx, c, netInterface, tcimage, pull, dryrun); e != nil {
+			err = e
+		}
+	}
+	// original traffic control configuration must be restored
+	if err == nil && !dryrun {
+		err = client.restoreOriginal(ctx, c, netInterface, tcimage, pull)
+	}
+	if err == nil && !dryrun {
+		ClearFault(NetemFault(c, netInterface, direction, filter, tcimage, pull))
+	}
+	return err // last non nil error
+}
+
+// ChangeNetemContainer replace netem parameters of already installed netem qdisc, without tearing it down
+func (client dockerClient) ChangeNetemContainer(ctx context.Context, c Container, netInterface string, direction string, netemCmd []string, filter NetemFilter, tcimage string, pull bool, dryrun bool) error {
+	prefix := ""
+	if dryrun {
+		prefix = dryRunPrefix
+	}
+	var err error
+	if direction != DirectionIngress {
+		log.Infof("%sChanging netem command to '%s' on container %s egress traffic", prefix, netemCmd, c.ID())
+		parent := ""
+		if !dryrun {
+			parent = client.netemParent(ctx, c, netInterface, tcimage, pull)
+		}
+		err = client.changeNetemContainer(ctx, c, netInterface, parent, netemCmd, filter, tcimage, pull, dryrun)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	if direction == DirectionIngress || direction == DirectionBoth {
+		log.Infof("%sChanging netem command to '%s' on container %s ingress traffic", prefix, netemCmd, c.ID())
+		err = client.changeNetemContainer(ctx, c, ifbDevice(netInterface), "", netemCmd, filter, tcimage, pull, dryrun)
+		if err != nil {
+			log.Error(err)
+		}
+	}
+	return err
+}
+
+// IPTablesContainer insert iptables rules into target container network namespace
+// every rule is a rule specification, starting with chain name: 'INPUT -p tcp --dport 80 -j DROP'
+func (client dockerClient) IPTablesContainer(ctx context.Context, c Container, rules [][]string, image string, pull bool, dryrun bool) error {
+	prefix := ""
+	if dryrun {
+		prefix = dryRunPrefix
+	}
+	log.Infof("%sAdding iptables rules %v to container %s", prefix, rules, c.ID())
+	if !dryrun {
+		var inserted [][]string
+		for _, rule := range rules {
+			// journal rule before inserting it: journal fault has inserted rules and rule being inserted
+			next := append(append([][]string{}, inserted...), rule)
+			if err := client.journalIPTables(c, inserted, next, image, pull); err != nil {
+				client.rollbackIPTables(c, inserted, image, pull)
+				return err
+			}
+			// 'iptables -I <chain> <rule-spec>': insert rule at the top of chain, before any ACCEPT rule
+			args := append([]string{"-I"}, rule...)
+			log.WithField("iptables", strings.Join(args, " ")).Debug("adding iptables rule")
+			if err := client.iptablesCommand(ctx, c, args, image, pull); err != nil {
+				log.WithError(err).Error("failed to execute iptables command")
+				// rule is not inserted: journal inserted rules only and delete them
+				if e := client.journalIPTables(c, next, inserted, image, pull); e != nil {
+					log.WithError(e).Warn("failed to journal inserted iptables rules")
+				}
+				client.rollbackIPTables(c, inserted, image, pull)
+				return err
+			}
+			inserted = next
+		}
+		return affected("iptables", nil)
+	}
+	return nil
+}
+
+// replace journal fault with previous rules by fault with next rules; new fault is recorded first
+func (client dockerClient) journalIPTables(c Container, previous [][]string, next [][]string, image string, pull bool) error {
+	if len(next) > 0 {
+		if err := RecordFault(IPTablesFault(c, next, image, pull)); err != nil {
+			return err
+		}
+	}
+	if len(previous) > 0 {
+		ClearFault(IPTablesFault(c, previous, image, pull))
+	}
+	return nil
+}
+
+// delete rules, inserted before failure; fault is kept in journal, if rules cannot be deleted
+func (client dockerClient) rollbackIPTables(c Container, inserted [][]string, image string, pull bool) {
+	if len(inserted) == 0 {
+		return
+	}
+	// use different context to delete rules since parent context can be canceled
+	if err := client.StopIPTablesContainer(context.Background(), c, inserted, image, pull, false); err != nil {
+		log.WithError(err).Warn("failed to delete inserted iptables rules")
+	}
+}
+
+// StopIPTablesContainer delete iptables rules, previously inserted by IPTablesContainer
+func (client dockerClient) StopIPTablesContainer(ctx context.Context, c Container, rules [][]string, image string, pull bool, dryrun bool) error {
+	log.WithFields(log.Fields{
+		"name":   c.Name(),
+		"id":     c.ID(),
+		"rules":  rules,
+		"image":  image,
+		"pull":   pull,
+		"dryrun": dryrun,
+	}).Info("stopping iptables on container")
+	var err error
+	if !dryrun {
+		// try to delete all rules, even if failed to delete some
+		for _, rule := range rules {
+			// 'iptables -D <chain> <rule-spec>'
+			args := append([]string{"-D"}, rule...)
+			log.WithField("iptables", strings.Join(args, " ")).Debug("deleting iptables rule")
+			if _, e := client.iptablesCommandOutput(ctx, c, args, image, pull); e != nil {
+				// rule is not in chain: it is already deleted (or was never inserted)
+				if isMissingRule(e) {
+					log.WithError(e).Warn("iptables rule is already deleted")
+					continue
+				}
+				log.WithError(e).Error("failed to execute iptables command")
+				err = e
+			}
+		}
+		if err == nil {
+			ClearFault(IPTablesFault(c, rules, image, pull))
+		}
+	}
+	return err // last non nil error
+}
+
+// count container, affected by successful chaos action
+func affected(action string, err error) error {
+	if err == nil {
+		metrics.ContainersAffected.WithLabelValues(action).Inc()
+	}
+	return err
 }
 
 func (client dockerClient) PauseContainer(ctx context.Context, c Container, dryrun bool) error {
@@ -263,7 +497,14 @@ func (client dockerClient) PauseContainer(ctx context.Context, c Container, dryr
 		"dryrun": dryrun,
 	}).Info("pausing container")
 	if !dryrun {
-		return client.containerAPI.ContainerPause(ctx, c.ID())
+		if err := RecordFault(PauseFault(c)); err != nil {
+			return err
+		}
+		err := client.containerAPI.ContainerPause(ctx, c.ID())
+		if err != nil {
+			ClearFault(PauseFault(c))
+		}
+		return affected("pause", err)
 	}
 	return nil
 }
@@ -275,16 +516,201 @@ func (client dockerClient) UnpauseContainer(ctx context.Context, c Container, dr
 		"dryrun": dryrun,
 	}).Info("stop pausing container")
 	if !dryrun {
-		return client.containerAPI.ContainerUnpause(ctx, c.ID())
+		err := client.containerAPI.ContainerUnpause(ctx, c.ID())
+		if err == nil {
+			ClearFault(PauseFault(c))
+		}
+		return err
+	}
+	return nil
+}
+
+func (client dockerClient) StressContainer(ctx context.Context, c Container, stressors []string, image string, pull bool, hostCgroup bool, dryrun bool) (string, error) {
+	log.WithFields(log.Fields{
+		"name":       c.Name(),
+		"id":         c.ID(),
+		"stressors":  stressors,
+		"image":      image,
+		"pull":       pull,
+		"hostCgroup": hostCgroup,
+		"dryrun":     dryrun,
+	}).Info("stress testing container")
+	if !dryrun {
+		id, err := client.stressContainerCommand(ctx, c, stressors, image, pull, hostCgroup)
+		return id, affected("stress", err)
+	}
+	return "", nil
+}
+
+func (client dockerClient) StopStressContainer(ctx context.Context, c Container, stressID string, dryrun bool) error {
+	log.WithFields(log.Fields{
+		"name":     c.Name(),
+		"id":       c.ID(),
+		"stressID": stressID,
+		"dryrun":   dryrun,
+	}).Info("stopping stress testing of container")
+	if !dryrun {
+		// stress-ng container is auto removed on exit, so it may be already gone
+		err := client.containerAPI.ContainerRemove(ctx, stressID, types.ContainerRemoveOptions{Force: true})
+		if err != nil && !dockerapi.IsErrNotFound(err) {
+			log.WithError(err).Error("failed to remove stress-ng container")
+			return err
+		}
+	}
+	return nil
+}
+
+// start netem on network interface, with or without traffic filter
+// start netem on network interface: root qdisc (parent is empty) or child qdisc of parent class
+func (client dockerClient) startNetem(ctx context.Context, c Container, netInterface string, parent string, netemCmd []string, filter NetemFilter, ingress bool, tcimage string, pull bool, dryrun bool) error {
+	if filter.Empty() {
+		return client.startNetemContainer(ctx, c, netInterface, parent, netemCmd, tcimage, pull, dryrun)
+	}
+	return client.startNetemContainerIPFilter(ctx, c, netInterface, netemCmd, filter, ingress, tcimage, pull, dryrun)
+}
+
+// IFB device for network interface: 'ifb-eth0'; too long name is replaced with its hash
+// IFB device is per network interface, so concurrent ingress experiments on different interfaces do not collide
+func ifbDevice(netInterface string) string {
+	name := ifbPrefix + netInterface
+	if len(name) > maxDeviceName {
+		h := fnv.New32a()
+		h.Write([]byte(netInterface))
+		name = fmt.Sprintf("%s%08x", ifbPrefix, h.Sum32())
+	}
+	return name
+}
+
+// network device names from 'ip -o link show' output: '2: eth0@if7: <BROADCAST,MULTICAST,UP> mtu 1500 ...'
+func linkNames(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, ": ", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		names = append(names, strings.SplitN(fields[1], "@", 2)[0])
+	}
+	return names
+}
+
+// fail if network device already exists in target container network namespace
+func (client dockerClient) checkNoDevice(ctx context.Context, c Container, dev string, tcimage string, pull bool) error {
+	output, err := client.ipCommandOutput(ctx, c, []string{"-o", "link", "show"}, tcimage, pull)
+	if err != nil {
+		return err
+	}
+	for _, name := range linkNames(output) {
+		if name == dev {
+			return fmt.Errorf("network device %s already exists in container %s: refusing to replace it", dev, c.Name())
+		}
+	}
+	return nil
+}
+
+// redirect all ingress traffic of network interface to IFB device, so netem can be applied on it;
+// steps are run one by one and partially applied redirect is rolled back on failure
+// requires `ifb` kernel module loaded on Docker host
+func (client dockerClient) startIngressRedirect(ctx context.Context, c Container, netInterface string, tcimage string, pull bool, dryrun bool) error {
+	ifb := ifbDevice(netInterface)
+	log.WithFields(log.Fields{
+		"name":    c.Name(),
+		"id":      c.ID(),
+		"iface":   netInterface,
+		"ifb":     ifb,
+		"tcimage": tcimage,
+		"pull":    pull,
+		"dryrun":  dryrun,
+	}).Info("start ingress redirect for container")
+	if !dryrun {
+		// create IFB device: 'ip link add ifb-eth0 type ifb'
+		ipCommand := []string{"link", "add", ifb, "type", "ifb"}
+		log.WithField("ip", strings.Join(ipCommand, " ")).Debug("adding IFB device")
+		if err := client.ipCommand(ctx, c, ipCommand, tcimage, pull); err != nil {
+			log.WithError(err).Error("failed to execute ip command")
+			return err
+		}
+		if err := client.redirectIngress(ctx, c, netInterface, ifb, tcimage, pull); err != nil {
+			// delete IFB device: 'ip link del ifb-eth0'
+			if e := client.ipCommand(ctx, c, []string{"link", "del", ifb}, tcimage, pull); e != nil {
+				log.WithError(e).Warn("failed to delete IFB device")
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// bring IFB device up and redirect ingress traffic of network interface to it; ingress qdisc is deleted on failure
+func (client dockerClient) redirectIngress(ctx context.Context, c Container, netInterface string, ifb string, tcimage string, pull bool) error {
+	// bring IFB device up: 'ip link set dev ifb-eth0 up'
+	ipCommand := []string{"link", "set", "dev", ifb, "up"}
+	log.WithField("ip", strings.Join(ipCommand, " ")).Debug("setting IFB device up")
+	if err := client.ipCommand(ctx, c, ipCommand, tcimage, pull); err != nil {
+		log.WithError(err).Error("failed to execute ip command")
+		return err
+	}
+	// add ingress qdisc: 'tc qdisc add dev <netInterface> handle ffff: ingress'
+	tcCommand := []string{"qdisc", "add", "dev", netInterface, "handle", "ffff:", "ingress"}
+	log.WithField("netem", strings.Join(tcCommand, " ")).Debug("adding ingress qdisc")
+	if err := client.tcCommand(ctx, c, tcCommand, tcimage, pull); err != nil {
+		log.WithError(err).Error("failed to execute tc command")
+		return err
+	}
+	// redirect all ingress traffic to IFB device:
+	// 'tc filter add dev <netInterface> parent ffff: protocol all u32 match u32 0 0 action mirred egress redirect dev ifb-eth0'
+	// See more: http://man7.org/linux/man-pages/man8/tc-mirred.8.html
+	tcCommand = []string{"filter", "add", "dev", netInterface, "parent", "ffff:", "protocol", "all",
+		"u32", "match", "u32", "0", "0", "action", "mirred", "egress", "redirect", "dev", ifb}
+	log.WithField("netem", strings.Join(tcCommand, " ")).Debug("adding ingress redirect filter")
+	if err := client.tcCommand(ctx, c, tcCommand, tcimage, pull); err != nil {
+		log.WithError(err).Error("failed to execute tc command")
+		// delete ingress qdisc: 'tc qdisc del dev <netInterface> handle ffff: ingress'
+		if e := client.tcCommand(ctx, c, []string{"qdisc", "del", "dev", netInterface, "handle", "ffff:", "ingress"}, tcimage, pull); e != nil {
+			log.WithError(e).Warn("failed to delete ingress qdisc")
+		}
+		return err
+	}
+	return nil
+}
+
+// remove ingress qdisc and IFB device
+func (client dockerClient) stopIngressRedirect(ctx context.Context, c Container, netInterface string, tcimage string, pull bool, dryrun bool) error {
+	ifb := ifbDevice(netInterface)
+	log.WithFields(log.Fields{
+		"name":    c.Name(),
+		"id":      c.ID(),
+		"iface":   netInterface,
+		"ifb":     ifb,
+		"tcimage": tcimage,
+		"pull":    pull,
+		"dryrun":  dryrun,
+	}).Info("stop ingress redirect for container")
+	if !dryrun {
+		// delete ingress qdisc (and redirect filter): 'tc qdisc del dev <netInterface> handle ffff: ingress'
+		tcCommand := []string{"qdisc", "del", "dev", netInterface, "handle", "ffff:", "ingress"}
+		log.WithField("netem", strings.Join(tcCommand, " ")).Debug("deleting ingress qdisc")
+		if err := client.tcCommand(ctx, c, tcCommand, tcimage, pull); err != nil {
+			log.WithError(err).Error("failed to execute tc command")
+			return err
+		}
+		// delete IFB device: 'ip link del ifb-eth0'
+		ipCommand := []string{"link", "del", ifb}
+		log.WithField("ip", strings.Join(ipCommand, " ")).Debug("deleting IFB device")
+		if err := client.ipCommand(ctx, c, ipCommand, tcimage, pull); err != nil {
+			log.WithError(err).Error("failed to execute ip command")
+			return err
+		}
 	}
 	return nil
 }
 
-func (client dockerClient) startNetemContainer(ctx context.Context, c Container, netInterface string, netemCmd []string, tcimage string, pull bool, dryrun bool) error {
+func (client dockerClient) startNetemContainer(ctx context.Context, c Container, netInterface string, parent string, netemCmd []string, tcimage string, pull bool, dryrun bool) error {
 	log.WithFields(log.Fields{
 		"name":    c.Name(),
 		"id":      c.ID(),
 		"iface":   netInterface,
+		"parent":  parent,
 		"netem":   strings.Join(netemCmd, " "),
 		"tcimage": tcimage,
 		"pull":    pull,
@@ -294,7 +720,9 @@ func (client dockerClient) startNetemContainer(ctx context.Context, c Container,
 		// use dockerclient ExecStart to run Traffic Control:
 		// 'tc qdisc add dev eth0 root netem delay 100ms'
 		// http://www.linuxfoundation.org/collaborate/workgroups/networking/netem
-		netemCommand := append([]string{"qdisc", "add", "dev", netInterface, "root", "netem"}, netemCmd...)
+		// 'tc qdisc add dev eth0 parent 1:1 handle 30: netem delay 100ms', when nested into pre-existing tbf qdisc
+		netemCommand := append(append([]string{"qdisc", "add", "dev", netInterface}, netemPlacement(parent)...), "netem")
+		netemCommand = append(netemCommand, netemCmd...)
 		// stop disruption command
 		// netemStopCommand := "tc qdisc del dev eth0 root netem"
 		log.WithField("netem", strings.Join(netemCommand, " ")).Debug("adding netem qdisc")
@@ -303,19 +731,47 @@ func (client dockerClient) startNetemContainer(ctx context.Context, c Container,
 	return nil
 }
 
-func (client dockerClient) stopNetemContainer(ctx context.Context, c Container, netInterface string, ips []*net.IPNet, port uint16, tcimage string, pull bool, dryrun bool) error {
+func (client dockerClient) changeNetemContainer(ctx context.Context, c Container, netInterface string, parent string, netemCmd []string, filter NetemFilter, tcimage string, pull bool, dryrun bool) error {
+	log.WithFields(log.Fields{
+		"name":    c.Name(),
+		"id":      c.ID(),
+		"iface":   netInterface,
+		"parent":  parent,
+		"netem":   strings.Join(netemCmd, " "),
+		"filter":  filter.String(),
+		"tcimage": tcimage,
+		"pull":    pull,
+		"dryrun":  dryrun,
+	}).Info("change netem for container")
+	if !dryrun {
+		// netem qdisc is either root qdisc, qdisc of pre-existing tbf class or, with traffic filter, qdisc of 1:3 class
+		// 'tc qdisc change dev <netInterface> root netem <netemCmd>'
+		// 'tc qdisc change dev <netInterface> parent 1:3 handle 30: netem <netemCmd>'
+		placement := netemPlacement(parent)
+		if !filter.Empty() {
+			placement = netemPlacement("1:3")
+		}
+		netemCommand := append(append([]string{"qdisc", "change", "dev", netInterface}, placement...), "netem")
+		netemCommand = append(netemCommand, netemCmd...)
+		log.WithField("netem", strings.Join(netemCommand, " ")).Debug("changing netem qdisc")
+		return client.tcCommand(ctx, c, netemCommand, tcimage, pull)
+	}
+	return nil
+}
+
+func (client dockerClient) stopNetemContainer(ctx context.Context, c Container, netInterface string, parent string, filter NetemFilter, tcimage string, pull bool, dryrun bool) error {
 	log.WithFields(log.Fields{
 		"name":    c.Name(),
 		"id":      c.ID(),
 		"iface":   netInterface,
-		"IPs":     ips,
-		"Port":	   port,
+		"parent":  parent,
+		"filter":  filter.String(),
 		"tcimage": tcimage,
 		"pull":    pull,
 		"dryrun":  dryrun,
 	}).Info("stop netem for container")
 	if !dryrun {
-		if len(ips) != 0 {
+		if !filter.Empty() {
 			// delete qdisc 'parent 1:1 handle 10:'
 			// http://www.linuxfoundation.org/collaborate/workgroups/networking/netem
 			netemCommand := []string{"qdisc", "del", "dev", netInterface, "parent", "1:1", "handle", "10:"}
@@ -352,6 +808,16 @@ func (client dockerClient) stopNetemContainer(ctx context.Context, c Container,
 				log.WithError(err).Error("failed to execute tc command")
 				return err
 			}
+		} else if parent != "" {
+			// delete netem, nested into pre-existing tbf qdisc: tbf gets its default child qdisc back
+			// 'tc qdisc del dev <netInterface> parent 1:1 handle 30:'
+			netemCommand := append([]string{"qdisc", "del", "dev", netInterface}, netemPlacement(parent)...)
+			log.WithField("netem", strings.Join(netemCommand, " ")).Debug("deleting netem qdisc")
+			err := client.tcCommand(ctx, c, netemCommand, tcimage, pull)
+			if err != nil {
+				log.WithError(err).Error("failed to execute tc command")
+				return err
+			}
 		} else {
 			// stop netem command
 			// http://www.linuxfoundation.org/collaborate/workgroups/networking/netem
@@ -367,18 +833,26 @@ func (client dockerClient) stopNetemContainer(ctx context.Context, c Container,
 	return nil
 }
 
+// netem qdisc placement arguments: 'root' or 'parent <parent> handle 30:'
+func netemPlacement(parent string) []string {
+	if parent == "" {
+		return []string{"root"}
+	}
+	return []string{"parent", parent, "handle", nestedNetemHandle}
+}
+
 func (client dockerClient) startNetemContainerIPFilter(ctx context.Context, c Container, netInterface string, netemCmd []string,
-	ips []*net.IPNet, port uint16, tcimage string, pull bool, dryrun bool) error {
+	filter NetemFilter, ingress bool, tcimage string, pull bool, dryrun bool) error {
 	log.WithFields(log.Fields{
 		"name":    c.Name(),
 		"id":      c.ID(),
 		"iface":   netInterface,
-		"IPs":     ips,
-		"Port":    port,
+		"filter":  filter.String(),
+		"ingress": ingress,
 		"tcimage": tcimage,
 		"pull":    pull,
 		"dryrun":  dryrun,
-	}).Info("start netem for container with IP(s) filter")
+	}).Info("start netem for container with traffic filter")
 	if !dryrun {
 		// use dockerclient ExecStart to run Traffic Control
 		// to filter network, needs to create a priority scheduling, add a low priority
@@ -439,23 +913,10 @@ func (client dockerClient) startNetemContainerIPFilter(ctx context.Context, c Co
 			return err
 		}
 
-		// # redirect traffic to specific IP through band 3
+		// # redirect matching traffic through band 3
 		// 'tc filter add dev <netInterface> protocol ip parent 1:0 prio 1 u32 match ip dst <targetIP> flowid 1:3'
-		// See more: http://man7.org/linux/man-pages/man8/tc-netem.8.html
-		for _, ip := range ips {
-			var filterCommand []string
-			if port == 0 {
-				log.WithField("netem", " noport ").Debug("adding netem filter - no port defined")
-				filterCommand = []string{"filter", "add", "dev", netInterface, "protocol", "ip", "parent", "1:0", "prio", "1",
-					"u32", "match", "ip", "dst", ip.String(), "flowid", "1:3"}
-			} else {
-				log.WithField("netem", " port ").Debug("adding netem filter - port defined")
-				// filter selected port for all protocols
-				// 0xffff means - all protocols
-				filterCommand = []string{"filter", "add", "dev", netInterface, "protocol", "ip", "parent", "1:0", "prio", "1",
-					"u32", "match", "ip", "dst", ip.String(), "dport", string(port), "0xffff", "flowid", "1:3"}
-
-			}
+		// See more: http://man7.org/linux/man-pages/man8/tc-netem.8.html and http://man7.org/linux/man-pages/man8/tc-u32.8.html
+		for _, filterCommand := range filter.u32Filters(netInterface, ingress) {
 			log.WithField("netem", strings.Join(filterCommand, " ")).Debug("adding netem filter")
 			err = client.tcCommand(ctx, c, filterCommand, tcimage, pull)
 			if err != nil {
@@ -474,17 +935,125 @@ func (client dockerClient) tcCommand(ctx context.Context, c Container, args []st
 	return client.tcContainerCommand(ctx, c, args, tcimage, pull)
 }
 
+func (client dockerClient) ipCommand(ctx context.Context, c Container, args []string, tcimage string, pull bool) error {
+	if tcimage == "" {
+		return client.execOnContainer(ctx, c, "ip", args, true)
+	}
+	return client.netContainerCommand(ctx, c, "ip", args, tcimage, pull)
+}
+
+// run tc command and return its output
+func (client dockerClient) tcCommandOutput(ctx context.Context, c Container, args []string, tcimage string, pull bool) (string, error) {
+	if tcimage == "" {
+		return client.execOnContainerOutput(ctx, c, "tc", args, false)
+	}
+	return client.netContainerCommandOutput(ctx, c, "tc", args, tcimage, pull)
+}
+
+// run ip command and return its output
+func (client dockerClient) ipCommandOutput(ctx context.Context, c Container, args []string, tcimage string, pull bool) (string, error) {
+	if tcimage == "" {
+		return client.execOnContainerOutput(ctx, c, "ip", args, false)
+	}
+	return client.netContainerCommandOutput(ctx, c, "ip", args, tcimage, pull)
+}
+
+func (client dockerClient) iptablesCommand(ctx context.Context, c Container, args []string, image string, pull bool) error {
+	if image == "" {
+		return client.execOnContainer(ctx, c, "iptables", args, true)
+	}
+	return client.netContainerCommand(ctx, c, "iptables", args, image, pull)
+}
+
+// run iptables command and return its output; error has iptables error message
+func (client dockerClient) iptablesCommandOutput(ctx context.Context, c Container, args []string, image string, pull bool) (string, error) {
+	if image == "" {
+		return client.execOnContainerOutput(ctx, c, "iptables", args, true)
+	}
+	return client.netContainerCommandOutput(ctx, c, "iptables", args, image, pull)
+}
+
+// 'iptables -D' error for rule, which is not in chain: 'iptables: Bad rule (does a matching rule exist in that chain?)'
+func isMissingRule(err error) bool {
+	return strings.Contains(err.Error(), "Bad rule")
+}
+
 // execute tc command using other container (with iproute2 package installed), using target container network stack
 // try to use `gaiadocker\iproute2` image (Alpine + iproute2 package)
 func (client dockerClient) tcContainerCommand(ctx context.Context, target Container, args []string, tcimage string, pull bool) error {
+	return client.netContainerCommand(ctx, target, "tc", args, tcimage, pull)
+}
+
+// execute network command (tc, ip or iptables) using other container, using target container network stack;
+// wait for command to exit: next command can depend on its result
+func (client dockerClient) netContainerCommand(ctx context.Context, target Container, command string, args []string, tcimage string, pull bool) error {
+	_, err := client.netContainerCommandOutput(ctx, target, command, args, tcimage, pull)
+	return err
+}
+
+// execute network command using other container, using target container network stack, and return command output;
+// container is removed after its output is read
+func (client dockerClient) netContainerCommandOutput(ctx context.Context, target Container, command string, args []string, tcimage string, pull bool) (string, error) {
 	log.WithFields(log.Fields{
 		"tcimage": tcimage,
-		"tc args": args,
-	}).Debug("executing tc command")
+		"command": command,
+		"args":    args,
+	}).Debug("executing network command with output")
+	config, hconfig := client.netContainerConfig(target, command, args, tcimage)
+	// keep container till its output is read
+	hconfig.AutoRemove = false
+	if pull {
+		if err := client.pullImage(ctx, config.Image); err != nil {
+			log.WithError(err).Error("failed to pull tc image")
+			return "", err
+		}
+	}
+	createResponse, err := client.containerAPI.ContainerCreate(ctx, &config, &hconfig, nil, "")
+	if err != nil {
+		log.WithError(err).Error("failed to create tc container")
+		return "", err
+	}
+	defer func() {
+		// use different context to remove container since parent context can be canceled
+		if e := client.containerAPI.ContainerRemove(context.Background(), createResponse.ID, types.ContainerRemoveOptions{Force: true}); e != nil {
+			log.WithError(e).Warn("failed to remove tc container")
+		}
+	}()
+	statusC, errC := client.containerAPI.ContainerWait(ctx, createResponse.ID, ctypes.WaitConditionNextExit)
+	if err = client.containerAPI.ContainerStart(ctx, createResponse.ID, types.ContainerStartOptions{}); err != nil {
+		log.WithError(err).Error("failed to start tc container")
+		return "", err
+	}
+	var exitCode int64
+	select {
+	case err = <-errC:
+		log.WithError(err).Error("failed to wait for tc container")
+		return "", err
+	case status := <-statusC:
+		exitCode = status.StatusCode
+	}
+	logs, err := client.containerAPI.ContainerLogs(ctx, createResponse.ID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		log.WithError(err).Error("failed to read tc container output")
+		return "", err
+	}
+	defer logs.Close()
+	var stdout, stderr bytes.Buffer
+	if _, err = stdcopy.StdCopy(&stdout, &stderr, logs); err != nil {
+		return "", err
+	}
+	if exitCode != 0 {
+		return "", fmt.Errorf("command '%s %s' failed with exit code %d: %s", command, strings.Join(args, " "), exitCode, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// config of container, running network command in target container network stack
+func netContainerConfig(target Container, command string, args []string, tcimage string) (ctypes.Config, ctypes.HostConfig) {
 	// container config
 	config := ctypes.Config{
 		Labels:     map[string]string{"com.gaiaadm.pumba.skip": "true"},
-		Entrypoint: []string{"tc"},
+		Entrypoint: []string{command},
 		Cmd:        args,
 		Image:      tcimage,
 	}
@@ -492,7 +1061,7 @@ func (client dockerClient) tcContainerCommand(ctx context.Context, target Contai
 	hconfig := ctypes.HostConfig{
 		// auto remove container on tc command exit
 		AutoRemove: true,
-		// NET_ADMIN is required for "tc netem"
+		// NET_ADMIN is required for "tc netem" and "iptables"
 		CapAdd: []string{"NET_ADMIN"},
 		// use target container network stack
 		NetworkMode: ctypes.NetworkMode("container:" + target.ID()),
@@ -502,40 +1071,156 @@ func (client dockerClient) tcContainerCommand(ctx context.Context, target Contai
 		DNSOptions:   []string{},
 		DNSSearch:    []string{},
 	}
-	log.WithField("network", hconfig.NetworkMode).Debug("network mode")
+	return config, hconfig
+}
+
+// network helper container config; rootless Podman target network namespace is owned by target user namespace,
+// so helper NET_ADMIN capability is effective only inside the same user namespace
+func (client dockerClient) netContainerConfig(target Container, command string, args []string, tcimage string) (ctypes.Config, ctypes.HostConfig) {
+	config, hconfig := netContainerConfig(target, command, args, tcimage)
+	if client.shareUserns {
+		hconfig.UsernsMode = ctypes.UsernsMode("container:" + target.ID())
+	}
+	return config, hconfig
+}
+
+// move shell (that execs stress-ng) into every cgroup of target process, as listed in /proc/<pid>/cgroup
+// (read in host cgroup namespace, so paths are absolute): works for cgroup v1, v2 and hybrid hierarchies
+// and any cgroup driver; cgroups of named v1 hierarchies (name=systemd) do not limit resources and are skipped
+const stressCgroupScript = `set -e
+cgroot=%s
+while IFS=: read -r _ controllers path; do
+  case "$controllers" in
+    "") root=$cgroot; [ -f "$root/cgroup.controllers" ] || root=$cgroot/unified ;;
+    name=*) continue ;;
+    *) root=$cgroot/$controllers ;;
+  esac
+  if [ -d "$root$path" ]; then echo $$ > "$root$path/cgroup.procs"; fi
+done < /proc/%d/cgroup
+exec stress-ng "$@"
+`
+
+// execute stress-ng command using other container (with stress-ng as entrypoint), in target container PID namespace
+// try to use `alexeiled/stress-ng` image; returns stress-ng container ID
+// by default, stress-ng container is unprivileged: it is created in target container cgroup parent, with target
+// container resource limits; with hostCgroup, it runs privileged in host PID namespace and joins target container cgroups
+func (client dockerClient) stressContainerCommand(ctx context.Context, target Container, stressors []string, image string, pull bool, hostCgroup bool) (string, error) {
+	log.WithFields(log.Fields{
+		"image":      image,
+		"stressors":  stressors,
+		"hostCgroup": hostCgroup,
+	}).Debug("executing stress-ng command")
+	pid := 0
+	if state := containerState(target); state != nil {
+		pid = state.Pid
+	}
+	if pid == 0 {
+		return "", fmt.Errorf("cannot stress container %s: container is not running", target.ID())
+	}
+	var config ctypes.Config
+	var hconfig ctypes.HostConfig
+	if hostCgroup {
+		config, hconfig = stressHostCgroupConfig(pid, stressors, image)
+		log.WithFields(log.Fields{
+			"target": target.ID(),
+			"pid":    pid,
+		}).Debug("stress-ng joins target process cgroups")
+	} else {
+		config, hconfig = stressConfig(target, stressors, image)
+		log.WithFields(log.Fields{
+			"target":       target.ID(),
+			"cgroupParent": hconfig.CgroupParent,
+		}).Debug("stress-ng shares target cgroup parent and resource limits")
+	}
 	// pull docker image if required: can pull only public images
 	if pull {
-		log.WithField("image", config.Image).Debug("pulling tc image")
-		events, err := client.imageAPI.ImagePull(ctx, config.Image, types.ImagePullOptions{})
-		if err != nil {
-			log.WithError(err).Error("failed to pull tc image")
-			return err
-		}
-		defer events.Close()
-		d := json.NewDecoder(events)
-		var pullResponse *ImagePullResponse
-		for {
-			if err = d.Decode(&pullResponse); err != nil {
-				if err == io.EOF {
-					break
-				}
-				log.WithError(err).Error("failed to decode docker pull result")
-			}
-			log.Debug(pullResponse)
+		if err := client.pullImage(ctx, config.Image); err != nil {
+			log.WithError(err).Error("failed to pull stress-ng image")
+			return "", err
 		}
 	}
-	log.WithField("image", config.Image).Debug("creating tc container")
+	log.WithField("image", config.Image).Debug("creating stress-ng container")
 	createResponse, err := client.containerAPI.ContainerCreate(ctx, &config, &hconfig, nil, "")
 	if err != nil {
-		log.WithError(err).Error("failed to create tc container")
-		return err
+		log.WithError(err).Error("failed to create stress-ng container")
+		return "", err
 	}
-	log.WithField("id", createResponse.ID).Debug("tc container created, starting it")
+	log.WithField("id", createResponse.ID).Debug("stress-ng container created, starting it")
 	err = client.containerAPI.ContainerStart(ctx, createResponse.ID, types.ContainerStartOptions{})
 	if err != nil {
-		log.WithError(err).Error("failed to start tc container")
+		log.WithError(err).Error("failed to start stress-ng container")
+		// auto remove applies only to started containers
+		if e := client.containerAPI.ContainerRemove(ctx, createResponse.ID, types.ContainerRemoveOptions{Force: true}); e != nil {
+			log.WithError(e).Warn("failed to remove stress-ng container")
+		}
+		return "", err
+	}
+	return createResponse.ID, nil
+}
+
+// stress-ng container config: join target container PID namespace and get target cgroup parent and resource limits,
+// so stress-ng is limited the same way as target container
+func stressConfig(target Container, stressors []string, image string) (ctypes.Config, ctypes.HostConfig) {
+	config := ctypes.Config{
+		Labels: map[string]string{"com.gaiaadm.pumba.skip": "true"},
+		Cmd:    stressors,
+		Image:  image,
+	}
+	hconfig := ctypes.HostConfig{
+		// auto remove container on stress-ng command exit
+		AutoRemove: true,
+		PidMode:    ctypes.PidMode("container:" + target.ID()),
+	}
+	if info := target.containerInfo; info.ContainerJSONBase != nil && info.HostConfig != nil {
+		hconfig.Resources = info.HostConfig.Resources
+		// stress-ng does not need target devices
+		hconfig.Devices = nil
+		hconfig.DeviceCgroupRules = nil
+	}
+	return config, hconfig
+}
+
+// stress-ng container config: move stress-ng into target process cgroups; requires privileged container
+// in host PID namespace (to join host cgroup namespace) with host cgroup filesystem mounted
+func stressHostCgroupConfig(pid int, stressors []string, image string) (ctypes.Config, ctypes.HostConfig) {
+	config := ctypes.Config{
+		Labels:     map[string]string{"com.gaiaadm.pumba.skip": "true"},
+		Entrypoint: []string{"nsenter", "--target", "1", "--cgroup", "--", "/bin/sh", "-c", fmt.Sprintf(stressCgroupScript, hostCgroupMount, pid), "stress-ng"},
+		Cmd:        stressors,
+		Image:      image,
+	}
+	hconfig := ctypes.HostConfig{
+		// auto remove container on stress-ng command exit
+		AutoRemove: true,
+		// host PID namespace: join host cgroup namespace (of host init process) and read target process cgroups
+		PidMode: ctypes.PidMode("host"),
+		// join cgroup namespace and write to cgroup filesystem
+		Privileged: true,
+		Binds:      []string{"/sys/fs/cgroup:" + hostCgroupMount},
+	}
+	return config, hconfig
+}
+
+// pull Docker image and wait till pull is complete
+func (client dockerClient) pullImage(ctx context.Context, image string) error {
+	log.WithField("image", image).Debug("pulling image")
+	events, err := client.imageAPI.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
 		return err
 	}
+	defer events.Close()
+	d := json.NewDecoder(events)
+	var pullResponse *ImagePullResponse
+	for {
+		if err = d.Decode(&pullResponse); err != nil {
+			if err == io.EOF {
+				break
+			}
+			log.WithError(err).Error("failed to decode docker pull result")
+			return err
+		}
+		log.Debug(pullResponse)
+	}
 	return nil
 }
 
@@ -549,6 +1234,10 @@ func (client dockerClient) execOnContainer(ctx context.Context, c Container, exe
 	}).Debug("executing command in container")
 	// trim all spaces from cmd
 	execCmd = strings.Replace(execCmd, " ", "", -1)
+	if client.netns != nil {
+		_, err := client.netns(ctx, c, execCmd, execArgs)
+		return err
+	}
 
 	// check if command exists inside target container
 	checkExists := types.ExecConfig{
@@ -565,7 +1254,7 @@ func (client dockerClient) execOnContainer(ctx context.Context, c Container, exe
 		log.WithError(err).Error("failed to check if command exists in a container")
 		return err
 	}
-	checkInspect, err := client.containerAPI.ContainerExecInspect(ctx, exec.ID)
+	checkInspect, err := client.waitExec(ctx, exec.ID)
 	if err != nil {
 		log.WithError(err).Error("failed to inspect check execution")
 		return err
@@ -595,7 +1284,7 @@ func (client dockerClient) execOnContainer(ctx context.Context, c Container, exe
 		log.WithError(err).Error("failed to start command execution")
 		return err
 	}
-	exitInspect, err := client.containerAPI.ContainerExecInspect(ctx, exec.ID)
+	exitInspect, err := client.waitExec(ctx, exec.ID)
 	if err != nil {
 		log.WithError(err).Error("failed to inspect command execution")
 		return err
@@ -607,6 +1296,67 @@ func (client dockerClient) execOnContainer(ctx context.Context, c Container, exe
 	return nil
 }
 
+// execute command in container and return its output
+func (client dockerClient) execOnContainerOutput(ctx context.Context, c Container, execCmd string, execArgs []string, privileged bool) (string, error) {
+	log.WithFields(log.Fields{
+		"id":         c.ID(),
+		"name":       c.Name(),
+		"command":    execCmd,
+		"args":       execArgs,
+		"privileged": privileged,
+	}).Debug("executing command in container with output")
+	if client.netns != nil {
+		return client.netns(ctx, c, execCmd, execArgs)
+	}
+	config := types.ExecConfig{
+		Privileged:   privileged,
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          append([]string{execCmd}, execArgs...),
+	}
+	exec, err := client.containerAPI.ContainerExecCreate(ctx, c.ID(), config)
+	if err != nil {
+		log.WithError(err).Error("failed to create exec configuration for a command")
+		return "", err
+	}
+	resp, err := client.containerAPI.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		log.WithError(err).Error("failed to attach to command execution")
+		return "", err
+	}
+	defer resp.Close()
+	var stdout, stderr bytes.Buffer
+	if _, err = stdcopy.StdCopy(&stdout, &stderr, resp.Reader); err != nil {
+		log.WithError(err).Error("failed to read command output")
+		return "", err
+	}
+	exitInspect, err := client.waitExec(ctx, exec.ID)
+	if err != nil {
+		log.WithError(err).Error("failed to inspect command execution")
+		return "", err
+	}
+	if exitInspect.ExitCode != 0 {
+		return "", fmt.Errorf("command '%s' failed in %s (%s) container with exit code %d: %s", execCmd, c.Name(), c.ID(), exitInspect.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// wait for command, started by ContainerExecStart, to exit and inspect it: ContainerExecStart does not wait for
+// command to exit, so its exit code is not known yet and next command can run before it is done
+func (client dockerClient) waitExec(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	for {
+		inspect, err := client.containerAPI.ContainerExecInspect(ctx, execID)
+		if err != nil || !inspect.Running {
+			return inspect, err
+		}
+		select {
+		case <-ctx.Done():
+			return inspect, ctx.Err()
+		case <-time.After(execPollInterval):
+		}
+	}
+}
+
 func (client dockerClient) waitForStop(ctx context.Context, c Container, waitTime int) error {
 	timeout := time.After(time.Duration(waitTime) * time.Second)
 	log.WithFields(log.Fields{
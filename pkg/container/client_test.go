@@ -1,17 +1,24 @@
 package container
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -377,6 +384,8 @@ func TestNetemContainer_Success(t *testing.T) {
 	}
 
 	engineClient := NewMockEngine()
+	expectTCSnapshot(t, engineClient, "eth0", defaultQdiscs)
+	defer resetNetemStates()
 
 	checkConfig := types.ExecConfig{Cmd: []string{"which", "tc"}}
 	engineClient.On("ContainerExecCreate", mock.Anything, "abc123", checkConfig).Return(types.IDResponse{ID: "checkID"}, nil)
@@ -389,7 +398,7 @@ func TestNetemContainer_Success(t *testing.T) {
 	engineClient.On("ContainerExecInspect", mock.Anything, "testID").Return(types.ContainerExecInspect{}, nil)
 
 	client := dockerClient{containerAPI: engineClient}
-	err := client.NetemContainer(context.TODO(), c, "eth0", []string{"delay", "500ms"}, nil, 0, 1*time.Millisecond, "", false, false)
+	err := client.NetemContainer(context.TODO(), c, "eth0", DirectionEgress, []string{"delay", "500ms"}, NetemFilter{}, 1*time.Millisecond, "", false, false)
 
 	assert.NoError(t, err)
 	engineClient.AssertExpectations(t)
@@ -402,6 +411,7 @@ func TestStopNetemContainer_Success(t *testing.T) {
 
 	ctx := mock.Anything
 	engineClient := NewMockEngine()
+	expectTCSnapshot(t, engineClient, "eth0", defaultQdiscs)
 
 	checkConfig := types.ExecConfig{Cmd: []string{"which", "tc"}}
 	engineClient.On("ContainerExecCreate", ctx, "abc123", checkConfig).Return(types.IDResponse{ID: "checkID"}, nil)
@@ -414,7 +424,7 @@ func TestStopNetemContainer_Success(t *testing.T) {
 	engineClient.On("ContainerExecInspect", ctx, "testID").Return(types.ContainerExecInspect{}, nil)
 
 	client := dockerClient{containerAPI: engineClient}
-	err := client.StopNetemContainer(context.TODO(), c, "eth0", nil, 0,"", false, false)
+	err := client.StopNetemContainer(context.TODO(), c, "eth0", DirectionEgress, NetemFilter{}, "", false, false)
 
 	assert.NoError(t, err)
 	engineClient.AssertExpectations(t)
@@ -427,7 +437,7 @@ func TestNetemContainer_DryRun(t *testing.T) {
 
 	engineClient := NewMockEngine()
 	client := dockerClient{containerAPI: engineClient}
-	err := client.NetemContainer(context.TODO(), c, "eth0", []string{"delay", "500ms"}, nil, 0, 1*time.Millisecond, "", false, true)
+	err := client.NetemContainer(context.TODO(), c, "eth0", DirectionEgress, []string{"delay", "500ms"}, NetemFilter{}, 1*time.Millisecond, "", false, true)
 
 	assert.NoError(t, err)
 	engineClient.AssertNotCalled(t, "ContainerExecCreate", mock.Anything)
@@ -441,6 +451,8 @@ func TestNetemContainerIPFilter_Success(t *testing.T) {
 
 	ctx := mock.Anything
 	engineClient := NewMockEngine()
+	expectTCSnapshot(t, engineClient, "eth0", defaultQdiscs)
+	defer resetNetemStates()
 
 	checkConfig := types.ExecConfig{Cmd: []string{"which", "tc"}}
 	engineClient.On("ContainerExecCreate", ctx, "abc123", checkConfig).Return(types.IDResponse{ID: "checkID"}, nil)
@@ -474,12 +486,466 @@ func TestNetemContainerIPFilter_Success(t *testing.T) {
 	engineClient.On("ContainerExecInspect", ctx, "cmd5").Return(types.ContainerExecInspect{}, nil)
 
 	client := dockerClient{containerAPI: engineClient}
-	err := client.NetemContainer(context.TODO(), c, "eth0", []string{"delay", "500ms"}, []*net.IPNet{util.ParseCIDR("10.10.0.1")}, 0, 1*time.Millisecond, "", false, false)
+	err := client.NetemContainer(context.TODO(), c, "eth0", DirectionEgress, []string{"delay", "500ms"}, NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.0.1")}}, 1*time.Millisecond, "", false, false)
 
 	assert.NoError(t, err)
 	engineClient.AssertExpectations(t)
 }
 
+func TestNetemContainerIPv6Filter_Success(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+
+	ctx := mock.Anything
+	engineClient := NewMockEngine()
+	expectTCSnapshot(t, engineClient, "eth0", defaultQdiscs)
+	defer resetNetemStates()
+
+	checkConfig := types.ExecConfig{Cmd: []string{"which", "tc"}}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", checkConfig).Return(types.IDResponse{ID: "checkID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "checkID", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "checkID").Return(types.ContainerExecInspect{}, nil)
+
+	// mixed IPv4 and IPv6 targets
+	commands := [][]string{
+		{"tc", "qdisc", "add", "dev", "eth0", "root", "handle", "1:", "prio"},
+		{"tc", "qdisc", "add", "dev", "eth0", "parent", "1:1", "handle", "10:", "sfq"},
+		{"tc", "qdisc", "add", "dev", "eth0", "parent", "1:2", "handle", "20:", "sfq"},
+		{"tc", "qdisc", "add", "dev", "eth0", "parent", "1:3", "handle", "30:", "netem", "delay", "500ms"},
+		{"tc", "filter", "add", "dev", "eth0", "protocol", "ip", "parent", "1:0", "prio", "1",
+			"u32", "match", "ip", "dst", "10.10.0.1/32", "match", "ip", "dport", "443", "0xffff", "flowid", "1:3"},
+		{"tc", "filter", "add", "dev", "eth0", "protocol", "ipv6", "parent", "1:0", "prio", "2",
+			"u32", "match", "ip6", "dst", "fd00::1/128", "match", "ip6", "dport", "443", "0xffff", "flowid", "1:3"},
+		{"tc", "filter", "add", "dev", "eth0", "protocol", "ipv6", "parent", "1:0", "prio", "2",
+			"u32", "match", "ip6", "dst", "2001:db8::/64", "match", "ip6", "dport", "443", "0xffff", "flowid", "1:3"},
+	}
+	for i, cmd := range commands {
+		id := fmt.Sprintf("cmd%d", i)
+		config := types.ExecConfig{Cmd: cmd, Privileged: true}
+		engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.IDResponse{ID: id}, nil)
+		engineClient.On("ContainerExecStart", ctx, id, types.ExecStartCheck{}).Return(nil)
+		engineClient.On("ContainerExecInspect", ctx, id).Return(types.ContainerExecInspect{}, nil)
+	}
+
+	client := dockerClient{containerAPI: engineClient}
+	ips := []*net.IPNet{util.ParseCIDR("10.10.0.1"), util.ParseCIDR("fd00::1"), util.ParseCIDR("2001:db8::/64")}
+	err := client.NetemContainer(context.TODO(), c, "eth0", DirectionEgress, []string{"delay", "500ms"}, NetemFilter{Targets: ips, Ports: []PortRange{{From: 443, To: 443}}}, 1*time.Millisecond, "", false, false)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainerIngress_Success(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+
+	ctx := mock.Anything
+	engineClient := NewMockEngine()
+	expectTCSnapshot(t, engineClient, "eth0", defaultQdiscs)
+	expectCommandOutput(t, engineClient, "ip", []string{"-o", "link", "show"}, linkShow)
+	defer resetNetemStates()
+
+	for _, cmd := range []string{"tc", "ip"} {
+		checkConfig := types.ExecConfig{Cmd: []string{"which", cmd}}
+		engineClient.On("ContainerExecCreate", ctx, "abc123", checkConfig).Return(types.IDResponse{ID: "check-" + cmd}, nil)
+		engineClient.On("ContainerExecStart", ctx, "check-"+cmd, types.ExecStartCheck{}).Return(nil)
+		engineClient.On("ContainerExecInspect", ctx, "check-"+cmd).Return(types.ContainerExecInspect{}, nil)
+	}
+
+	commands := [][]string{
+		{"ip", "link", "add", "ifb-eth0", "type", "ifb"},
+		{"ip", "link", "set", "dev", "ifb-eth0", "up"},
+		{"tc", "qdisc", "add", "dev", "eth0", "handle", "ffff:", "ingress"},
+		{"tc", "filter", "add", "dev", "eth0", "parent", "ffff:", "protocol", "all",
+			"u32", "match", "u32", "0", "0", "action", "mirred", "egress", "redirect", "dev", "ifb-eth0"},
+		{"tc", "qdisc", "add", "dev", "ifb-eth0", "root", "handle", "1:", "prio"},
+		{"tc", "qdisc", "add", "dev", "ifb-eth0", "parent", "1:1", "handle", "10:", "sfq"},
+		{"tc", "qdisc", "add", "dev", "ifb-eth0", "parent", "1:2", "handle", "20:", "sfq"},
+		{"tc", "qdisc", "add", "dev", "ifb-eth0", "parent", "1:3", "handle", "30:", "netem", "delay", "500ms"},
+		{"tc", "filter", "add", "dev", "ifb-eth0", "protocol", "ip",
+			"parent", "1:0", "prio", "1", "u32", "match", "ip", "src", "10.10.0.1/32", "match", "ip", "sport", "80", "0xffff", "flowid", "1:3"},
+	}
+	for i, cmd := range commands {
+		id := fmt.Sprintf("cmd%d", i)
+		config := types.ExecConfig{Cmd: cmd, Privileged: true}
+		engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.IDResponse{ID: id}, nil)
+		engineClient.On("ContainerExecStart", ctx, id, types.ExecStartCheck{}).Return(nil)
+		engineClient.On("ContainerExecInspect", ctx, id).Return(types.ContainerExecInspect{}, nil)
+	}
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.NetemContainer(context.TODO(), c, "eth0", DirectionIngress, []string{"delay", "500ms"}, NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.10.0.1")}, Ports: []PortRange{{From: 80, To: 80}}}, 1*time.Millisecond, "", false, false)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+// 'ip -o link show' output of container network namespace
+const linkShow = `1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN mode DEFAULT group default qlen 1000\    link/loopback 00:00:00:00:00:00 brd 00:00:00:00:00:00
+7: eth0@if8: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP mode DEFAULT group default \    link/ether 02:42:ac:11:00:02 brd ff:ff:ff:ff:ff:ff link-netnsid 0
+`
+
+func TestNetemContainerIngress_DeviceExists(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123", "Name", "foo")),
+	}
+
+	engineClient := NewMockEngine()
+	expectTCSnapshot(t, engineClient, "eth0", defaultQdiscs)
+	expectCommandOutput(t, engineClient, "ip", []string{"-o", "link", "show"}, linkShow+"9: ifb-eth0: <BROADCAST,NOARP> mtu 1500 qdisc noop state DOWN\n")
+	defer resetNetemStates()
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.NetemContainer(context.TODO(), c, "eth0", DirectionIngress, []string{"delay", "500ms"}, NetemFilter{}, 1*time.Millisecond, "", false, false)
+
+	assert.EqualError(t, err, "network device ifb-eth0 already exists in container foo: refusing to replace it")
+	engineClient.AssertExpectations(t)
+	engineClient.AssertNotCalled(t, "ContainerExecCreate", mock.Anything, "abc123", types.ExecConfig{Cmd: []string{"which", "ip"}})
+	// netem is not applied: no netem state is left
+	assert.Empty(t, netemStates.m)
+}
+
+func TestNetemContainer_RecordFaultFailed(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123", "Name", "foo")),
+	}
+	path, cleanup := testJournal(t)
+	defer cleanup()
+	// journal cannot be written
+	assert.NoError(t, os.RemoveAll(filepath.Dir(path)))
+
+	engineClient := NewMockEngine()
+	expectTCSnapshot(t, engineClient, "eth0", defaultQdiscs)
+	defer resetNetemStates()
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.NetemContainer(context.TODO(), c, "eth0", DirectionEgress, []string{"delay", "500ms"}, NetemFilter{}, 1*time.Millisecond, "", false, false)
+
+	assert.Error(t, err)
+	engineClient.AssertExpectations(t)
+	// netem is not applied: no netem state is left
+	assert.Empty(t, netemStates.m)
+}
+
+func Test_startIngressRedirect_Rollback(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+
+	ctx := mock.Anything
+	engineClient := NewMockEngine()
+	for _, cmd := range []string{"tc", "ip"} {
+		checkConfig := types.ExecConfig{Cmd: []string{"which", cmd}}
+		engineClient.On("ContainerExecCreate", ctx, "abc123", checkConfig).Return(types.IDResponse{ID: "check-" + cmd}, nil)
+		engineClient.On("ContainerExecStart", ctx, "check-"+cmd, types.ExecStartCheck{}).Return(nil)
+		engineClient.On("ContainerExecInspect", ctx, "check-"+cmd).Return(types.ContainerExecInspect{}, nil)
+	}
+
+	// redirect filter fails: ingress qdisc and IFB device are deleted
+	commands := []struct {
+		cmd  []string
+		exit int
+	}{
+		{[]string{"ip", "link", "add", "ifb-eth0", "type", "ifb"}, 0},
+		{[]string{"ip", "link", "set", "dev", "ifb-eth0", "up"}, 0},
+		{[]string{"tc", "qdisc", "add", "dev", "eth0", "handle", "ffff:", "ingress"}, 0},
+		{[]string{"tc", "filter", "add", "dev", "eth0", "parent", "ffff:", "protocol", "all",
+			"u32", "match", "u32", "0", "0", "action", "mirred", "egress", "redirect", "dev", "ifb-eth0"}, 2},
+		{[]string{"tc", "qdisc", "del", "dev", "eth0", "handle", "ffff:", "ingress"}, 0},
+		{[]string{"ip", "link", "del", "ifb-eth0"}, 0},
+	}
+	for i, cmd := range commands {
+		id := fmt.Sprintf("cmd%d", i)
+		config := types.ExecConfig{Cmd: cmd.cmd, Privileged: true}
+		engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.IDResponse{ID: id}, nil).Once()
+		engineClient.On("ContainerExecStart", ctx, id, types.ExecStartCheck{}).Return(nil).Once()
+		engineClient.On("ContainerExecInspect", ctx, id).Return(types.ContainerExecInspect{ExitCode: cmd.exit}, nil).Once()
+	}
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.startIngressRedirect(context.TODO(), c, "eth0", "", false, false)
+
+	assert.Error(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func Test_ifbDevice(t *testing.T) {
+	assert.Equal(t, "ifb-eth0", ifbDevice("eth0"))
+	assert.Equal(t, "ifb-enp0s31f6", ifbDevice("enp0s31f6"))
+	long := ifbDevice("very-long-device")
+	assert.Len(t, long, 12)
+	assert.NotEqual(t, long, ifbDevice("very-long-device2"))
+}
+
+func Test_linkNames(t *testing.T) {
+	assert.Equal(t, []string{"lo", "eth0"}, linkNames(linkShow))
+	assert.Empty(t, linkNames(""))
+}
+
+func TestStopNetemContainerBoth_Success(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+
+	ctx := mock.Anything
+	engineClient := NewMockEngine()
+	expectTCSnapshot(t, engineClient, "eth0", defaultQdiscs)
+
+	for _, cmd := range []string{"tc", "ip"} {
+		checkConfig := types.ExecConfig{Cmd: []string{"which", cmd}}
+		engineClient.On("ContainerExecCreate", ctx, "abc123", checkConfig).Return(types.IDResponse{ID: "check-" + cmd}, nil)
+		engineClient.On("ContainerExecStart", ctx, "check-"+cmd, types.ExecStartCheck{}).Return(nil)
+		engineClient.On("ContainerExecInspect", ctx, "check-"+cmd).Return(types.ContainerExecInspect{}, nil)
+	}
+
+	commands := [][]string{
+		{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"},
+		{"tc", "qdisc", "del", "dev", "ifb-eth0", "root", "netem"},
+		{"tc", "qdisc", "del", "dev", "eth0", "handle", "ffff:", "ingress"},
+		{"ip", "link", "del", "ifb-eth0"},
+	}
+	for i, cmd := range commands {
+		id := fmt.Sprintf("cmd%d", i)
+		config := types.ExecConfig{Cmd: cmd, Privileged: true}
+		engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.IDResponse{ID: id}, nil)
+		engineClient.On("ContainerExecStart", ctx, id, types.ExecStartCheck{}).Return(nil)
+		engineClient.On("ContainerExecInspect", ctx, id).Return(types.ContainerExecInspect{}, nil)
+	}
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.StopNetemContainer(context.TODO(), c, "eth0", DirectionBoth, NetemFilter{}, "", false, false)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestChangeNetemContainerBoth_Success(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+
+	ctx := mock.Anything
+	engineClient := NewMockEngine()
+	expectTCSnapshot(t, engineClient, "eth0", defaultQdiscs)
+
+	checkConfig := types.ExecConfig{Cmd: []string{"which", "tc"}}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", checkConfig).Return(types.IDResponse{ID: "checkID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "checkID", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "checkID").Return(types.ContainerExecInspect{}, nil)
+
+	commands := [][]string{
+		{"tc", "qdisc", "change", "dev", "eth0", "parent", "1:3", "handle", "30:", "netem", "delay", "300ms"},
+		{"tc", "qdisc", "change", "dev", "ifb-eth0", "parent", "1:3", "handle", "30:", "netem", "delay", "300ms"},
+	}
+	for i, cmd := range commands {
+		id := fmt.Sprintf("cmd%d", i)
+		config := types.ExecConfig{Cmd: cmd, Privileged: true}
+		engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.IDResponse{ID: id}, nil)
+		engineClient.On("ContainerExecStart", ctx, id, types.ExecStartCheck{}).Return(nil)
+		engineClient.On("ContainerExecInspect", ctx, id).Return(types.ContainerExecInspect{}, nil)
+	}
+
+	client := dockerClient{containerAPI: engineClient}
+	ips := []*net.IPNet{{IP: net.IP{10, 10, 10, 10}, Mask: net.IPMask{255, 255, 255, 255}}}
+	err := client.ChangeNetemContainer(context.TODO(), c, "eth0", DirectionBoth, []string{"delay", "300ms"}, NetemFilter{Targets: ips}, "", false, false)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestIPTablesContainer_Success(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+
+	ctx := mock.Anything
+	engineClient := NewMockEngine()
+
+	checkConfig := types.ExecConfig{Cmd: []string{"which", "iptables"}}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", checkConfig).Return(types.IDResponse{ID: "checkID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "checkID", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "checkID").Return(types.ContainerExecInspect{}, nil)
+
+	config1 := types.ExecConfig{Cmd: []string{"iptables", "-I", "INPUT", "-p", "tcp", "--dport", "80", "-j", "DROP"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config1).Return(types.IDResponse{ID: "cmd1"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "cmd1", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "cmd1").Return(types.ContainerExecInspect{}, nil)
+
+	config2 := types.ExecConfig{Cmd: []string{"iptables", "-I", "OUTPUT", "-p", "tcp", "--dport", "80", "-j", "DROP"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config2).Return(types.IDResponse{ID: "cmd2"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "cmd2", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "cmd2").Return(types.ContainerExecInspect{}, nil)
+
+	rules := [][]string{
+		{"INPUT", "-p", "tcp", "--dport", "80", "-j", "DROP"},
+		{"OUTPUT", "-p", "tcp", "--dport", "80", "-j", "DROP"},
+	}
+	client := dockerClient{containerAPI: engineClient}
+	err := client.IPTablesContainer(context.TODO(), c, rules, "", false, false)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestIPTablesContainer_DryRun(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+
+	engineClient := NewMockEngine()
+	client := dockerClient{containerAPI: engineClient}
+	err := client.IPTablesContainer(context.TODO(), c, [][]string{{"INPUT", "-j", "DROP"}}, "", false, true)
+
+	assert.NoError(t, err)
+	engineClient.AssertNotCalled(t, "ContainerExecCreate", mock.Anything)
+}
+
+// expect privileged iptables command in target container, which prints error message and exits with exit code
+func expectIPTablesOutput(t *testing.T, engineClient *mocks.APIClient, args []string, stderr string, exitCode int) {
+	id := "iptables " + strings.Join(args, " ")
+	config := types.ExecConfig{Privileged: true, AttachStdout: true, AttachStderr: true, Cmd: append([]string{"iptables"}, args...)}
+	engineClient.On("ContainerExecCreate", mock.Anything, "abc123", config).Return(types.IDResponse{ID: id}, nil)
+	engineClient.On("ContainerExecAttach", mock.Anything, id, types.ExecStartCheck{}).Return(
+		func(context.Context, string, types.ExecStartCheck) types.HijackedResponse {
+			var buf bytes.Buffer
+			_, err := stdcopy.NewStdWriter(&buf, stdcopy.Stderr).Write([]byte(stderr))
+			assert.NoError(t, err)
+			conn, _ := net.Pipe()
+			return types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(&buf)}
+		}, nil)
+	engineClient.On("ContainerExecInspect", mock.Anything, id).Return(types.ContainerExecInspect{ExitCode: exitCode}, nil)
+}
+
+// expect privileged iptables command in target container, which does not print output
+func expectIPTablesCommand(engineClient *mocks.APIClient, args []string, exitCode int) {
+	checkConfig := types.ExecConfig{Cmd: []string{"which", "iptables"}}
+	engineClient.On("ContainerExecCreate", mock.Anything, "abc123", checkConfig).Return(types.IDResponse{ID: "checkID"}, nil)
+	engineClient.On("ContainerExecStart", mock.Anything, "checkID", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", mock.Anything, "checkID").Return(types.ContainerExecInspect{}, nil)
+	id := "iptables " + strings.Join(args, " ")
+	config := types.ExecConfig{Privileged: true, Cmd: append([]string{"iptables"}, args...)}
+	engineClient.On("ContainerExecCreate", mock.Anything, "abc123", config).Return(types.IDResponse{ID: id}, nil)
+	engineClient.On("ContainerExecStart", mock.Anything, id, types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", mock.Anything, id).Return(types.ContainerExecInspect{ExitCode: exitCode}, nil)
+}
+
+func TestIPTablesContainer_RollbackInserted(t *testing.T) {
+	_, cleanup := testJournal(t)
+	defer cleanup()
+
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+	engineClient := NewMockEngine()
+	expectIPTablesCommand(engineClient, []string{"-I", "INPUT", "-j", "DROP"}, 0)
+	expectIPTablesCommand(engineClient, []string{"-I", "OUTPUT", "-j", "DROP"}, 1)
+	// only inserted rule is deleted
+	expectIPTablesOutput(t, engineClient, []string{"-D", "INPUT", "-j", "DROP"}, "", 0)
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.IPTablesContainer(context.TODO(), c, [][]string{{"INPUT", "-j", "DROP"}, {"OUTPUT", "-j", "DROP"}}, "", false, false)
+
+	assert.Error(t, err)
+	assert.Empty(t, JournalFaults())
+	engineClient.AssertExpectations(t)
+	engineClient.AssertNotCalled(t, "ContainerExecCreate", mock.Anything, "abc123", types.ExecConfig{Privileged: true, AttachStdout: true, AttachStderr: true, Cmd: []string{"iptables", "-D", "OUTPUT", "-j", "DROP"}})
+}
+
+func TestIPTablesContainer_FirstRuleFailed(t *testing.T) {
+	_, cleanup := testJournal(t)
+	defer cleanup()
+
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+	engineClient := NewMockEngine()
+	expectIPTablesCommand(engineClient, []string{"-I", "INPUT", "-j", "DROP"}, 2)
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.IPTablesContainer(context.TODO(), c, [][]string{{"INPUT", "-j", "DROP"}, {"OUTPUT", "-j", "DROP"}}, "", false, false)
+
+	// nothing is inserted: nothing to delete and nothing to recover
+	assert.Error(t, err)
+	assert.Empty(t, JournalFaults())
+	engineClient.AssertExpectations(t)
+}
+
+func TestStopIPTablesContainer_ContinueOnError(t *testing.T) {
+	_, cleanup := testJournal(t)
+	defer cleanup()
+
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+	rules := [][]string{{"INPUT", "-j", "DROP"}, {"OUTPUT", "-j", "DROP"}}
+	assert.NoError(t, RecordFault(IPTablesFault(c, rules, "", false)))
+	engineClient := NewMockEngine()
+	expectIPTablesOutput(t, engineClient, []string{"-D", "INPUT", "-j", "DROP"}, "iptables: Resource temporarily unavailable.", 4)
+	expectIPTablesOutput(t, engineClient, []string{"-D", "OUTPUT", "-j", "DROP"}, "", 0)
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.StopIPTablesContainer(context.TODO(), c, rules, "", false, false)
+
+	assert.Error(t, err)
+	assert.Len(t, JournalFaults(), 1)
+	engineClient.AssertExpectations(t)
+}
+
+func TestStopIPTablesContainer_MissingRule(t *testing.T) {
+	_, cleanup := testJournal(t)
+	defer cleanup()
+
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+	rules := [][]string{{"INPUT", "-j", "DROP"}, {"OUTPUT", "-j", "DROP"}}
+	assert.NoError(t, RecordFault(IPTablesFault(c, rules, "", false)))
+	engineClient := NewMockEngine()
+	// rule was never inserted (or is already deleted)
+	expectIPTablesOutput(t, engineClient, []string{"-D", "INPUT", "-j", "DROP"}, "iptables: Bad rule (does a matching rule exist in that chain?).", 1)
+	expectIPTablesOutput(t, engineClient, []string{"-D", "OUTPUT", "-j", "DROP"}, "", 0)
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.StopIPTablesContainer(context.TODO(), c, rules, "", false, false)
+
+	assert.NoError(t, err)
+	assert.Empty(t, JournalFaults())
+	engineClient.AssertExpectations(t)
+}
+
+func TestStopIPTablesContainer_ImageExitCode(t *testing.T) {
+	_, cleanup := testJournal(t)
+	defer cleanup()
+
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+	rules := [][]string{{"INPUT", "-j", "DROP"}}
+	assert.NoError(t, RecordFault(IPTablesFault(c, rules, "pumba/iptables", false)))
+
+	ctx := mock.Anything
+	engineClient := NewMockEngine()
+	config, hconfig := netContainerConfig(c, "iptables", []string{"-D", "INPUT", "-j", "DROP"}, "pumba/iptables")
+	hconfig.AutoRemove = false
+	engineClient.On("ContainerCreate", ctx, &config, &hconfig, (*network.NetworkingConfig)(nil), "").Return(container.ContainerCreateCreatedBody{ID: "iptablesID"}, nil)
+	statusC := make(chan container.ContainerWaitOKBody, 1)
+	statusC <- container.ContainerWaitOKBody{StatusCode: 1}
+	engineClient.On("ContainerWait", ctx, "iptablesID", container.WaitConditionNextExit).Return((<-chan container.ContainerWaitOKBody)(statusC), (<-chan error)(make(chan error)))
+	engineClient.On("ContainerStart", ctx, "iptablesID", types.ContainerStartOptions{}).Return(nil)
+	engineClient.On("ContainerLogs", ctx, "iptablesID", types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true}).Return(ioutil.NopCloser(stdoutStream(t, "")), nil)
+	engineClient.On("ContainerRemove", ctx, "iptablesID", types.ContainerRemoveOptions{Force: true}).Return(nil)
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.StopIPTablesContainer(context.TODO(), c, rules, "pumba/iptables", false, false)
+
+	// rule is not deleted: fault is kept in journal
+	assert.Error(t, err)
+	assert.Len(t, JournalFaults(), 1)
+	engineClient.AssertExpectations(t)
+}
+
 func Test_tcContainerCommand(t *testing.T) {
 	c := Container{
 		containerInfo: ContainerDetailsResponse(AsMap("ID", "targetID")),
@@ -493,8 +959,8 @@ func Test_tcContainerCommand(t *testing.T) {
 	}
 	// host config
 	hconfig := container.HostConfig{
-		// auto remove container on tc command exit
-		AutoRemove: true,
+		// container is removed after tc command exit
+		AutoRemove: false,
 		// NET_ADMIN is required for "tc netem"
 		CapAdd: []string{"NET_ADMIN"},
 		// use target container network stack
@@ -528,8 +994,14 @@ func Test_tcContainerCommand(t *testing.T) {
 	engineClient.On("ImagePull", ctx, config.Image, types.ImagePullOptions{}).Return(ioutil.NopCloser(readerResponse), nil)
 	// create container
 	engineClient.On("ContainerCreate", ctx, &config, &hconfig, (*network.NetworkingConfig)(nil), "").Return(container.ContainerCreateCreatedBody{ID: "tcID"}, nil)
-	// start container
+	// start container and wait for it to exit
+	statusC := make(chan container.ContainerWaitOKBody, 1)
+	statusC <- container.ContainerWaitOKBody{StatusCode: 0}
+	engineClient.On("ContainerWait", ctx, "tcID", container.WaitConditionNextExit).Return((<-chan container.ContainerWaitOKBody)(statusC), (<-chan error)(make(chan error)))
 	engineClient.On("ContainerStart", ctx, "tcID", types.ContainerStartOptions{}).Return(nil)
+	engineClient.On("ContainerLogs", ctx, "tcID", types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true}).Return(ioutil.NopCloser(stdoutStream(t, "")), nil)
+	// remove container
+	engineClient.On("ContainerRemove", ctx, "tcID", types.ContainerRemoveOptions{Force: true}).Return(nil)
 
 	client := dockerClient{containerAPI: engineClient, imageAPI: engineClient}
 	err := client.tcContainerCommand(context.TODO(), c, []string{"test", "me"}, "pumba/tcimage", true)
@@ -538,6 +1010,28 @@ func Test_tcContainerCommand(t *testing.T) {
 	engineClient.AssertExpectations(t)
 }
 
+func Test_tcContainerCommand_ExitCode(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "targetID")),
+	}
+
+	ctx := mock.Anything
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerCreate", ctx, mock.Anything, mock.Anything, (*network.NetworkingConfig)(nil), "").Return(container.ContainerCreateCreatedBody{ID: "tcID"}, nil)
+	statusC := make(chan container.ContainerWaitOKBody, 1)
+	statusC <- container.ContainerWaitOKBody{StatusCode: 2}
+	engineClient.On("ContainerWait", ctx, "tcID", container.WaitConditionNextExit).Return((<-chan container.ContainerWaitOKBody)(statusC), (<-chan error)(make(chan error)))
+	engineClient.On("ContainerStart", ctx, "tcID", types.ContainerStartOptions{}).Return(nil)
+	engineClient.On("ContainerLogs", ctx, "tcID", types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true}).Return(ioutil.NopCloser(stdoutStream(t, "")), nil)
+	engineClient.On("ContainerRemove", ctx, "tcID", types.ContainerRemoveOptions{Force: true}).Return(nil)
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.tcContainerCommand(context.TODO(), c, []string{"qdisc", "del", "dev", "eth0", "root", "netem"}, "pumba/tcimage", false)
+
+	assert.EqualError(t, err, "command 'tc qdisc del dev eth0 root netem' failed with exit code 2: ")
+	engineClient.AssertExpectations(t)
+}
+
 func Test_execOnContainerSuccess(t *testing.T) {
 	c := Container{
 		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
@@ -616,6 +1110,36 @@ func Test_execOnContainerFailed(t *testing.T) {
 	engineClient.AssertExpectations(t)
 }
 
+func Test_execOnContainerWaitExit(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap(
+			"ID", "abc123",
+			"Name", "abcName",
+		)),
+	}
+
+	ctx := mock.Anything
+	engineClient := NewMockEngine()
+
+	checkConfig := types.ExecConfig{Cmd: []string{"which", "testcmd"}}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", checkConfig).Return(types.IDResponse{ID: "checkID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "checkID", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "checkID").Return(types.ContainerExecInspect{}, nil)
+
+	execConfig := types.ExecConfig{Cmd: []string{"testcmd", "arg1"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", execConfig).Return(types.IDResponse{ID: "testID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "testID", types.ExecStartCheck{}).Return(nil)
+	// command is still running, when exec start returns; its exit code is known only after it exits
+	engineClient.On("ContainerExecInspect", ctx, "testID").Return(types.ContainerExecInspect{Running: true}, nil).Once()
+	engineClient.On("ContainerExecInspect", ctx, "testID").Return(types.ContainerExecInspect{ExitCode: 2}, nil).Once()
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.execOnContainer(context.TODO(), c, "testcmd", []string{"arg1"}, true)
+
+	assert.EqualError(t, err, "command 'testcmd' failed in abcName (abc123) container; run it in manually to debug")
+	engineClient.AssertExpectations(t)
+}
+
 func Test_execOnContainerExecStartError(t *testing.T) {
 	c := Container{
 		containerInfo: ContainerDetailsResponse(AsMap(
@@ -716,3 +1240,146 @@ func TestStartContainer_DryRun(t *testing.T) {
 	assert.NoError(t, err)
 	api.AssertNotCalled(t, "ContainerStart", mock.Anything, "abc123", types.ContainerStartOptions{})
 }
+
+func Test_stressContainerCommand(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "targetID")),
+	}
+	c.containerInfo.State.Pid = 4242
+	c.containerInfo.HostConfig = &container.HostConfig{Resources: container.Resources{
+		CgroupParent: "/kubepods/pod1",
+		CPUQuota:     50000,
+		Memory:       256 * 1024 * 1024,
+		Devices:      []container.DeviceMapping{{PathOnHost: "/dev/fuse", PathInContainer: "/dev/fuse"}},
+	}}
+
+	ctx := mock.Anything
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerCreate", ctx, &container.Config{
+		Labels: map[string]string{"com.gaiaadm.pumba.skip": "true"},
+		Cmd:    []string{"--cpu", "1"},
+		Image:  "pumba/stress-ng",
+	}, &container.HostConfig{
+		AutoRemove: true,
+		PidMode:    container.PidMode("container:targetID"),
+		Resources: container.Resources{
+			CgroupParent: "/kubepods/pod1",
+			CPUQuota:     50000,
+			Memory:       256 * 1024 * 1024,
+		},
+	}, (*network.NetworkingConfig)(nil), "").Return(container.ContainerCreateCreatedBody{ID: "stressID"}, nil)
+	engineClient.On("ContainerStart", ctx, "stressID", types.ContainerStartOptions{}).Return(nil)
+
+	client := dockerClient{containerAPI: engineClient, imageAPI: engineClient}
+	id, err := client.stressContainerCommand(context.TODO(), c, []string{"--cpu", "1"}, "pumba/stress-ng", false, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "stressID", id)
+	engineClient.AssertExpectations(t)
+}
+
+func Test_stressContainerCommand_HostCgroup(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "targetID")),
+	}
+	c.containerInfo.State.Pid = 4242
+
+	ctx := mock.Anything
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerCreate", ctx, mock.MatchedBy(func(config *container.Config) bool {
+		return config.Image == "pumba/stress-ng" && config.Labels["com.gaiaadm.pumba.skip"] == "true" &&
+			reflect.DeepEqual([]string(config.Cmd), []string{"--cpu", "1"}) &&
+			reflect.DeepEqual([]string(config.Entrypoint[:5]), []string{"nsenter", "--target", "1", "--cgroup", "--"}) &&
+			strings.Contains(config.Entrypoint[7], "done < /proc/4242/cgroup") &&
+			strings.Contains(config.Entrypoint[7], `exec stress-ng "$@"`)
+	}), &container.HostConfig{
+		AutoRemove: true,
+		PidMode:    container.PidMode("host"),
+		Privileged: true,
+		Binds:      []string{"/sys/fs/cgroup:/host/sys/fs/cgroup"},
+	}, (*network.NetworkingConfig)(nil), "").Return(container.ContainerCreateCreatedBody{ID: "stressID"}, nil)
+	engineClient.On("ContainerStart", ctx, "stressID", types.ContainerStartOptions{}).Return(nil)
+
+	client := dockerClient{containerAPI: engineClient, imageAPI: engineClient}
+	id, err := client.stressContainerCommand(context.TODO(), c, []string{"--cpu", "1"}, "pumba/stress-ng", false, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "stressID", id)
+	engineClient.AssertExpectations(t)
+}
+
+func Test_stressContainerCommand_NotRunning(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "targetID")),
+	}
+
+	engineClient := NewMockEngine()
+	client := dockerClient{containerAPI: engineClient, imageAPI: engineClient}
+	_, err := client.stressContainerCommand(context.TODO(), c, []string{"--cpu", "1"}, "pumba/stress-ng", false, false)
+
+	assert.EqualError(t, err, "cannot stress container targetID: container is not running")
+	engineClient.AssertNotCalled(t, "ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_stressContainerCommand_StartError(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "targetID")),
+	}
+	c.containerInfo.State.Pid = 4242
+
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "").Return(container.ContainerCreateCreatedBody{ID: "stressID"}, nil)
+	engineClient.On("ContainerStart", mock.Anything, "stressID", types.ContainerStartOptions{}).Return(errors.New("oops"))
+	// created container is removed
+	engineClient.On("ContainerRemove", mock.Anything, "stressID", types.ContainerRemoveOptions{Force: true}).Return(nil)
+
+	client := dockerClient{containerAPI: engineClient, imageAPI: engineClient}
+	_, err := client.stressContainerCommand(context.TODO(), c, []string{"--cpu", "1"}, "pumba/stress-ng", false, false)
+
+	assert.EqualError(t, err, "oops")
+	engineClient.AssertExpectations(t)
+}
+
+func TestStressContainer_DryRun(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "targetID")),
+	}
+
+	engineClient := NewMockEngine()
+	client := dockerClient{containerAPI: engineClient, imageAPI: engineClient}
+	id, err := client.StressContainer(context.TODO(), c, []string{"--cpu", "1"}, "pumba/stress-ng", false, false, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", id)
+	engineClient.AssertNotCalled(t, "ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStopStressContainer_Success(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "targetID")),
+	}
+
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerRemove", mock.Anything, "stressID", types.ContainerRemoveOptions{Force: true}).Return(nil)
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.StopStressContainer(context.TODO(), c, "stressID", false)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestStopStressContainer_RemoveError(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "targetID")),
+	}
+
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerRemove", mock.Anything, "stressID", types.ContainerRemoveOptions{Force: true}).Return(errors.New("oops"))
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.StopStressContainer(context.TODO(), c, "stressID", false)
+
+	assert.EqualError(t, err, "oops")
+	engineClient.AssertExpectations(t)
+}
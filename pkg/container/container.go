@@ -2,21 +2,27 @@ package container
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/docker/docker/api/types"
 )
 
 const (
-	pumbaLabel = "com.gaiaadm.pumba"
-	pumbaSkipLabel = "com.gaiaadm.pumba.skip"
-	signalLabel = "com.gaiaadm.pumba.stop-signal"
+	pumbaLabel          = "com.gaiaadm.pumba"
+	pumbaSkipLabel      = "com.gaiaadm.pumba.skip"
+	signalLabel         = "com.gaiaadm.pumba.stop-signal"
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+	swarmServiceLabel   = "com.docker.swarm.service.name"
 )
 
 // Container represents a running Docker container.
 type Container struct {
 	containerInfo types.ContainerJSON
 	imageInfo     types.ImageInspect
+	// Docker daemon of container; set by multi-host client only
+	host string
 }
 
 // NewContainer returns a new Container instance instantiated with the
@@ -38,6 +44,11 @@ func (c Container) Name() string {
 	return c.containerInfo.Name
 }
 
+// Host returns the Docker daemon endpoint, container is running on; empty for single daemon.
+func (c Container) Host() string {
+	return c.host
+}
+
 // ImageID returns the ID of the Docker image that was used to start the container.
 func (c Container) ImageID() string {
 	return c.imageInfo.ID
@@ -73,6 +84,96 @@ func (c Container) Links() []string {
 	return links
 }
 
+// IPAddresses returns IPv4 and global IPv6 addresses of the container in all networks it's
+// connected to, sorted.
+func (c Container) IPAddresses() []string {
+	var ips []string
+
+	if c.containerInfo.NetworkSettings != nil {
+		for _, network := range c.containerInfo.NetworkSettings.Networks {
+			if network != nil && network.IPAddress != "" {
+				ips = append(ips, network.IPAddress)
+			}
+			// dual-stack networks: global IPv6 address
+			if network != nil && network.GlobalIPv6Address != "" {
+				ips = append(ips, network.GlobalIPv6Address)
+			}
+		}
+	}
+	sort.Strings(ips)
+
+	return ips
+}
+
+// NetworkIPAddress returns IPv4 (or global IPv6, if no IPv4) address of the container in the
+// network; empty if container is not connected to the network.
+func (c Container) NetworkIPAddress(name string) string {
+	if c.containerInfo.NetworkSettings == nil {
+		return ""
+	}
+	network := c.containerInfo.NetworkSettings.Networks[name]
+	if network == nil {
+		return ""
+	}
+	if network.IPAddress != "" {
+		return network.IPAddress
+	}
+	return network.GlobalIPv6Address
+}
+
+// SharedIPAddresses returns IPv4 and global IPv6 addresses of the container in networks it
+// shares with the other container, sorted. Containers on different Docker daemons do not share
+// networks, even if network names are the same.
+func (c Container) SharedIPAddresses(other Container) []string {
+	var ips []string
+
+	if c.host == other.host && c.containerInfo.NetworkSettings != nil && other.containerInfo.NetworkSettings != nil {
+		for name, network := range c.containerInfo.NetworkSettings.Networks {
+			if _, ok := other.containerInfo.NetworkSettings.Networks[name]; !ok || network == nil {
+				continue
+			}
+			if network.IPAddress != "" {
+				ips = append(ips, network.IPAddress)
+			}
+			if network.GlobalIPv6Address != "" {
+				ips = append(ips, network.GlobalIPv6Address)
+			}
+		}
+	}
+	sort.Strings(ips)
+
+	return ips
+}
+
+// Labels returns the labels attached to the container.
+func (c Container) Labels() map[string]string {
+	if c.containerInfo.Config == nil {
+		return map[string]string{}
+	}
+	return c.containerInfo.Config.Labels
+}
+
+// ComposeProject returns the Docker Compose project name the container belongs to,
+// taken from the "com.docker.compose.project" label. The empty string "" is
+// returned for containers that were not created by Docker Compose.
+func (c Container) ComposeProject() string {
+	return c.Labels()[composeProjectLabel]
+}
+
+// ComposeService returns the Docker Compose service name of the container,
+// taken from the "com.docker.compose.service" label. The empty string "" is
+// returned for containers that were not created by Docker Compose.
+func (c Container) ComposeService() string {
+	return c.Labels()[composeServiceLabel]
+}
+
+// SwarmService returns the Docker Swarm service name of the container (service task),
+// taken from the "com.docker.swarm.service.name" label. The empty string "" is
+// returned for containers that are not Swarm service tasks.
+func (c Container) SwarmService() string {
+	return c.Labels()[swarmServiceLabel]
+}
+
 // IsPumba returns a boolean flag indicating whether or not the current
 // container is the Pumba container itself. The Pumba container is
 // identified by the presence of the "com.gaiaadm.pumba" label in
@@ -3,6 +3,7 @@ package container
 import (
 	"testing"
 
+	"github.com/docker/docker/api/types/network"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -56,6 +57,51 @@ func TestLinks(t *testing.T) {
 	assert.Equal(t, []string{"foo", "bar"}, links)
 }
 
+func TestIPAddresses(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("IPAddress", "172.17.0.2")),
+	}
+	c.containerInfo.NetworkSettings.Networks["other"] = &network.EndpointSettings{IPAddress: "10.0.0.2", GlobalIPv6Address: "fd00::2"}
+	c.containerInfo.NetworkSettings.Networks["none"] = &network.EndpointSettings{}
+
+	assert.Equal(t, []string{"10.0.0.2", "172.17.0.2", "fd00::2"}, c.IPAddresses())
+}
+
+func TestSharedIPAddresses(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("IPAddress", "172.17.0.2")),
+	}
+	c.containerInfo.NetworkSettings.Networks["backend"] = &network.EndpointSettings{IPAddress: "10.0.0.2", GlobalIPv6Address: "fd00::2"}
+	c.containerInfo.NetworkSettings.Networks["frontend"] = &network.EndpointSettings{IPAddress: "10.1.0.2"}
+	other := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("IPAddress", "172.17.0.3")),
+	}
+	other.containerInfo.NetworkSettings.Networks["backend"] = &network.EndpointSettings{IPAddress: "10.0.0.3"}
+
+	assert.Equal(t, []string{"10.0.0.2", "172.17.0.2", "fd00::2"}, c.SharedIPAddresses(other))
+}
+
+func TestComposeProjectAndService(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("Labels", map[string]string{
+			"com.docker.compose.project": "proj",
+			"com.docker.compose.service": "web",
+		})),
+	}
+
+	assert.Equal(t, "proj", c.ComposeProject())
+	assert.Equal(t, "web", c.ComposeService())
+}
+
+func TestComposeProjectAndService_NotCompose(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap()),
+	}
+
+	assert.Equal(t, "", c.ComposeProject())
+	assert.Equal(t, "", c.ComposeService())
+}
+
 func TestIsPumba_True(t *testing.T) {
 	labels := map[string]string{
 		"com.gaiaadm.pumba": "true",
@@ -0,0 +1,399 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	types "github.com/docker/docker/api/types"
+	ctypes "github.com/docker/docker/api/types/container"
+)
+
+const (
+	// DefaultContainerdAddress default containerd socket
+	DefaultContainerdAddress = "/run/containerd/containerd.sock"
+	// DefaultContainerdNamespace default containerd namespace
+	DefaultContainerdNamespace = "default"
+	// container name label, set by nerdctl
+	nerdctlNameLabel = "nerdctl/name"
+	// containerd task statuses
+	taskRunning = "RUNNING"
+	taskPaused  = "PAUSED"
+)
+
+// run host command and return its output
+type commandRunner func(ctx context.Context, name string, args ...string) (string, error)
+
+// run command in container network namespace and return its output
+type netnsCommand func(ctx context.Context, c Container, command string, args []string) (string, error)
+
+// container, as reported by 'ctr containers info'
+type ctrContainer struct {
+	ID     string            `json:"ID"`
+	Labels map[string]string `json:"Labels"`
+	Image  string            `json:"Image"`
+}
+
+// task of container, as reported by 'ctr tasks ls'
+type ctrTask struct {
+	pid    int
+	status string
+}
+
+// NewContainerdClient returns a new Client instance which can be used to interact with
+// containerd, using 'ctr' tool; network commands are run inside task network namespace with 'nsenter'
+func NewContainerdClient(address string, namespace string) Client {
+	return newContainerdClient(address, namespace, runCommand)
+}
+
+func newContainerdClient(address string, namespace string, run commandRunner) containerdClient {
+	client := containerdClient{address: address, namespace: namespace, run: run}
+	client.net = dockerClient{netns: client.netnsCommand}
+	return client
+}
+
+type containerdClient struct {
+	address   string
+	namespace string
+	run       commandRunner
+	// netem and iptables commands, run inside task network namespace
+	net dockerClient
+}
+
+func runCommand(ctx context.Context, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("command '%s %s' failed: %s: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// run 'ctr' command in configured containerd namespace
+func (client containerdClient) ctr(ctx context.Context, args ...string) (string, error) {
+	args = append([]string{"--address", client.address, "--namespace", client.namespace}, args...)
+	log.WithField("args", args).Debug("executing ctr command")
+	return client.run(ctx, "ctr", args...)
+}
+
+// run network command (tc, ip or iptables) inside task network namespace
+func (client containerdClient) netnsCommand(ctx context.Context, c Container, command string, args []string) (string, error) {
+	pid := c.containerInfo.State.Pid
+	if pid == 0 {
+		return "", fmt.Errorf("container %s has no running task", c.ID())
+	}
+	log.WithFields(log.Fields{
+		"id":      c.ID(),
+		"pid":     pid,
+		"command": command,
+		"args":    args,
+	}).Debug("executing command in task network namespace")
+	return client.run(ctx, "nsenter", append([]string{"--target", strconv.Itoa(pid), "--net", command}, args...)...)
+}
+
+func (client containerdClient) ListContainers(ctx context.Context, fn Filter) ([]Container, error) {
+	return client.listContainers(ctx, fn, false)
+}
+
+func (client containerdClient) ListAllContainers(ctx context.Context, fn Filter) ([]Container, error) {
+	return client.listContainers(ctx, fn, true)
+}
+
+func (client containerdClient) listContainers(ctx context.Context, fn Filter, all bool) ([]Container, error) {
+	log.Debug("listing containerd containers")
+	tasks, err := client.listTasks(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to list tasks")
+		return nil, err
+	}
+	output, err := client.ctr(ctx, "containers", "ls", "-q")
+	if err != nil {
+		log.WithError(err).Error("failed to list containers")
+		return nil, err
+	}
+	cs := []Container{}
+	for _, id := range strings.Fields(output) {
+		task := tasks[id]
+		// as Docker, consider paused container running
+		if !all && task.status != taskRunning && task.status != taskPaused {
+			continue
+		}
+		output, err = client.ctr(ctx, "containers", "info", id)
+		if err != nil {
+			log.WithError(err).Error("failed to inspect container")
+			return nil, err
+		}
+		var info ctrContainer
+		if err = json.Unmarshal([]byte(output), &info); err != nil {
+			return nil, fmt.Errorf("failed to parse container %s info: %s", id, err)
+		}
+		c := containerdContainer(info, task)
+		log.WithFields(log.Fields{
+			"name": c.Name(),
+			"id":   c.ID(),
+		}).Debug("found container")
+		if fn(c) {
+			cs = append(cs, c)
+		}
+	}
+	return cs, nil
+}
+
+// tasks by container ID: 'ctr tasks ls' output
+func (client containerdClient) listTasks(ctx context.Context) (map[string]ctrTask, error) {
+	output, err := client.ctr(ctx, "tasks", "ls")
+	if err != nil {
+		return nil, err
+	}
+	tasks := map[string]ctrTask{}
+	for _, line := range strings.Split(output, "\n") {
+		// TASK PID STATUS
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] == "TASK" {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse task %s pid: %s", fields[0], err)
+		}
+		tasks[fields[0]] = ctrTask{pid: pid, status: fields[2]}
+	}
+	return tasks, nil
+}
+
+// Docker like container, from containerd container and its task
+func containerdContainer(info ctrContainer, task ctrTask) Container {
+	labels := info.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	name := info.ID
+	if val, ok := labels[nerdctlNameLabel]; ok && val != "" {
+		name = val
+	}
+	return Container{
+		containerInfo: types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID: info.ID,
+				// Docker container names start with forward slash
+				Name:  "/" + name,
+				Image: info.Image,
+				State: &types.ContainerState{
+					Status:  strings.ToLower(task.status),
+					Running: task.status == taskRunning || task.status == taskPaused,
+					Paused:  task.status == taskPaused,
+					Pid:     task.pid,
+				},
+			},
+			Config: &ctypes.Config{Image: info.Image, Labels: labels},
+		},
+		imageInfo: types.ImageInspect{ID: info.Image},
+	}
+}
+
+func (client containerdClient) KillContainer(ctx context.Context, c Container, signal string, dryrun bool) error {
+	log.WithFields(log.Fields{
+		"name":   c.Name(),
+		"id":     c.ID(),
+		"signal": signal,
+		"dryrun": dryrun,
+	}).Info("killing container")
+	if !dryrun {
+		_, err := client.ctr(ctx, "tasks", "kill", "--signal", signal, c.ID())
+		return affected("kill", err)
+	}
+	return nil
+}
+
+func (client containerdClient) StopContainer(ctx context.Context, c Container, timeout int, dryrun bool) error {
+	signal := c.StopSignal()
+	if signal == "" {
+		signal = defaultStopSignal
+	}
+	log.WithFields(log.Fields{
+		"name":   c.Name(),
+		"id":     c.ID(),
+		"timout": timeout,
+		"signal": signal,
+		"dryrun": dryrun,
+	}).Info("stopping container")
+	if !dryrun {
+		if _, err := client.ctr(ctx, "tasks", "kill", "--signal", signal, c.ID()); err != nil {
+			log.WithError(err).Warn("failed to kill container")
+			return err
+		}
+		// Wait for task to exit, but proceed anyway after the timeout elapses
+		if err := client.waitForStop(ctx, c, timeout); err != nil {
+			log.WithError(err).Warn("failed waiting for container to stop, going to kill it")
+			if _, err := client.ctr(ctx, "tasks", "kill", "--signal", defaultKillSignal, c.ID()); err != nil {
+				log.WithError(err).Error("failed to kill container")
+				return err
+			}
+			if err := client.waitForStop(ctx, c, timeout); err != nil {
+				log.WithError(err).Error("failed waiting for container to stop")
+				return errors.New("failed waiting for container to stop")
+			}
+		}
+		return affected("stop", nil)
+	}
+	return nil
+}
+
+func (client containerdClient) waitForStop(ctx context.Context, c Container, waitTime int) error {
+	timeout := time.After(time.Duration(waitTime) * time.Second)
+	for {
+		tasks, err := client.listTasks(ctx)
+		if err != nil {
+			return err
+		}
+		if task, ok := tasks[c.ID()]; !ok || (task.status != taskRunning && task.status != taskPaused) {
+			return nil
+		}
+		select {
+		case <-timeout:
+			return errors.New("timeout on waiting to stop")
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (client containerdClient) StartContainer(ctx context.Context, c Container, dryrun bool) error {
+	log.WithFields(log.Fields{
+		"name":   c.Name(),
+		"id":     c.ID(),
+		"dryrun": dryrun,
+	}).Info("starting container")
+	if !dryrun {
+		// stopped task must be deleted before new task is started
+		if _, err := client.ctr(ctx, "tasks", "delete", c.ID()); err != nil {
+			log.WithError(err).Debug("no stopped task to delete")
+		}
+		_, err := client.ctr(ctx, "tasks", "start", "--null-io", "--detach", c.ID())
+		return err
+	}
+	return nil
+}
+
+func (client containerdClient) RemoveContainer(ctx context.Context, c Container, force bool, links bool, volumes bool, dryrun bool) error {
+	log.WithFields(log.Fields{
+		"name":   c.Name(),
+		"id":     c.ID(),
+		"force":  force,
+		"dryrun": dryrun,
+	}).Info("removing container")
+	if !dryrun {
+		if c.containerInfo.State.Status != "" {
+			args := []string{"tasks", "delete", c.ID()}
+			if force {
+				args = []string{"tasks", "delete", "--force", c.ID()}
+			}
+			if _, err := client.ctr(ctx, args...); err != nil {
+				return err
+			}
+		}
+		_, err := client.ctr(ctx, "containers", "delete", c.ID())
+		return affected("remove", err)
+	}
+	return nil
+}
+
+func (client containerdClient) PauseContainer(ctx context.Context, c Container, dryrun bool) error {
+	log.WithFields(log.Fields{
+		"name":   c.Name(),
+		"id":     c.ID(),
+		"dryrun": dryrun,
+	}).Info("pausing container")
+	if !dryrun {
+		if err := RecordFault(PauseFault(c)); err != nil {
+			return err
+		}
+		_, err := client.ctr(ctx, "tasks", "pause", c.ID())
+		if err != nil {
+			ClearFault(PauseFault(c))
+		}
+		return affected("pause", err)
+	}
+	return nil
+}
+
+func (client containerdClient) UnpauseContainer(ctx context.Context, c Container, dryrun bool) error {
+	log.WithFields(log.Fields{
+		"name":   c.Name(),
+		"id":     c.ID(),
+		"dryrun": dryrun,
+	}).Info("stop pausing container")
+	if !dryrun {
+		_, err := client.ctr(ctx, "tasks", "resume", c.ID())
+		if err == nil {
+			ClearFault(PauseFault(c))
+		}
+		return err
+	}
+	return nil
+}
+
+// network tools from host are used inside task network namespace: tc image is not needed
+func warnNetImage(image string) {
+	if image != "" {
+		log.WithField("image", image).Warn("containerd runtime runs host network tools inside container network namespace: image is ignored")
+	}
+}
+
+func (client containerdClient) NetemContainer(ctx context.Context, c Container, netInterface string, direction string, netemCmd []string, filter NetemFilter, duration time.Duration, tcimage string, pull bool, dryrun bool) error {
+	warnNetImage(tcimage)
+	return client.net.NetemContainer(ctx, c, netInterface, direction, netemCmd, filter, duration, "", false, dryrun)
+}
+
+func (client containerdClient) StopNetemContainer(ctx context.Context, c Container, netInterface string, direction string, filter NetemFilter, tcimage string, pull bool, dryrun bool) error {
+	return client.net.StopNetemContainer(ctx, c, netInterface, direction, filter, "", false, dryrun)
+}
+
+func (client containerdClient) ChangeNetemContainer(ctx context.Context, c Container, netInterface string, direction string, netemCmd []string, filter NetemFilter, tcimage string, pull bool, dryrun bool) error {
+	return client.net.ChangeNetemContainer(ctx, c, netInterface, direction, netemCmd, filter, "", false, dryrun)
+}
+
+func (client containerdClient) NetworkInterface(ctx context.Context, c Container, network string, targets []*net.IPNet, tcimage string, pull bool) (string, error) {
+	return client.net.NetworkInterface(ctx, c, network, targets, "", false)
+}
+
+func (client containerdClient) IPTablesContainer(ctx context.Context, c Container, rules [][]string, image string, pull bool, dryrun bool) error {
+	warnNetImage(image)
+	return client.net.IPTablesContainer(ctx, c, rules, "", false, dryrun)
+}
+
+func (client containerdClient) StopIPTablesContainer(ctx context.Context, c Container, rules [][]string, image string, pull bool, dryrun bool) error {
+	return client.net.StopIPTablesContainer(ctx, c, rules, "", false, dryrun)
+}
+
+func (client containerdClient) StressContainer(ctx context.Context, c Container, stressors []string, image string, pull bool, hostCgroup bool, dryrun bool) (string, error) {
+	return "", errors.New("stress is not supported by containerd runtime")
+}
+
+func (client containerdClient) StopStressContainer(ctx context.Context, c Container, stressID string, dryrun bool) error {
+	return errors.New("stress is not supported by containerd runtime")
+}
+
+func (client containerdClient) ServiceReplicas(ctx context.Context, name string) (uint64, error) {
+	return 0, errors.New("swarm services are not supported by containerd runtime")
+}
+
+func (client containerdClient) ScaleService(ctx context.Context, name string, replicas uint64, dryrun bool) (uint64, error) {
+	return 0, errors.New("swarm services are not supported by containerd runtime")
+}
+
+func (client containerdClient) RestartService(ctx context.Context, name string, dryrun bool) error {
+	return errors.New("swarm services are not supported by containerd runtime")
+}
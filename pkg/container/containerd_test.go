@@ -0,0 +1,115 @@
+package container
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const ctrPrefix = "ctr --address /run/containerd/containerd.sock --namespace default "
+
+// fake host commands: output by command line; records executed command lines
+type fakeRunner struct {
+	outputs map[string]string
+	calls   []string
+}
+
+func (f *fakeRunner) run(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := strings.Join(append([]string{name}, args...), " ")
+	f.calls = append(f.calls, cmd)
+	return f.outputs[cmd], nil
+}
+
+func newFakeContainerdClient(outputs map[string]string) (containerdClient, *fakeRunner) {
+	runner := &fakeRunner{outputs: outputs}
+	return newContainerdClient(DefaultContainerdAddress, DefaultContainerdNamespace, runner.run), runner
+}
+
+func TestContainerdListContainers(t *testing.T) {
+	client, _ := newFakeContainerdClient(map[string]string{
+		ctrPrefix + "tasks ls":           "TASK    PID     STATUS\nc1      1234    RUNNING\nc2      0       STOPPED\n",
+		ctrPrefix + "containers ls -q":   "c1\nc2\nc3\n",
+		ctrPrefix + "containers info c1": `{"ID":"c1","Labels":{"nerdctl/name":"web"},"Image":"docker.io/library/nginx:latest"}`,
+		ctrPrefix + "containers info c2": `{"ID":"c2","Image":"docker.io/library/redis:latest"}`,
+		ctrPrefix + "containers info c3": `{"ID":"c3","Labels":{"com.gaiaadm.pumba":"true"},"Image":"pumba"}`,
+	})
+
+	cs, err := client.ListContainers(context.TODO(), AllContainersFilter)
+	assert.NoError(t, err)
+	if assert.Len(t, cs, 1) {
+		assert.Equal(t, "c1", cs[0].ID())
+		assert.Equal(t, "/web", cs[0].Name())
+		assert.Equal(t, "docker.io/library/nginx:latest", cs[0].ImageName())
+		assert.Equal(t, 1234, cs[0].containerInfo.State.Pid)
+	}
+
+	cs, err = client.ListAllContainers(context.TODO(), ContainerFilter([]string{"c2"}))
+	assert.NoError(t, err)
+	if assert.Len(t, cs, 1) {
+		assert.Equal(t, "/c2", cs[0].Name())
+		assert.False(t, cs[0].containerInfo.State.Running)
+	}
+}
+
+func TestContainerdStopContainer(t *testing.T) {
+	c := containerdContainer(ctrContainer{ID: "c1", Labels: map[string]string{"com.gaiaadm.pumba.stop-signal": "SIGINT"}}, ctrTask{pid: 1234, status: taskRunning})
+	client, runner := newFakeContainerdClient(map[string]string{
+		ctrPrefix + "tasks ls": "TASK    PID     STATUS\nc1      1234    STOPPED\n",
+	})
+
+	err := client.StopContainer(context.TODO(), c, 1, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ctrPrefix + "tasks kill --signal SIGINT c1", ctrPrefix + "tasks ls"}, runner.calls)
+}
+
+func TestContainerdPauseStartContainer(t *testing.T) {
+	c := containerdContainer(ctrContainer{ID: "c1"}, ctrTask{pid: 1234, status: taskRunning})
+	client, runner := newFakeContainerdClient(nil)
+
+	assert.NoError(t, client.PauseContainer(context.TODO(), c, false))
+	assert.NoError(t, client.UnpauseContainer(context.TODO(), c, false))
+	assert.NoError(t, client.StartContainer(context.TODO(), c, false))
+	assert.NoError(t, client.KillContainer(context.TODO(), c, "SIGKILL", true))
+
+	assert.Equal(t, []string{
+		ctrPrefix + "tasks pause c1",
+		ctrPrefix + "tasks resume c1",
+		ctrPrefix + "tasks delete c1",
+		ctrPrefix + "tasks start --null-io --detach c1",
+	}, runner.calls)
+}
+
+func TestContainerdNetemContainer(t *testing.T) {
+	defer resetNetemStates()
+	c := containerdContainer(ctrContainer{ID: "c1"}, ctrTask{pid: 1234, status: taskRunning})
+	client, runner := newFakeContainerdClient(map[string]string{
+		"nsenter --target 1234 --net tc -j qdisc show dev eth0": defaultQdiscs,
+	})
+
+	err := client.NetemContainer(context.TODO(), c, "eth0", DirectionEgress, []string{"delay", "100ms"}, NetemFilter{}, time.Second, "pumba/tc", false, false)
+	assert.NoError(t, err)
+	err = client.StopNetemContainer(context.TODO(), c, "eth0", DirectionEgress, NetemFilter{}, "pumba/tc", false, false)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"nsenter --target 1234 --net tc -j qdisc show dev eth0",
+		"nsenter --target 1234 --net tc -j filter show dev eth0",
+		"nsenter --target 1234 --net tc qdisc add dev eth0 root netem delay 100ms",
+		"nsenter --target 1234 --net tc qdisc del dev eth0 root netem",
+		"nsenter --target 1234 --net tc -j qdisc show dev eth0",
+		"nsenter --target 1234 --net tc -j filter show dev eth0",
+	}, runner.calls)
+}
+
+func TestContainerdNetemContainer_NoTask(t *testing.T) {
+	c := containerdContainer(ctrContainer{ID: "c1"}, ctrTask{})
+	client, _ := newFakeContainerdClient(nil)
+
+	_, err := client.netnsCommand(context.TODO(), c, "tc", []string{"qdisc", "show"})
+
+	assert.Error(t, err)
+}
@@ -47,6 +47,16 @@ func newHTTPClient(url *url.URL, tlsConfig *tls.Config, timeout time.Duration) (
 		url.Scheme = "http"
 		url.Host = "unix.sock"
 		url.Path = ""
+	case "ssh":
+		dialer, err := newSSHDialer(url, timeout)
+		if err != nil {
+			return nil, err
+		}
+		httpTransport.DialContext = dialer.DialContext
+		// SSH connection is encrypted and authenticated: no TLS
+		httpTransport.TLSClientConfig = nil
 	}
+	// Docker client uses *http.Transport TLS config and dialer for hijacked (exec attach) connections:
+	// do not wrap it; Docker API latency is observed by instrumented Docker API clients
 	return &http.Client{Transport: httpTransport}, nil
 }
@@ -0,0 +1,48 @@
+package container
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dockerapi "github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPClient_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/_ping") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("API-Version", "1.39")
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	tlsConfig := &tls.Config{RootCAs: pool}
+	host := "tcp://" + server.Listener.Addr().String()
+
+	httpClient, err := HTTPClient(host, tlsConfig)
+	assert.NoError(t, err)
+	// Docker client needs *http.Transport to use TLS
+	if assert.IsType(t, &http.Transport{}, httpClient.Transport) {
+		assert.Equal(t, tlsConfig, httpClient.Transport.(*http.Transport).TLSClientConfig)
+	}
+	apiClient, err := dockerapi.NewClient(host, "", httpClient, nil)
+	assert.NoError(t, err)
+
+	ping, err := apiClient.Ping(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "1.39", ping.APIVersion)
+	// hijacked (exec attach) connections use TLS too: dialer completes TLS handshake
+	conn, err := apiClient.Dialer()(context.TODO())
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+}
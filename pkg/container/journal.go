@@ -0,0 +1,356 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// fault kinds
+const (
+	// FaultNetem network emulation: tc qdiscs on container network interface
+	FaultNetem = "netem"
+	// FaultIPTables iptables rules in container network namespace
+	FaultIPTables = "iptables"
+	// FaultPause paused container
+	FaultPause = "pause"
+	// FaultStop stopped container, that must be restarted
+	FaultStop = "stop"
+	// FaultScale scaled down Swarm service, that must be scaled back
+	FaultScale = "scale"
+)
+
+// Fault applied fault, that must be undone
+type Fault struct {
+	Kind      string `json:"kind"`
+	Container string `json:"container"`
+	Name      string `json:"name"`
+	// netem: network interface, traffic direction, traffic filter and created qdiscs 'dev handle'
+	Interface string   `json:"interface,omitempty"`
+	Direction string   `json:"direction,omitempty"`
+	IPs       []string `json:"ips,omitempty"`
+	Sources   []string `json:"sources,omitempty"`
+	Ports     string   `json:"ports,omitempty"`
+	SrcPorts  string   `json:"src-ports,omitempty"`
+	Protocol  string   `json:"protocol,omitempty"`
+	Qdiscs    []string `json:"qdiscs,omitempty"`
+	// iptables: inserted rules
+	Rules [][]string `json:"rules,omitempty"`
+	// scale: Swarm service and its original number of replicas
+	Service  string `json:"service,omitempty"`
+	Replicas uint64 `json:"replicas,omitempty"`
+	// netem and iptables: helper image
+	Image   string    `json:"image,omitempty"`
+	Pull    bool      `json:"pull,omitempty"`
+	Applied time.Time `json:"applied"`
+}
+
+// NetemFault netem fault for container network interface
+func NetemFault(c Container, netInterface string, direction string, filter NetemFilter, image string, pull bool) Fault {
+	f := Fault{Kind: FaultNetem, Container: c.ID(), Name: c.Name(), Interface: netInterface, Direction: direction, Image: image, Pull: pull}
+	for _, ip := range filter.Targets {
+		f.IPs = append(f.IPs, ip.String())
+	}
+	for _, ip := range filter.Sources {
+		f.Sources = append(f.Sources, ip.String())
+	}
+	f.Ports = FormatPortRanges(filter.Ports)
+	f.SrcPorts = FormatPortRanges(filter.SrcPorts)
+	f.Protocol = filter.Protocol
+	if direction != DirectionIngress {
+		f.Qdiscs = append(f.Qdiscs, netemQdiscs(netInterface, !filter.Empty())...)
+	}
+	if direction == DirectionIngress || direction == DirectionBoth {
+		f.Qdiscs = append(f.Qdiscs, netInterface+" ffff:")
+		f.Qdiscs = append(f.Qdiscs, netemQdiscs(ifbDevice(netInterface), !filter.Empty())...)
+	}
+	return f
+}
+
+// qdiscs, created by netem on network device
+func netemQdiscs(dev string, filter bool) []string {
+	if !filter {
+		return []string{dev + " root"}
+	}
+	return []string{dev + " 1:", dev + " 10:", dev + " 20:", dev + " 30:"}
+}
+
+// IPTablesFault iptables rules fault
+func IPTablesFault(c Container, rules [][]string, image string, pull bool) Fault {
+	return Fault{Kind: FaultIPTables, Container: c.ID(), Name: c.Name(), Rules: rules, Image: image, Pull: pull}
+}
+
+// PauseFault paused container fault
+func PauseFault(c Container) Fault {
+	return Fault{Kind: FaultPause, Container: c.ID(), Name: c.Name()}
+}
+
+// StopFault stopped container fault
+func StopFault(c Container) Fault {
+	return Fault{Kind: FaultStop, Container: c.ID(), Name: c.Name()}
+}
+
+// ScaleFault scaled down Swarm service fault; replicas - number of replicas to restore
+func ScaleFault(service string, replicas uint64) Fault {
+	return Fault{Kind: FaultScale, Name: service, Service: service, Replicas: replicas}
+}
+
+// fault identity: single fault of each kind per container (and network interface or rules)
+func (f Fault) key() string {
+	key := f.Kind + "/" + f.Container
+	switch f.Kind {
+	case FaultNetem:
+		key += "/" + f.Interface
+	case FaultIPTables:
+		for _, rule := range f.Rules {
+			key += "/" + strings.Join(rule, " ")
+		}
+	case FaultScale:
+		key += "/" + f.Service
+	}
+	return key
+}
+
+// Journal persistent journal of applied faults; journal file is used by single process
+type Journal struct {
+	path   string
+	lock   *os.File
+	mu     sync.Mutex
+	faults []Fault
+}
+
+// journal of current process; nil if disabled
+var journal *Journal
+
+// OpenJournal lock journal file, load it (if exists) and record all following faults into it;
+// fails if journal file is used by another pumba instance
+func OpenJournal(path string) error {
+	// reopen: release lock of current journal
+	CloseJournal()
+	lock, err := lockJournal(path)
+	if err != nil {
+		log.WithError(err).WithField("journal", path).Error("failed to lock journal")
+		return err
+	}
+	j := &Journal{path: path, lock: lock}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		unlockJournal(path, lock)
+		log.WithError(err).WithField("journal", path).Error("failed to read journal")
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &j.faults); err != nil {
+			unlockJournal(path, lock)
+			log.WithError(err).WithField("journal", path).Error("failed to parse journal")
+			return fmt.Errorf("bad journal file %s: %v", path, err)
+		}
+	}
+	journal = j
+	return nil
+}
+
+// CloseJournal stop recording faults and unlock journal file; no-op if journal is disabled
+func CloseJournal() {
+	if journal == nil {
+		return
+	}
+	if err := unlockJournal(journal.path, journal.lock); err != nil {
+		log.WithError(err).WithField("journal", journal.path).Warn("failed to unlock journal")
+	}
+	journal = nil
+}
+
+// JournalFaults faults recorded in journal
+func JournalFaults() []Fault {
+	if journal == nil {
+		return nil
+	}
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	return append([]Fault{}, journal.faults...)
+}
+
+// WarnPendingFaults warn about faults, left in journal by previous run (killed pumba), and return their number;
+// faults are not undone: use 'recover' command or global 'recover-on-start' option
+func WarnPendingFaults() int {
+	faults := JournalFaults()
+	for _, f := range faults {
+		log.WithFields(log.Fields{
+			"fault":     f.Kind,
+			"container": f.Name,
+			"id":        f.Container,
+			"applied":   f.Applied,
+		}).Warn("fault left by previous run")
+	}
+	if len(faults) > 0 {
+		log.WithFields(log.Fields{
+			"journal": journal.path,
+			"faults":  len(faults),
+		}).Warn("journal has faults left by previous run: run 'pumba recover' or use '--recover-on-start' option to undo them")
+	}
+	return len(faults)
+}
+
+// RecordFault write fault to journal before applying it; no-op if journal is disabled
+func RecordFault(f Fault) error {
+	if journal == nil {
+		return nil
+	}
+	f.Applied = time.Now()
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	journal.faults = append(removeFault(journal.faults, f), f)
+	if err := journal.save(); err != nil {
+		log.WithError(err).WithField("journal", journal.path).Error("failed to write journal")
+		return err
+	}
+	return nil
+}
+
+// ClearFault remove undone fault from journal
+func ClearFault(f Fault) {
+	if journal == nil {
+		return
+	}
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	faults := removeFault(journal.faults, f)
+	if len(faults) == len(journal.faults) {
+		return
+	}
+	journal.faults = faults
+	if err := journal.save(); err != nil {
+		log.WithError(err).WithField("journal", journal.path).Error("failed to write journal")
+	}
+}
+
+func removeFault(faults []Fault, f Fault) []Fault {
+	result := make([]Fault, 0, len(faults))
+	for _, fault := range faults {
+		if fault.key() != f.key() {
+			result = append(result, fault)
+		}
+	}
+	return result
+}
+
+// write journal to temporary file and rename it, so journal is never left half written
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j.faults, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// Recover undo all faults, recorded in journal: stop netem, remove iptables rules, unpause and restart containers,
+// scale services back
+// faults are removed from journal once undone (or when container is gone), failed faults are kept for next recovery;
+// dry-run only logs planned recovery
+func Recover(ctx context.Context, client Client, dryRun bool) error {
+	if journal == nil {
+		return errors.New("journal is disabled: set journal file with global 'journal' option")
+	}
+	faults := JournalFaults()
+	if len(faults) == 0 {
+		log.WithField("journal", journal.path).Info("nothing to recover")
+		return nil
+	}
+	log.WithFields(log.Fields{
+		"journal": journal.path,
+		"faults":  len(faults),
+	}).Warn("recovering faults left by previous run")
+	containers, err := client.ListAllContainers(ctx, func(Container) bool { return true })
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]Container, len(containers))
+	for _, c := range containers {
+		byID[c.ID()] = c
+	}
+	// undo faults in reverse order
+	for i := len(faults) - 1; i >= 0; i-- {
+		f := faults[i]
+		logger := log.WithFields(log.Fields{
+			"fault":     f.Kind,
+			"container": f.Name,
+			"id":        f.Container,
+		})
+		// service faults are not bound to container
+		c, ok := byID[f.Container]
+		if !ok && f.Kind != FaultScale {
+			logger.Warn("container not found, skipping recovery")
+		} else if e := undoFault(ctx, client, c, f, dryRun); e != nil {
+			logger.WithError(e).Error("failed to recover fault")
+			err = e
+			// keep fault in journal: retry on next recovery
+			continue
+		} else {
+			logger.Info("fault recovered")
+		}
+		if !dryRun {
+			ClearFault(f)
+		}
+	}
+	return err // last non nil error
+}
+
+func undoFault(ctx context.Context, client Client, c Container, f Fault, dryRun bool) error {
+	switch f.Kind {
+	case FaultNetem:
+		filter, err := ParseNetemFilter(f.IPs, f.Sources, f.Ports, f.SrcPorts, f.Protocol)
+		if err != nil {
+			return err
+		}
+		return client.StopNetemContainer(ctx, c, f.Interface, f.Direction, filter, f.Image, f.Pull, dryRun)
+	case FaultIPTables:
+		return client.StopIPTablesContainer(ctx, c, f.Rules, f.Image, f.Pull, dryRun)
+	case FaultPause:
+		if state := containerState(c); state != nil && !state.Paused {
+			return nil
+		}
+		return client.UnpauseContainer(ctx, c, dryRun)
+	case FaultStop:
+		if state := containerState(c); state != nil && state.Running {
+			return nil
+		}
+		return client.StartContainer(ctx, c, dryRun)
+	case FaultScale:
+		_, err := client.ScaleService(ctx, f.Service, f.Replicas, dryRun)
+		return err
+	}
+	return fmt.Errorf("unknown fault kind '%s'", f.Kind)
+}
+
+// inspected container state; nil if unknown
+func containerState(c Container) *types.ContainerState {
+	if c.containerInfo.ContainerJSONBase == nil {
+		return nil
+	}
+	return c.containerInfo.State
+}
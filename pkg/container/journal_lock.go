@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package container
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lock journal file for exclusive use by current process: '<journal>.lock' file is locked with flock;
+// lock is released by kernel, when process exits (or is killed)
+func lockJournal(path string) (*os.File, error) {
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("journal %s is used by another pumba instance: %v", path, err)
+	}
+	return f, nil
+}
+
+func unlockJournal(path string, f *os.File) error {
+	// lock file is not removed: other instance can hold open lock file already
+	return f.Close()
+}
@@ -0,0 +1,25 @@
+package container
+
+import (
+	"fmt"
+	"os"
+)
+
+// lock journal file for exclusive use by current process: '<journal>.lock' file is created exclusively;
+// lock file of killed process must be removed manually
+func lockJournal(path string) (*os.File, error) {
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("journal %s is used by another pumba instance: remove %s.lock file, if no pumba instance is running", path, path)
+		}
+		return nil, err
+	}
+	fmt.Fprintf(f, "%d\n", os.Getpid())
+	return f, nil
+}
+
+func unlockJournal(path string, f *os.File) error {
+	f.Close()
+	return os.Remove(path + ".lock")
+}
@@ -0,0 +1,137 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testJournal(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "journal")
+	assert.NoError(t, err)
+	path := filepath.Join(dir, "journal.json")
+	assert.NoError(t, OpenJournal(path))
+	return path, func() {
+		CloseJournal()
+		os.RemoveAll(dir)
+	}
+}
+
+func testContainer(id string, state types.ContainerState) Container {
+	return *NewContainer(types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: id, Name: id, State: &state},
+	}, types.ImageInspect{})
+}
+
+func TestJournal_RecordClear(t *testing.T) {
+	path, cleanup := testJournal(t)
+	defer cleanup()
+
+	c := testContainer("c1", types.ContainerState{Running: true})
+	assert.NoError(t, RecordFault(NetemFault(c, "eth0", DirectionBoth, NetemFilter{}, "", false)))
+	assert.NoError(t, RecordFault(PauseFault(c)))
+	// same fault is recorded once
+	assert.NoError(t, RecordFault(PauseFault(c)))
+
+	// reload journal from file
+	assert.NoError(t, OpenJournal(path))
+	faults := JournalFaults()
+	if assert.Len(t, faults, 2) {
+		assert.Equal(t, FaultNetem, faults[0].Kind)
+		assert.Equal(t, []string{"eth0 root", "eth0 ffff:", "ifb-eth0 root"}, faults[0].Qdiscs)
+		assert.Equal(t, FaultPause, faults[1].Kind)
+	}
+
+	ClearFault(PauseFault(c))
+	assert.NoError(t, OpenJournal(path))
+	assert.Len(t, JournalFaults(), 1)
+}
+
+func TestWarnPendingFaults(t *testing.T) {
+	_, cleanup := testJournal(t)
+	defer cleanup()
+
+	assert.Equal(t, 0, WarnPendingFaults())
+	c := testContainer("c1", types.ContainerState{Paused: true})
+	assert.NoError(t, RecordFault(PauseFault(c)))
+	assert.NoError(t, RecordFault(ScaleFault("web", 3)))
+
+	// faults are reported, but not undone
+	assert.Equal(t, 2, WarnPendingFaults())
+	assert.Len(t, JournalFaults(), 2)
+}
+
+func TestJournal_Disabled(t *testing.T) {
+	c := testContainer("c1", types.ContainerState{})
+	assert.NoError(t, RecordFault(PauseFault(c)))
+	assert.Nil(t, JournalFaults())
+	assert.Equal(t, 0, WarnPendingFaults())
+	assert.Error(t, Recover(context.TODO(), nil, false))
+}
+
+func TestRecover(t *testing.T) {
+	_, cleanup := testJournal(t)
+	defer cleanup()
+
+	netem := testContainer("netem", types.ContainerState{Running: true})
+	paused := testContainer("paused", types.ContainerState{Running: true, Paused: true})
+	stopped := testContainer("stopped", types.ContainerState{})
+	gone := testContainer("gone", types.ContainerState{})
+	assert.NoError(t, RecordFault(NetemFault(netem, "eth0", DirectionEgress, NetemFilter{}, "tc", true)))
+	assert.NoError(t, RecordFault(PauseFault(paused)))
+	assert.NoError(t, RecordFault(StopFault(stopped)))
+	assert.NoError(t, RecordFault(StopFault(gone)))
+
+	mockClient := new(MockClient)
+	mockClient.On("ListAllContainers", mock.Anything, mock.Anything).Return([]Container{netem, paused, stopped}, nil)
+	mockClient.On("StopNetemContainer", mock.Anything, netem, "eth0", DirectionEgress, NetemFilter{}, "tc", true, false).Return(errors.New("ERROR"))
+	mockClient.On("UnpauseContainer", mock.Anything, paused, false).Return(nil)
+	mockClient.On("StartContainer", mock.Anything, stopped, false).Return(nil)
+
+	assert.Error(t, Recover(context.TODO(), mockClient, false))
+	mockClient.AssertExpectations(t)
+	// failed netem recovery is kept in journal, other faults are cleared
+	faults := JournalFaults()
+	if assert.Len(t, faults, 1) {
+		assert.Equal(t, FaultNetem, faults[0].Kind)
+	}
+}
+
+func TestJournal_Locked(t *testing.T) {
+	path, cleanup := testJournal(t)
+	defer cleanup()
+
+	// journal is locked by other pumba instance
+	lock, err := lockJournal(path)
+	assert.Error(t, err)
+	assert.Nil(t, lock)
+
+	// lock is released on close
+	CloseJournal()
+	lock, err = lockJournal(path)
+	if assert.NoError(t, err) {
+		assert.NoError(t, unlockJournal(path, lock))
+	}
+}
+
+func TestRecover_Scale(t *testing.T) {
+	_, cleanup := testJournal(t)
+	defer cleanup()
+
+	assert.NoError(t, RecordFault(ScaleFault("web", 3)))
+
+	mockClient := new(MockClient)
+	mockClient.On("ListAllContainers", mock.Anything, mock.Anything).Return([]Container{}, nil)
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(3), false).Return(uint64(0), nil)
+
+	assert.NoError(t, Recover(context.TODO(), mockClient, false))
+	mockClient.AssertExpectations(t)
+	assert.Empty(t, JournalFaults())
+}
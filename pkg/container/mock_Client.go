@@ -49,6 +49,55 @@ func (_m *MockClient) ListAllContainers(_a0 context.Context, _a1 Filter) ([]Cont
 	return r0, r1
 }
 
+// ChangeNetemContainer provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5, _a6, _a7, _a8
+func (_m *MockClient) ChangeNetemContainer(_a0 context.Context, _a1 Container, _a2 string, _a3 string, _a4 []string, _a5 NetemFilter, _a6 string, _a7 bool, _a8 bool) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4, _a5, _a6, _a7, _a8)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, Container, string, string, []string, NetemFilter, string, bool, bool) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4, _a5, _a6, _a7, _a8)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NetworkInterface provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5
+func (_m *MockClient) NetworkInterface(_a0 context.Context, _a1 Container, _a2 string, _a3 []*net.IPNet, _a4 string, _a5 bool) (string, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4, _a5)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, Container, string, []*net.IPNet, string, bool) string); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4, _a5)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, Container, string, []*net.IPNet, string, bool) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3, _a4, _a5)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IPTablesContainer provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5
+func (_m *MockClient) IPTablesContainer(_a0 context.Context, _a1 Container, _a2 [][]string, _a3 string, _a4 bool, _a5 bool) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4, _a5)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, Container, [][]string, string, bool, bool) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4, _a5)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ListContainers provides a mock function with given fields: _a0, _a1
 func (_m *MockClient) ListContainers(_a0 context.Context, _a1 Filter) ([]Container, error) {
 	ret := _m.Called(_a0, _a1)
@@ -72,12 +121,12 @@ func (_m *MockClient) ListContainers(_a0 context.Context, _a1 Filter) ([]Contain
 	return r0, r1
 }
 
-// NetemContainer provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5, _a6, _a7, _a8
-func (_m *MockClient) NetemContainer(_a0 context.Context, _a1 Container, _a2 string, _a3 []string, _a4 []*net.IPNet, _a5 uint16 , _a6 time.Duration, _a7 string, _a8 bool, _a9 bool) error {
+// NetemContainer provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5, _a6, _a7, _a8, _a9
+func (_m *MockClient) NetemContainer(_a0 context.Context, _a1 Container, _a2 string, _a3 string, _a4 []string, _a5 NetemFilter, _a6 time.Duration, _a7 string, _a8 bool, _a9 bool) error {
 	ret := _m.Called(_a0, _a1, _a2, _a3, _a4, _a5, _a6, _a7, _a8, _a9)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, Container, string, []string, []*net.IPNet, uint16, time.Duration, string, bool, bool) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, Container, string, string, []string, NetemFilter, time.Duration, string, bool, bool) error); ok {
 		r0 = rf(_a0, _a1, _a2, _a3, _a4, _a5, _a6, _a7, _a8, _a9)
 	} else {
 		r0 = ret.Error(0)
@@ -142,12 +191,26 @@ func (_m *MockClient) StopContainer(_a0 context.Context, _a1 Container, _a2 int,
 	return r0
 }
 
-// StopNetemContainer provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5, _a6
-func (_m *MockClient) StopNetemContainer(_a0 context.Context, _a1 Container, _a2 string, _a3 []*net.IPNet, _a4 uint16, _a5 string, _a6 bool, _a7 bool) error {
+// StopIPTablesContainer provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5
+func (_m *MockClient) StopIPTablesContainer(_a0 context.Context, _a1 Container, _a2 [][]string, _a3 string, _a4 bool, _a5 bool) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4, _a5)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, Container, [][]string, string, bool, bool) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4, _a5)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StopNetemContainer provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5, _a6, _a7
+func (_m *MockClient) StopNetemContainer(_a0 context.Context, _a1 Container, _a2 string, _a3 string, _a4 NetemFilter, _a5 string, _a6 bool, _a7 bool) error {
 	ret := _m.Called(_a0, _a1, _a2, _a3, _a4, _a5, _a6, _a7)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, Container, string, []*net.IPNet, uint16, string, bool, bool) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, Container, string, string, NetemFilter, string, bool, bool) error); ok {
 		r0 = rf(_a0, _a1, _a2, _a3, _a4, _a5, _a6, _a7)
 	} else {
 		r0 = ret.Error(0)
@@ -156,6 +219,97 @@ func (_m *MockClient) StopNetemContainer(_a0 context.Context, _a1 Container, _a2
 	return r0
 }
 
+// RestartService provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockClient) RestartService(_a0 context.Context, _a1 string, _a2 bool) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScaleService provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *MockClient) ScaleService(_a0 context.Context, _a1 string, _a2 uint64, _a3 bool) (uint64, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64, bool) uint64); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint64, bool) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ServiceReplicas provides a mock function with given fields: _a0, _a1
+func (_m *MockClient) ServiceReplicas(_a0 context.Context, _a1 string) (uint64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(context.Context, string) uint64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StopStressContainer provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *MockClient) StopStressContainer(_a0 context.Context, _a1 Container, _a2 string, _a3 bool) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, Container, string, bool) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StressContainer provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4, _a5, _a6
+func (_m *MockClient) StressContainer(_a0 context.Context, _a1 Container, _a2 []string, _a3 string, _a4 bool, _a5 bool, _a6 bool) (string, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4, _a5, _a6)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, Container, []string, string, bool, bool, bool) string); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4, _a5, _a6)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, Container, []string, string, bool, bool, bool) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3, _a4, _a5, _a6)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UnpauseContainer provides a mock function with given fields: _a0, _a1, _a2
 func (_m *MockClient) UnpauseContainer(_a0 context.Context, _a1 Container, _a2 bool) error {
 	ret := _m.Called(_a0, _a1, _a2)
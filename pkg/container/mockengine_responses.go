@@ -28,6 +28,7 @@ func ContainerDetailsResponse(params map[string]interface{}) types.ContainerJSON
 	Running := lookupWithDefault(params, "Running", false).(bool)
 	Labels := lookupWithDefault(params, "Labels", map[string]string{}).(map[string]string)
 	Links := lookupWithDefault(params, "Links", []string{}).([]string)
+	IPAddress := lookupWithDefault(params, "IPAddress", "").(string)
 
 	return types.ContainerJSON{
 		ContainerJSONBase: &types.ContainerJSONBase{
@@ -42,7 +43,7 @@ func ContainerDetailsResponse(params map[string]interface{}) types.ContainerJSON
 		},
 		NetworkSettings: &types.NetworkSettings{
 			Networks: map[string]*network.EndpointSettings{
-				"default": {Links: Links},
+				"default": {Links: Links, IPAddress: IPAddress},
 			},
 		},
 	}
@@ -0,0 +1,248 @@
+package container
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// NewMultiHostClient returns a Client, that fans out to clients of several Docker daemons (by daemon endpoint):
+// containers are listed from all daemons, tagged with their daemon, and container operations are routed to it
+func NewMultiHostClient(clients map[string]Client) Client {
+	hosts := make([]string, 0, len(clients))
+	for host := range clients {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return multiHostClient{hosts: hosts, clients: clients}
+}
+
+// ReadHostsFile reads Docker daemon endpoints from file: one endpoint per line; empty lines and '#' comments are skipped
+func ReadHostsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var hosts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			hosts = append(hosts, line)
+		}
+	}
+	return hosts, scanner.Err()
+}
+
+type multiHostClient struct {
+	// sorted daemon endpoints
+	hosts   []string
+	clients map[string]Client
+}
+
+func (client multiHostClient) ListContainers(ctx context.Context, fn Filter) ([]Container, error) {
+	return client.listContainers(func(c Client) ([]Container, error) {
+		return c.ListContainers(ctx, fn)
+	})
+}
+
+func (client multiHostClient) ListAllContainers(ctx context.Context, fn Filter) ([]Container, error) {
+	return client.listContainers(func(c Client) ([]Container, error) {
+		return c.ListAllContainers(ctx, fn)
+	})
+}
+
+// list containers from all daemons in parallel; fail if any daemon fails
+func (client multiHostClient) listContainers(list func(Client) ([]Container, error)) ([]Container, error) {
+	results := make([][]Container, len(client.hosts))
+	errs := make([]error, len(client.hosts))
+	var wg sync.WaitGroup
+	for i, host := range client.hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			results[i], errs[i] = list(client.clients[host])
+		}(i, host)
+	}
+	wg.Wait()
+	cs := []Container{}
+	for i, host := range client.hosts {
+		if errs[i] != nil {
+			log.WithError(errs[i]).WithField("host", host).Error("failed to list containers")
+			return nil, fmt.Errorf("failed to list containers on %s: %s", host, errs[i])
+		}
+		for _, c := range results[i] {
+			c.host = host
+			cs = append(cs, c)
+		}
+	}
+	return cs, nil
+}
+
+// client of container daemon
+func (client multiHostClient) client(c Container) (Client, error) {
+	if hc, ok := client.clients[c.host]; ok {
+		return hc, nil
+	}
+	return nil, fmt.Errorf("unknown Docker host '%s' of container %s (%s)", c.host, c.Name(), c.ID())
+}
+
+func (client multiHostClient) StopContainer(ctx context.Context, c Container, timeout int, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.StopContainer(ctx, c, timeout, dryrun)
+}
+
+func (client multiHostClient) KillContainer(ctx context.Context, c Container, signal string, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.KillContainer(ctx, c, signal, dryrun)
+}
+
+func (client multiHostClient) RemoveContainer(ctx context.Context, c Container, force bool, links bool, volumes bool, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.RemoveContainer(ctx, c, force, links, volumes, dryrun)
+}
+
+func (client multiHostClient) NetemContainer(ctx context.Context, c Container, netInterface string, direction string, netemCmd []string, filter NetemFilter, duration time.Duration, tcimage string, pull bool, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.NetemContainer(ctx, c, netInterface, direction, netemCmd, filter, duration, tcimage, pull, dryrun)
+}
+
+func (client multiHostClient) StopNetemContainer(ctx context.Context, c Container, netInterface string, direction string, filter NetemFilter, tcimage string, pull bool, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.StopNetemContainer(ctx, c, netInterface, direction, filter, tcimage, pull, dryrun)
+}
+
+func (client multiHostClient) ChangeNetemContainer(ctx context.Context, c Container, netInterface string, direction string, netemCmd []string, filter NetemFilter, tcimage string, pull bool, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.ChangeNetemContainer(ctx, c, netInterface, direction, netemCmd, filter, tcimage, pull, dryrun)
+}
+
+func (client multiHostClient) NetworkInterface(ctx context.Context, c Container, network string, targets []*net.IPNet, tcimage string, pull bool) (string, error) {
+	hc, err := client.client(c)
+	if err != nil {
+		return "", err
+	}
+	return hc.NetworkInterface(ctx, c, network, targets, tcimage, pull)
+}
+
+func (client multiHostClient) IPTablesContainer(ctx context.Context, c Container, rules [][]string, image string, pull bool, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.IPTablesContainer(ctx, c, rules, image, pull, dryrun)
+}
+
+func (client multiHostClient) StopIPTablesContainer(ctx context.Context, c Container, rules [][]string, image string, pull bool, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.StopIPTablesContainer(ctx, c, rules, image, pull, dryrun)
+}
+
+func (client multiHostClient) PauseContainer(ctx context.Context, c Container, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.PauseContainer(ctx, c, dryrun)
+}
+
+func (client multiHostClient) UnpauseContainer(ctx context.Context, c Container, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.UnpauseContainer(ctx, c, dryrun)
+}
+
+func (client multiHostClient) StartContainer(ctx context.Context, c Container, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.StartContainer(ctx, c, dryrun)
+}
+
+func (client multiHostClient) StressContainer(ctx context.Context, c Container, stressors []string, image string, pull bool, hostCgroup bool, dryrun bool) (string, error) {
+	hc, err := client.client(c)
+	if err != nil {
+		return "", err
+	}
+	return hc.StressContainer(ctx, c, stressors, image, pull, hostCgroup, dryrun)
+}
+
+func (client multiHostClient) StopStressContainer(ctx context.Context, c Container, stressID string, dryrun bool) error {
+	hc, err := client.client(c)
+	if err != nil {
+		return err
+	}
+	return hc.StopStressContainer(ctx, c, stressID, dryrun)
+}
+
+func (client multiHostClient) ServiceReplicas(ctx context.Context, name string) (uint64, error) {
+	var err error
+	for _, host := range client.hosts {
+		var replicas uint64
+		if replicas, err = client.clients[host].ServiceReplicas(ctx, name); err == nil {
+			return replicas, nil
+		}
+		log.WithError(err).WithField("host", host).Debug("failed to inspect service")
+	}
+	return 0, err
+}
+
+// Swarm service is managed by any manager node: try daemons in order, till one of them succeeds
+func (client multiHostClient) ScaleService(ctx context.Context, name string, replicas uint64, dryrun bool) (uint64, error) {
+	var err error
+	for _, host := range client.hosts {
+		var previous uint64
+		if previous, err = client.clients[host].ScaleService(ctx, name, replicas, dryrun); err == nil {
+			return previous, nil
+		}
+		log.WithError(err).WithField("host", host).Debug("failed to scale service")
+	}
+	return 0, err
+}
+
+func (client multiHostClient) RestartService(ctx context.Context, name string, dryrun bool) error {
+	var err error
+	for _, host := range client.hosts {
+		if err = client.clients[host].RestartService(ctx, name, dryrun); err == nil {
+			return nil
+		}
+		log.WithError(err).WithField("host", host).Debug("failed to restart service")
+	}
+	return err
+}
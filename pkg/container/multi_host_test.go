@@ -0,0 +1,106 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMultiHostClient_ListContainers(t *testing.T) {
+	host1, host2 := new(MockClient), new(MockClient)
+	cs1, cs2 := CreateTestContainers(2), CreateTestContainers(1)
+	host1.On("ListContainers", mock.Anything, mock.Anything).Return(cs1, nil)
+	host2.On("ListContainers", mock.Anything, mock.Anything).Return(cs2, nil)
+	client := NewMultiHostClient(map[string]Client{"tcp://host2:2375": host2, "tcp://host1:2375": host1})
+
+	cs, err := client.ListContainers(context.TODO(), AllContainersFilter)
+
+	assert.NoError(t, err)
+	if assert.Len(t, cs, 3) {
+		assert.Equal(t, "tcp://host1:2375", cs[0].Host())
+		assert.Equal(t, "tcp://host1:2375", cs[1].Host())
+		assert.Equal(t, "tcp://host2:2375", cs[2].Host())
+	}
+	host1.AssertExpectations(t)
+	host2.AssertExpectations(t)
+}
+
+func TestMultiHostClient_ListContainersError(t *testing.T) {
+	host1, host2 := new(MockClient), new(MockClient)
+	host1.On("ListAllContainers", mock.Anything, mock.Anything).Return(CreateTestContainers(1), nil)
+	host2.On("ListAllContainers", mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+	client := NewMultiHostClient(map[string]Client{"host1": host1, "host2": host2})
+
+	_, err := client.ListAllContainers(context.TODO(), AllContainersFilter)
+
+	assert.EqualError(t, err, "failed to list containers on host2: connection refused")
+}
+
+func TestMultiHostClient_Route(t *testing.T) {
+	host1, host2 := new(MockClient), new(MockClient)
+	client := NewMultiHostClient(map[string]Client{"host1": host1, "host2": host2})
+	c := CreateTestContainers(1)[0]
+	c.host = "host2"
+	host2.On("KillContainer", mock.Anything, c, "SIGKILL", false).Return(nil)
+	host2.On("StopNetemContainer", mock.Anything, c, "eth0", DirectionEgress, NetemFilter{}, "", false, false).Return(nil)
+
+	assert.NoError(t, client.KillContainer(context.TODO(), c, "SIGKILL", false))
+	assert.NoError(t, client.StopNetemContainer(context.TODO(), c, "eth0", DirectionEgress, NetemFilter{}, "", false, false))
+	host1.AssertNotCalled(t, "KillContainer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	host2.AssertExpectations(t)
+
+	c.host = "host3"
+	assert.Error(t, client.PauseContainer(context.TODO(), c, false))
+}
+
+func TestReadHostsFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "hosts")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("# staging\ntcp://10.0.0.1:2376\n\n  tcp://10.0.0.2:2376  # db\n")
+	assert.NoError(t, err)
+	f.Close()
+
+	hosts, err := ReadHostsFile(f.Name())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tcp://10.0.0.1:2376", "tcp://10.0.0.2:2376"}, hosts)
+	_, err = ReadHostsFile(f.Name() + ".missing")
+	assert.Error(t, err)
+}
+
+func TestSharedIPAddresses_OtherHost(t *testing.T) {
+	c1 := Container{containerInfo: ContainerDetailsResponse(AsMap("ID", "c1")), host: "host1"}
+	c1.containerInfo.NetworkSettings.Networks["backend"] = &network.EndpointSettings{IPAddress: "10.0.1.2"}
+	c2 := Container{containerInfo: ContainerDetailsResponse(AsMap("ID", "c2")), host: "host1"}
+	c2.containerInfo.NetworkSettings.Networks["backend"] = &network.EndpointSettings{IPAddress: "10.0.1.3"}
+
+	assert.Equal(t, []string{"10.0.1.2"}, c1.SharedIPAddresses(c2))
+	// same network name on other daemon is other network
+	c2.host = "host2"
+	assert.Empty(t, c1.SharedIPAddresses(c2))
+}
+
+func TestMultiHostClient_ScaleService(t *testing.T) {
+	worker, manager := new(MockClient), new(MockClient)
+	worker.On("ScaleService", mock.Anything, "web", uint64(0), false).Return(uint64(0), errors.New("This node is not a swarm manager"))
+	manager.On("ScaleService", mock.Anything, "web", uint64(0), false).Return(uint64(3), nil)
+	client := NewMultiHostClient(map[string]Client{"host1": worker, "host2": manager})
+
+	previous, err := client.ScaleService(context.TODO(), "web", 0, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), previous)
+	worker.AssertExpectations(t)
+	manager.AssertExpectations(t)
+
+	worker.On("RestartService", mock.Anything, "web", false).Return(errors.New("This node is not a swarm manager"))
+	manager.On("RestartService", mock.Anything, "web", false).Return(errors.New("no such service: web"))
+	assert.EqualError(t, client.RestartService(context.TODO(), "web", false), "no such service: web")
+}
@@ -0,0 +1,91 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AutoInterface network interface name, resolved inside target container network namespace
+const AutoInterface = "auto"
+
+// NetworkInterface resolve network interface inside container network namespace with 'ip' command:
+// interface with container IP on Docker network (if network is defined), interface carrying traffic
+// to target IPs (if targets are defined) or interface of default route; resolution is read-only,
+// so it is done in dry-run mode too
+func (client dockerClient) NetworkInterface(ctx context.Context, c Container, network string, targets []*net.IPNet, tcimage string, pull bool) (string, error) {
+	log.WithFields(log.Fields{
+		"name":     c.Name(),
+		"id":       c.ID(),
+		"network":  network,
+		"targets":  targets,
+		"tc-image": tcimage,
+	}).Debug("resolving network interface")
+	// interface with container IP on Docker network
+	if network != "" {
+		ip := c.NetworkIPAddress(network)
+		if ip == "" {
+			return "", fmt.Errorf("container %s has no IP address on network %s", c.Name(), network)
+		}
+		output, err := client.ipCommandOutput(ctx, c, []string{"-o", "addr", "show"}, tcimage, pull)
+		if err != nil {
+			return "", err
+		}
+		return addrInterface(output, ip)
+	}
+	// interface carrying traffic to all targets: netem is applied to single interface
+	if len(targets) > 0 {
+		var iface string
+		for _, target := range targets {
+			output, err := client.ipCommandOutput(ctx, c, []string{"route", "get", target.IP.String()}, tcimage, pull)
+			if err != nil {
+				return "", err
+			}
+			dev, err := routeInterface(output)
+			if err != nil {
+				return "", err
+			}
+			if iface != "" && dev != iface {
+				return "", fmt.Errorf("targets are routed through different network interfaces %s and %s: use --interface or --network", iface, dev)
+			}
+			iface = dev
+		}
+		return iface, nil
+	}
+	// interface of default route
+	output, err := client.ipCommandOutput(ctx, c, []string{"route", "show", "default"}, tcimage, pull)
+	if err != nil {
+		return "", err
+	}
+	return routeInterface(output)
+}
+
+// find interface with IP in 'ip -o addr show' output:
+// '2: eth0    inet 172.17.0.2/16 brd 172.17.255.255 scope global eth0\       valid_lft forever preferred_lft forever'
+func addrInterface(output string, ip string) (string, error) {
+	addr := net.ParseIP(ip)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || (fields[2] != "inet" && fields[2] != "inet6") {
+			continue
+		}
+		if net.ParseIP(strings.SplitN(fields[3], "/", 2)[0]).Equal(addr) {
+			return fields[1], nil
+		}
+	}
+	return "", fmt.Errorf("no network interface found with IP address %s", ip)
+}
+
+// find route interface in 'ip route' output: '10.0.0.5 dev eth1 src 10.0.0.2 uid 0' or 'default via 172.17.0.1 dev eth0'
+func routeInterface(output string) (string, error) {
+	fields := strings.Fields(output)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == "dev" {
+			return fields[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("no network interface found in route '%s'", strings.TrimSpace(output))
+}
@@ -0,0 +1,138 @@
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	ctypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shinespb/pumba/pkg/util"
+)
+
+// multiplexed stdout stream, as returned by Docker exec attach and container logs
+func stdoutStream(t *testing.T, output string) *bytes.Buffer {
+	var buf bytes.Buffer
+	_, err := stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte(output))
+	assert.NoError(t, err)
+	return &buf
+}
+
+func Test_addrInterface(t *testing.T) {
+	output := `1: lo    inet 127.0.0.1/8 scope host lo\       valid_lft forever preferred_lft forever
+24: eth0    inet 172.17.0.2/16 brd 172.17.255.255 scope global eth0\       valid_lft forever preferred_lft forever
+26: eth1    inet 10.0.1.2/24 brd 10.0.1.255 scope global eth1\       valid_lft forever preferred_lft forever
+26: eth1    inet6 fd00:1::2/64 scope global nodad \       valid_lft forever preferred_lft forever
+`
+	iface, err := addrInterface(output, "10.0.1.2")
+	assert.NoError(t, err)
+	assert.Equal(t, "eth1", iface)
+	iface, err = addrInterface(output, "fd00:1::2")
+	assert.NoError(t, err)
+	assert.Equal(t, "eth1", iface)
+	_, err = addrInterface(output, "10.0.2.2")
+	assert.Error(t, err)
+}
+
+func Test_routeInterface(t *testing.T) {
+	iface, err := routeInterface("10.0.1.5 dev eth1 src 10.0.1.2 uid 0 \n    cache \n")
+	assert.NoError(t, err)
+	assert.Equal(t, "eth1", iface)
+	iface, err = routeInterface("default via 172.17.0.1 dev eth0 \n")
+	assert.NoError(t, err)
+	assert.Equal(t, "eth0", iface)
+	_, err = routeInterface("")
+	assert.Error(t, err)
+}
+
+func TestNetworkInterface_Exec(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		targets []*net.IPNet
+		args    []string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "network",
+			network: "backend",
+			args:    []string{"ip", "-o", "addr", "show"},
+			output:  "24: eth0    inet 172.17.0.2/16 scope global eth0\n26: eth1    inet 10.0.1.2/24 scope global eth1\n",
+			want:    "eth1",
+		},
+		{
+			name:    "unknown network",
+			network: "frontend",
+			wantErr: true,
+		},
+		{
+			name:    "target",
+			targets: []*net.IPNet{util.ParseCIDR("10.0.1.5")},
+			args:    []string{"ip", "route", "get", "10.0.1.5"},
+			output:  "10.0.1.5 dev eth1 src 10.0.1.2 uid 0\n",
+			want:    "eth1",
+		},
+		{
+			name:   "default route",
+			args:   []string{"ip", "route", "show", "default"},
+			output: "default via 172.17.0.1 dev eth0\n",
+			want:   "eth0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Container{containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123", "IPAddress", "172.17.0.2"))}
+			c.containerInfo.NetworkSettings.Networks["backend"] = &network.EndpointSettings{IPAddress: "10.0.1.2"}
+			engineClient := NewMockEngine()
+			if tt.args != nil {
+				config := types.ExecConfig{AttachStdout: true, AttachStderr: true, Cmd: tt.args}
+				engineClient.On("ContainerExecCreate", mock.Anything, "abc123", config).Return(types.IDResponse{ID: "execID"}, nil)
+				conn, _ := net.Pipe()
+				resp := types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(stdoutStream(t, tt.output))}
+				engineClient.On("ContainerExecAttach", mock.Anything, "execID", types.ExecStartCheck{}).Return(resp, nil)
+				engineClient.On("ContainerExecInspect", mock.Anything, "execID").Return(types.ContainerExecInspect{}, nil)
+			}
+			client := dockerClient{containerAPI: engineClient}
+			got, err := client.NetworkInterface(context.TODO(), c, tt.network, tt.targets, "", false)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			engineClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNetworkInterface_TCImage(t *testing.T) {
+	c := Container{containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123"))}
+	engineClient := NewMockEngine()
+	config, hconfig := netContainerConfig(c, "ip", []string{"route", "show", "default"}, "pumba/tc")
+	hconfig.AutoRemove = false
+	engineClient.On("ContainerCreate", mock.Anything, &config, &hconfig, (*network.NetworkingConfig)(nil), "").Return(ctypes.ContainerCreateCreatedBody{ID: "tcID"}, nil)
+	statusC := make(chan ctypes.ContainerWaitOKBody, 1)
+	statusC <- ctypes.ContainerWaitOKBody{StatusCode: 0}
+	engineClient.On("ContainerWait", mock.Anything, "tcID", ctypes.WaitConditionNextExit).Return((<-chan ctypes.ContainerWaitOKBody)(statusC), (<-chan error)(make(chan error)))
+	engineClient.On("ContainerStart", mock.Anything, "tcID", types.ContainerStartOptions{}).Return(nil)
+	logs := ioutil.NopCloser(stdoutStream(t, "default via 172.17.0.1 dev eth0\n"))
+	engineClient.On("ContainerLogs", mock.Anything, "tcID", types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true}).Return(logs, nil)
+	engineClient.On("ContainerRemove", mock.Anything, "tcID", types.ContainerRemoveOptions{Force: true}).Return(nil)
+
+	client := dockerClient{containerAPI: engineClient}
+	got, err := client.NetworkInterface(context.TODO(), c, "", nil, "pumba/tc", false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "eth0", got)
+	engineClient.AssertExpectations(t)
+}
@@ -0,0 +1,352 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shinespb/pumba/pkg/util"
+)
+
+// PortRange range of ports; single port if From equals To
+type PortRange struct {
+	From uint16
+	To   uint16
+}
+
+func (r PortRange) String() string {
+	if r.From == r.To {
+		return strconv.Itoa(int(r.From))
+	}
+	return fmt.Sprintf("%d-%d", r.From, r.To)
+}
+
+// NetemFilter netem traffic classifier: only traffic matching all defined criteria is affected by netem;
+// empty filter matches all traffic
+type NetemFilter struct {
+	Targets        []*net.IPNet // target IPs: destination for egress, source for ingress traffic
+	TargetNames    []string     // target container names; resolved to target IPs by ResolveNetemFilter
+	TargetPatterns []string     // target container re2 regex patterns; resolved to target IPs by ResolveNetemFilter
+	Sources        []*net.IPNet // source IPs: source for egress, destination for ingress traffic
+	Ports          []PortRange  // target ports: destination for egress, source for ingress traffic
+	SrcPorts       []PortRange  // source ports: source for egress, destination for ingress traffic
+	Protocol       string       // L4 protocol: tcp, udp or icmp
+}
+
+const (
+	// TargetContainerPrefix target container name prefix: 'container:<name>'
+	TargetContainerPrefix = "container:"
+	// TargetPatternPrefix target container re2 regex prefix: 're2:<pattern>'
+	TargetPatternPrefix = "re2:"
+)
+
+// L4 protocol numbers: IPv4 and IPv6 (next header)
+var netemProtocols = map[string][2]int{
+	"tcp":  {6, 6},
+	"udp":  {17, 17},
+	"icmp": {1, 58},
+}
+
+// ParseNetemFilter parse and validate netem filter: target and source IPs (support CIDR notation),
+// comma separated lists of ports and port ranges (example: '80,8000-8100') and protocol;
+// targets can also be containers: 'container:<name>' or 're2:<pattern>'
+func ParseNetemFilter(targets []string, sources []string, ports string, srcPorts string, protocol string) (NetemFilter, error) {
+	var f NetemFilter
+	var err error
+	var ips []string
+	for _, target := range targets {
+		switch {
+		case strings.HasPrefix(target, TargetContainerPrefix):
+			name := strings.TrimPrefix(target, TargetContainerPrefix)
+			if name == "" {
+				return f, fmt.Errorf("bad target: '%s' has empty container name", target)
+			}
+			f.TargetNames = append(f.TargetNames, name)
+		case strings.HasPrefix(target, TargetPatternPrefix):
+			pattern := strings.TrimPrefix(target, TargetPatternPrefix)
+			if _, err = regexp.Compile(pattern); err != nil || pattern == "" {
+				return f, fmt.Errorf("bad target: '%s' is not a valid re2 regex", target)
+			}
+			f.TargetPatterns = append(f.TargetPatterns, pattern)
+		default:
+			ips = append(ips, target)
+		}
+	}
+	if f.Targets, err = parseFilterIPs("target", ips); err != nil {
+		return f, err
+	}
+	if f.Sources, err = parseFilterIPs("source", sources); err != nil {
+		return f, err
+	}
+	if f.Ports, err = parsePortRanges(ports); err != nil {
+		return f, err
+	}
+	if f.SrcPorts, err = parsePortRanges(srcPorts); err != nil {
+		return f, err
+	}
+	f.Protocol = strings.ToLower(protocol)
+	if _, ok := netemProtocols[f.Protocol]; !ok && f.Protocol != "" {
+		return f, fmt.Errorf("invalid protocol '%s': must be one of {tcp | udp | icmp}", protocol)
+	}
+	if f.Protocol == "icmp" && (len(f.Ports) > 0 || len(f.SrcPorts) > 0) {
+		return f, errors.New("port filter cannot be used with icmp protocol")
+	}
+	// target and source IPs must have common IP version, otherwise no traffic matches;
+	// target containers are checked, when resolved
+	if !f.hasTargetContainers() {
+		if err = f.checkIPVersions(); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+func (f NetemFilter) hasTargetContainers() bool {
+	return len(f.TargetNames) > 0 || len(f.TargetPatterns) > 0
+}
+
+func (f NetemFilter) checkIPVersions() error {
+	if len(f.Targets) > 0 && len(f.Sources) > 0 && len(ipVersions(f.Targets, f.Sources)) == 0 {
+		return errors.New("bad filter: target and source IPs must include addresses of the same IP version")
+	}
+	return nil
+}
+
+// ResolveNetemFilter resolve target containers into target IPs: IPs of running target containers in networks,
+// shared with netem container c; resolved filter has only target IPs
+func ResolveNetemFilter(ctx context.Context, client Client, c Container, f NetemFilter) (NetemFilter, error) {
+	if !f.hasTargetContainers() {
+		return f, nil
+	}
+	filters := []Filter{ContainerFilter(f.TargetNames)}
+	if len(f.TargetNames) == 0 {
+		filters = nil
+	}
+	for _, pattern := range f.TargetPatterns {
+		filters = append(filters, RegexContainerFilter(pattern))
+	}
+	peers, err := client.ListContainers(ctx, func(peer Container) bool {
+		for _, filter := range filters {
+			if filter(peer) {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return f, err
+	}
+	resolved := f
+	resolved.Targets = append([]*net.IPNet{}, f.Targets...)
+	resolved.TargetNames, resolved.TargetPatterns = nil, nil
+	for _, peer := range peers {
+		// netem container cannot be its own target
+		if peer.ID() == c.ID() {
+			continue
+		}
+		for _, ip := range peer.SharedIPAddresses(c) {
+			if ipNet := util.ParseCIDR(ip); ipNet != nil {
+				resolved.Targets = append(resolved.Targets, ipNet)
+			}
+		}
+	}
+	// unresolved target would turn filter into match all
+	if len(resolved.Targets) == len(f.Targets) {
+		return f, fmt.Errorf("no IP addresses found for target containers in networks of container %s", c.Name())
+	}
+	return resolved, resolved.checkIPVersions()
+}
+
+func parseFilterIPs(kind string, list []string) ([]*net.IPNet, error) {
+	var ips []*net.IPNet
+	for _, str := range list {
+		ip := util.ParseCIDR(str)
+		if ip == nil {
+			return nil, fmt.Errorf("bad %s: '%s' is not a valid IP", kind, str)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// parse comma separated list of ports and port ranges: '80,443,8000-8100'
+func parsePortRanges(str string) ([]PortRange, error) {
+	if str == "" {
+		return nil, nil
+	}
+	var ranges []PortRange
+	for _, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		bounds := strings.SplitN(item, "-", 2)
+		from, err := strconv.ParseUint(bounds[0], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("bad port: '%s' is not a valid port or port range", item)
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.ParseUint(bounds[1], 10, 16); err != nil || to < from {
+				return nil, fmt.Errorf("bad port: '%s' is not a valid port or port range", item)
+			}
+		}
+		ranges = append(ranges, PortRange{From: uint16(from), To: uint16(to)})
+	}
+	return ranges, nil
+}
+
+// Empty filter matches all traffic
+func (f NetemFilter) Empty() bool {
+	return len(f.Targets) == 0 && !f.hasTargetContainers() && len(f.Sources) == 0 && len(f.Ports) == 0 && len(f.SrcPorts) == 0 && f.Protocol == ""
+}
+
+// String filter representation, used in logs and journal
+func (f NetemFilter) String() string {
+	var parts []string
+	for _, ip := range f.Targets {
+		parts = append(parts, "target "+ip.String())
+	}
+	for _, name := range f.TargetNames {
+		parts = append(parts, "target "+TargetContainerPrefix+name)
+	}
+	for _, pattern := range f.TargetPatterns {
+		parts = append(parts, "target "+TargetPatternPrefix+pattern)
+	}
+	for _, ip := range f.Sources {
+		parts = append(parts, "source "+ip.String())
+	}
+	if len(f.Ports) > 0 {
+		parts = append(parts, "port "+FormatPortRanges(f.Ports))
+	}
+	if len(f.SrcPorts) > 0 {
+		parts = append(parts, "src-port "+FormatPortRanges(f.SrcPorts))
+	}
+	if f.Protocol != "" {
+		parts = append(parts, "protocol "+f.Protocol)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatPortRanges format port ranges as comma separated list, accepted by ParseNetemFilter
+func FormatPortRanges(ranges []PortRange) string {
+	items := make([]string, len(ranges))
+	for i, r := range ranges {
+		items[i] = r.String()
+	}
+	return strings.Join(items, ",")
+}
+
+// IP versions (4 or 6) of IP lists; empty list matches any IP version
+func ipVersions(lists ...[]*net.IPNet) []int {
+	var versions []int
+	for _, version := range []int{4, 6} {
+		ok := true
+		for _, ips := range lists {
+			if len(ips) > 0 && len(ipsOfVersion(ips, version)) == 0 {
+				ok = false
+			}
+		}
+		if ok {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+func ipsOfVersion(ips []*net.IPNet, version int) []*net.IPNet {
+	var result []*net.IPNet
+	for _, ip := range ips {
+		if (ip.IP.To4() != nil) == (version == 4) {
+			result = append(result, ip)
+		}
+	}
+	return result
+}
+
+// u32 value and mask, matching aligned block of ports
+type portMask struct {
+	value uint16
+	mask  uint16
+}
+
+// split port range into aligned blocks of ports, matched by u32 value and mask: 8000-8100 is
+// 8000/0xffc0 (8000-8063), 8064/0xffe0 (8064-8095), 8096/0xfffc (8096-8099) and 8100/0xffff
+func portMasks(r PortRange) []portMask {
+	var masks []portMask
+	from, to := uint32(r.From), uint32(r.To)
+	for from <= to {
+		// largest aligned block, starting at from and fitting into range
+		size := uint32(1)
+		for from%(size*2) == 0 && from+size*2-1 <= to {
+			size *= 2
+		}
+		masks = append(masks, portMask{value: uint16(from), mask: uint16(^(size - 1))})
+		from += size
+	}
+	return masks
+}
+
+// u32 filter matches for all ports: single match for every aligned block of ports; nil match if ports are not defined
+func portMatches(match string, field string, ranges []PortRange) [][]string {
+	if len(ranges) == 0 {
+		return [][]string{nil}
+	}
+	var matches [][]string
+	for _, r := range ranges {
+		for _, m := range portMasks(r) {
+			matches = append(matches, []string{"match", match, field, strconv.Itoa(int(m.value)), fmt.Sprintf("0x%x", m.mask)})
+		}
+	}
+	return matches
+}
+
+// u32 filter matches for all IPs; nil match if IPs are not defined
+func ipMatches(match string, field string, ips []*net.IPNet) [][]string {
+	if len(ips) == 0 {
+		return [][]string{nil}
+	}
+	matches := make([][]string, 0, len(ips))
+	for _, ip := range ips {
+		matches = append(matches, []string{"match", match, field, ip.String()})
+	}
+	return matches
+}
+
+// u32 filter arguments ('tc filter add dev <netInterface> ...'), redirecting matching traffic to netem band 3 (class 1:3);
+// u32 matches of single filter are combined with AND, so every combination of IPs and port blocks is separate filter;
+// IPv4 and IPv6 filters use different priorities, since all filters of the same priority must have the same protocol;
+// for ingress traffic (redirected to IFB device) target IP and port are the source ones and source IP and port are destination ones
+func (f NetemFilter) u32Filters(netInterface string, ingress bool) [][]string {
+	dst, src, dport, sport := "dst", "src", "dport", "sport"
+	if ingress {
+		dst, src, dport, sport = src, dst, sport, dport
+	}
+	var filters [][]string
+	for _, version := range ipVersions(f.Targets, f.Sources) {
+		protocol, match, prio := "ip", "ip", "1"
+		if version == 6 {
+			protocol, match, prio = "ipv6", "ip6", "2"
+		}
+		filter := []string{"filter", "add", "dev", netInterface, "protocol", protocol, "parent", "1:0", "prio", prio, "u32"}
+		if numbers, ok := netemProtocols[f.Protocol]; ok {
+			filter = append(filter, "match", match, "protocol", strconv.Itoa(numbers[version/6]), "0xff")
+		}
+		for _, target := range ipMatches(match, dst, ipsOfVersion(f.Targets, version)) {
+			for _, source := range ipMatches(match, src, ipsOfVersion(f.Sources, version)) {
+				for _, port := range portMatches(match, dport, f.Ports) {
+					for _, srcPort := range portMatches(match, sport, f.SrcPorts) {
+						args := append([]string{}, filter...)
+						args = append(args, target...)
+						args = append(args, source...)
+						args = append(args, port...)
+						args = append(args, srcPort...)
+						filters = append(filters, append(args, "flowid", "1:3"))
+					}
+				}
+			}
+		}
+	}
+	return filters
+}
@@ -0,0 +1,297 @@
+package container
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseNetemFilter(t *testing.T) {
+	type args struct {
+		targets  []string
+		sources  []string
+		ports    string
+		srcPorts string
+		protocol string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "empty filter",
+		},
+		{
+			name: "all criteria",
+			args: args{targets: []string{"10.0.0.1", "10.0.1.0/24"}, sources: []string{"10.0.2.1"}, ports: "80, 8000-8100", srcPorts: "5000", protocol: "TCP"},
+			want: "target 10.0.0.1/32, target 10.0.1.0/24, source 10.0.2.1/32, port 80,8000-8100, src-port 5000, protocol tcp",
+		},
+		{
+			name:    "bad target",
+			args:    args{targets: []string{"1.2.3.4.5"}},
+			wantErr: true,
+		},
+		{
+			name:    "bad source",
+			args:    args{sources: []string{"host"}},
+			wantErr: true,
+		},
+		{
+			name:    "bad port",
+			args:    args{ports: "http"},
+			wantErr: true,
+		},
+		{
+			name:    "port out of range",
+			args:    args{ports: "65536"},
+			wantErr: true,
+		},
+		{
+			name:    "reversed port range",
+			args:    args{srcPorts: "8100-8000"},
+			wantErr: true,
+		},
+		{
+			name:    "bad protocol",
+			args:    args{protocol: "sctp"},
+			wantErr: true,
+		},
+		{
+			name:    "icmp with port",
+			args:    args{ports: "80", protocol: "icmp"},
+			wantErr: true,
+		},
+		{
+			name:    "IPv4 target with IPv6 source",
+			args:    args{targets: []string{"10.0.0.1"}, sources: []string{"fd00::1"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNetemFilter(tt.args.targets, tt.args.sources, tt.args.ports, tt.args.srcPorts, tt.args.protocol)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestNetemFilter_u32Filters(t *testing.T) {
+	const ipv4 = "filter add dev eth0 protocol ip parent 1:0 prio 1 u32 "
+	const ipv6 = "filter add dev eth0 protocol ipv6 parent 1:0 prio 2 u32 "
+	type args struct {
+		targets  []string
+		sources  []string
+		ports    string
+		srcPorts string
+		protocol string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		ingress bool
+		want    []string
+	}{
+		{
+			name: "target IP",
+			args: args{targets: []string{"10.0.0.1"}},
+			want: []string{ipv4 + "match ip dst 10.0.0.1/32 flowid 1:3"},
+		},
+		{
+			name: "source CIDR",
+			args: args{sources: []string{"10.0.0.0/16"}},
+			want: []string{ipv4 + "match ip src 10.0.0.0/16 flowid 1:3"},
+		},
+		{
+			name: "target IP and port range",
+			args: args{targets: []string{"10.0.0.1"}, ports: "8000-8100"},
+			want: []string{
+				ipv4 + "match ip dst 10.0.0.1/32 match ip dport 8000 0xffc0 flowid 1:3",
+				ipv4 + "match ip dst 10.0.0.1/32 match ip dport 8064 0xffe0 flowid 1:3",
+				ipv4 + "match ip dst 10.0.0.1/32 match ip dport 8096 0xfffc flowid 1:3",
+				ipv4 + "match ip dst 10.0.0.1/32 match ip dport 8100 0xffff flowid 1:3",
+			},
+		},
+		{
+			name: "multiple target IPs and ports",
+			args: args{targets: []string{"10.0.0.1", "10.0.0.2"}, ports: "80,443"},
+			want: []string{
+				ipv4 + "match ip dst 10.0.0.1/32 match ip dport 80 0xffff flowid 1:3",
+				ipv4 + "match ip dst 10.0.0.1/32 match ip dport 443 0xffff flowid 1:3",
+				ipv4 + "match ip dst 10.0.0.2/32 match ip dport 80 0xffff flowid 1:3",
+				ipv4 + "match ip dst 10.0.0.2/32 match ip dport 443 0xffff flowid 1:3",
+			},
+		},
+		{
+			name: "target IP, source port and protocol",
+			args: args{targets: []string{"10.0.0.1"}, srcPorts: "5000", protocol: "udp"},
+			want: []string{ipv4 + "match ip protocol 17 0xff match ip dst 10.0.0.1/32 match ip sport 5000 0xffff flowid 1:3"},
+		},
+		{
+			name:    "ingress swaps destination and source",
+			args:    args{targets: []string{"10.0.0.1"}, sources: []string{"10.0.0.2"}, ports: "80", srcPorts: "5000", protocol: "tcp"},
+			ingress: true,
+			want:    []string{ipv4 + "match ip protocol 6 0xff match ip src 10.0.0.1/32 match ip dst 10.0.0.2/32 match ip sport 80 0xffff match ip dport 5000 0xffff flowid 1:3"},
+		},
+		{
+			name: "mixed IPv4 and IPv6 targets",
+			args: args{targets: []string{"10.0.0.1", "fd00::1"}, protocol: "icmp"},
+			want: []string{
+				ipv4 + "match ip protocol 1 0xff match ip dst 10.0.0.1/32 flowid 1:3",
+				ipv6 + "match ip6 protocol 58 0xff match ip6 dst fd00::1/128 flowid 1:3",
+			},
+		},
+		{
+			name: "IPv4 and IPv6 targets with IPv6 source",
+			args: args{targets: []string{"10.0.0.1", "fd00::1"}, sources: []string{"fd00::2"}},
+			want: []string{ipv6 + "match ip6 dst fd00::1/128 match ip6 src fd00::2/128 flowid 1:3"},
+		},
+		{
+			name: "port only filters both IP versions",
+			args: args{ports: "53", protocol: "udp"},
+			want: []string{
+				ipv4 + "match ip protocol 17 0xff match ip dport 53 0xffff flowid 1:3",
+				ipv6 + "match ip6 protocol 17 0xff match ip6 dport 53 0xffff flowid 1:3",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseNetemFilter(tt.args.targets, tt.args.sources, tt.args.ports, tt.args.srcPorts, tt.args.protocol)
+			assert.NoError(t, err)
+			var got []string
+			for _, args := range f.u32Filters("eth0", tt.ingress) {
+				got = append(got, strings.Join(args, " "))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_portMasks(t *testing.T) {
+	tests := []struct {
+		name string
+		r    PortRange
+		want []portMask
+	}{
+		{
+			name: "single port",
+			r:    PortRange{From: 80, To: 80},
+			want: []portMask{{value: 80, mask: 0xffff}},
+		},
+		{
+			name: "aligned range",
+			r:    PortRange{From: 1024, To: 2047},
+			want: []portMask{{value: 1024, mask: 0xfc00}},
+		},
+		{
+			name: "all ports",
+			r:    PortRange{From: 0, To: 65535},
+			want: []portMask{{value: 0, mask: 0}},
+		},
+		{
+			name: "unaligned range",
+			r:    PortRange{From: 8000, To: 8100},
+			want: []portMask{{value: 8000, mask: 0xffc0}, {value: 8064, mask: 0xffe0}, {value: 8096, mask: 0xfffc}, {value: 8100, mask: 0xffff}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, portMasks(tt.r))
+		})
+	}
+}
+
+func TestParseNetemFilter_targetContainers(t *testing.T) {
+	f, err := ParseNetemFilter([]string{"10.0.0.1", "container:db", "re2:^cache"}, nil, "", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"db"}, f.TargetNames)
+	assert.Equal(t, []string{"^cache"}, f.TargetPatterns)
+	assert.False(t, f.Empty())
+	assert.Equal(t, "target 10.0.0.1/32, target container:db, target re2:^cache", f.String())
+
+	_, err = ParseNetemFilter([]string{"container:"}, nil, "", "", "")
+	assert.Error(t, err)
+	_, err = ParseNetemFilter([]string{"re2:(cache"}, nil, "", "", "")
+	assert.Error(t, err)
+}
+
+func TestResolveNetemFilter(t *testing.T) {
+	newContainer := func(id, name, ip string) Container {
+		return *NewContainer(ContainerDetailsResponse(AsMap("ID", id, "Name", name, "IPAddress", ip)), ImageDetailsResponse(AsMap()))
+	}
+	c := newContainer("id1", "/app", "172.17.0.2")
+	db := newContainer("id2", "/db", "172.17.0.3")
+	cache := newContainer("id3", "/cache", "172.17.0.4")
+	tests := []struct {
+		name    string
+		targets []string
+		sources []string
+		peers   []Container
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "no target containers",
+			targets: []string{"10.0.0.1"},
+			want:    []string{"10.0.0.1/32"},
+		},
+		{
+			name:    "target containers and IP",
+			targets: []string{"10.0.0.1", "container:db", "re2:^cache"},
+			peers:   []Container{db, cache},
+			want:    []string{"10.0.0.1/32", "172.17.0.3/32", "172.17.0.4/32"},
+		},
+		{
+			name:    "skip netem container",
+			targets: []string{"re2:."},
+			peers:   []Container{c, db},
+			want:    []string{"172.17.0.3/32"},
+		},
+		{
+			name:    "no target containers found",
+			targets: []string{"container:db"},
+			wantErr: true,
+		},
+		{
+			name:    "IPv4 target container with IPv6 source",
+			targets: []string{"container:db"},
+			sources: []string{"fd00::1"},
+			peers:   []Container{db},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &MockClient{}
+			f, err := ParseNetemFilter(tt.targets, tt.sources, "", "", "")
+			assert.NoError(t, err)
+			if f.hasTargetContainers() {
+				client.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter")).Return(tt.peers, nil)
+			}
+			got, err := ResolveNetemFilter(context.TODO(), client, c, f)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Nil(t, got.TargetNames)
+			assert.Nil(t, got.TargetPatterns)
+			var ips []string
+			for _, ip := range got.Targets {
+				ips = append(ips, ip.String())
+			}
+			assert.Equal(t, tt.want, ips)
+			client.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package container
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	dockerapi "github.com/docker/docker/client"
+)
+
+const (
+	// podman socket of rootful Podman API service
+	podmanRootSocket = "unix:///run/podman/podman.sock"
+	// libpod API path of Podman host info; libpod API accepts any version
+	libpodInfoPath = "/v1.0.0/libpod/info"
+)
+
+// PodmanSocket returns default Podman API service socket: rootful or rootless (user runtime dir)
+func PodmanSocket() string {
+	uid := os.Getuid()
+	if uid == 0 {
+		return podmanRootSocket
+	}
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = fmt.Sprintf("/run/user/%d", uid)
+	}
+	return "unix://" + dir + "/podman/podman.sock"
+}
+
+// Podman host info, from libpod API
+type podmanInfo struct {
+	Host struct {
+		CgroupVersion string `json:"cgroupVersion"`
+		Security      struct {
+			Rootless bool `json:"rootless"`
+		} `json:"security"`
+	} `json:"host"`
+}
+
+// NewPodmanClient returns a new Client instance which can be used to interact with
+// the Podman API service: Docker-compatible API for containers and libpod API for host info
+func NewPodmanClient(podmanHost string, tlsConfig *tls.Config) Client {
+	httpClient, err := HTTPClient(podmanHost, tlsConfig)
+	if err != nil {
+		log.Fatalf("Error instantiating Podman client: %s", err)
+	}
+	apiClient, err := dockerapi.NewClient(podmanHost, "", httpClient, nil)
+	if err != nil {
+		log.Fatalf("Error instantiating Podman compatible API client: %s", err)
+	}
+	info, err := getPodmanInfo(context.Background(), httpClient, podmanHost, tlsConfig != nil)
+	if err != nil {
+		log.Fatalf("Error getting Podman info (is 'podman system service' running?): %s", err)
+	}
+	log.WithFields(log.Fields{
+		"rootless": info.Host.Security.Rootless,
+		"cgroups":  info.Host.CgroupVersion,
+	}).Debug("connected to Podman")
+	return newPodmanClient(newInstrumentedClient(apiClient), info)
+}
+
+func newPodmanClient(client dockerClient, info podmanInfo) podmanClient {
+	rootless := info.Host.Security.Rootless
+	// rootless: network namespace of target container is owned by its user namespace
+	client.shareUserns = rootless
+	return podmanClient{dockerClient: client, rootless: rootless, cgroupVersion: info.Host.CgroupVersion}
+}
+
+// get Podman host info with libpod API
+func getPodmanInfo(ctx context.Context, httpClient *http.Client, podmanHost string, useTLS bool) (podmanInfo, error) {
+	var info podmanInfo
+	u, err := url.Parse(podmanHost)
+	if err != nil {
+		return info, err
+	}
+	// unix socket (local or over ssh) is dialed by HTTP client transport: any host name
+	addr := "http://unix.sock" + libpodInfoPath
+	if u.Scheme != "unix" && u.Scheme != "ssh" {
+		scheme := "http"
+		if useTLS {
+			scheme = "https"
+		}
+		addr = scheme + "://" + u.Host + libpodInfoPath
+	}
+	req, err := http.NewRequest(http.MethodGet, addr, nil)
+	if err != nil {
+		return info, err
+	}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return info, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return info, fmt.Errorf("libpod info request failed: %s", resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	return info, err
+}
+
+// Podman client: Docker client, adjusted to Podman differences
+type podmanClient struct {
+	dockerClient
+	rootless      bool
+	cgroupVersion string
+}
+
+// PauseContainer pause container; rootless Podman can freeze containers only with cgroups v2
+func (client podmanClient) PauseContainer(ctx context.Context, c Container, dryrun bool) error {
+	if client.rootless && client.cgroupVersion != "v2" {
+		return fmt.Errorf("cannot pause container %s: rootless Podman requires cgroups v2 to pause containers (cgroups %s)", c.Name(), client.cgroupVersion)
+	}
+	err := client.dockerClient.PauseContainer(ctx, c, dryrun)
+	if err != nil && client.rootless {
+		return fmt.Errorf("failed to pause rootless Podman container %s (is 'freezer' cgroup controller delegated to user?): %s", c.Name(), err)
+	}
+	return err
+}
+
+// StressContainer run stress-ng sidecar in target container PID namespace and cgroup parent, as with Docker;
+// rootless Podman cannot run privileged sidecar in host PID namespace, required to join target container cgroups
+func (client podmanClient) StressContainer(ctx context.Context, c Container, stressors []string, image string, pull bool, hostCgroup bool, dryrun bool) (string, error) {
+	if client.rootless && hostCgroup {
+		return "", fmt.Errorf("cannot stress container %s with host cgroup: rootless Podman cannot run privileged container in host PID namespace", c.Name())
+	}
+	return client.dockerClient.StressContainer(ctx, c, stressors, image, pull, hostCgroup, dryrun)
+}
+
+// ServiceReplicas Podman has no Swarm mode
+func (client podmanClient) ServiceReplicas(ctx context.Context, name string) (uint64, error) {
+	return 0, errors.New("swarm services are not supported by podman runtime")
+}
+
+// ScaleService Podman has no Swarm mode
+func (client podmanClient) ScaleService(ctx context.Context, name string, replicas uint64, dryrun bool) (uint64, error) {
+	return 0, errors.New("swarm services are not supported by podman runtime")
+}
+
+// RestartService Podman has no Swarm mode
+func (client podmanClient) RestartService(ctx context.Context, name string, dryrun bool) error {
+	return errors.New("swarm services are not supported by podman runtime")
+}
@@ -0,0 +1,131 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	types "github.com/docker/docker/api/types"
+	ctypes "github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func rootlessInfo(cgroupVersion string) podmanInfo {
+	var info podmanInfo
+	info.Host.CgroupVersion = cgroupVersion
+	info.Host.Security.Rootless = true
+	return info
+}
+
+func Test_getPodmanInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != libpodInfoPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"host":{"arch":"amd64","cgroupVersion":"v2","security":{"rootless":true,"seccompEnabled":true}}}`))
+	}))
+	defer server.Close()
+
+	httpClient, err := HTTPClient(server.URL, nil)
+	assert.NoError(t, err)
+	info, err := getPodmanInfo(context.TODO(), httpClient, server.URL, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, rootlessInfo("v2"), info)
+}
+
+func Test_getPodmanInfo_Docker(t *testing.T) {
+	// Docker daemon has no libpod API
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	httpClient, err := HTTPClient(server.URL, nil)
+	assert.NoError(t, err)
+	_, err = getPodmanInfo(context.TODO(), httpClient, server.URL, false)
+
+	assert.Error(t, err)
+}
+
+func TestPodmanPauseContainer(t *testing.T) {
+	c := Container{containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123", "Name", "/web"))}
+
+	// rootless with cgroups v1: cannot freeze
+	engineClient := NewMockEngine()
+	client := newPodmanClient(dockerClient{containerAPI: engineClient}, rootlessInfo("v1"))
+	err := client.PauseContainer(context.TODO(), c, false)
+	assert.Error(t, err)
+	engineClient.AssertNotCalled(t, "ContainerPause", mock.Anything, "abc123")
+
+	// rootless with cgroups v2, without delegated freezer
+	engineClient = NewMockEngine()
+	engineClient.On("ContainerPause", mock.Anything, "abc123").Return(errors.New("cgroup freezer: permission denied"))
+	client = newPodmanClient(dockerClient{containerAPI: engineClient}, rootlessInfo("v2"))
+	err = client.PauseContainer(context.TODO(), c, false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "freezer")
+	engineClient.AssertExpectations(t)
+
+	// rootful
+	engineClient = NewMockEngine()
+	engineClient.On("ContainerPause", mock.Anything, "abc123").Return(nil)
+	client = newPodmanClient(dockerClient{containerAPI: engineClient}, podmanInfo{})
+	err = client.PauseContainer(context.TODO(), c, false)
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestPodmanNetContainerConfig(t *testing.T) {
+	c := Container{containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123"))}
+
+	client := newPodmanClient(dockerClient{}, rootlessInfo("v2"))
+	_, hconfig := client.netContainerConfig(c, "tc", []string{"qdisc", "show"}, "pumba/tc")
+	assert.Equal(t, ctypes.UsernsMode("container:abc123"), hconfig.UsernsMode)
+	assert.Equal(t, ctypes.NetworkMode("container:abc123"), hconfig.NetworkMode)
+	assert.Contains(t, hconfig.CapAdd, "NET_ADMIN")
+
+	client = newPodmanClient(dockerClient{}, podmanInfo{})
+	_, hconfig = client.netContainerConfig(c, "tc", []string{"qdisc", "show"}, "pumba/tc")
+	assert.Equal(t, ctypes.UsernsMode(""), hconfig.UsernsMode)
+}
+
+func TestPodmanStressContainer(t *testing.T) {
+	c := Container{containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123", "Name", "c1"))}
+	c.containerInfo.State.Pid = 4242
+
+	// rootless: cannot run privileged sidecar in host PID namespace
+	engineClient := NewMockEngine()
+	client := newPodmanClient(dockerClient{containerAPI: engineClient}, rootlessInfo("v2"))
+	_, err := client.StressContainer(context.TODO(), c, []string{"--cpu", "1"}, "stress", false, true, false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rootless")
+	engineClient.AssertNotCalled(t, "ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// rootless: unprivileged sidecar in target PID namespace
+	engineClient = NewMockEngine()
+	engineClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.MatchedBy(func(hconfig *ctypes.HostConfig) bool {
+		return hconfig.PidMode == "container:abc123" && !hconfig.Privileged
+	}), mock.Anything, "").Return(ctypes.ContainerCreateCreatedBody{ID: "stressID"}, nil)
+	engineClient.On("ContainerStart", mock.Anything, "stressID", types.ContainerStartOptions{}).Return(nil)
+	client = newPodmanClient(dockerClient{containerAPI: engineClient}, rootlessInfo("v2"))
+	id, err := client.StressContainer(context.TODO(), c, []string{"--cpu", "1"}, "stress", false, false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "stressID", id)
+	engineClient.AssertExpectations(t)
+
+	// rootful: Docker stress path, with host cgroup
+	engineClient = NewMockEngine()
+	engineClient.On("ContainerCreate", mock.Anything, mock.Anything, mock.MatchedBy(func(hconfig *ctypes.HostConfig) bool {
+		return hconfig.PidMode == "host" && hconfig.Privileged
+	}), mock.Anything, "").Return(ctypes.ContainerCreateCreatedBody{ID: "stressID"}, nil)
+	engineClient.On("ContainerStart", mock.Anything, "stressID", types.ContainerStartOptions{}).Return(nil)
+	engineClient.On("ContainerRemove", mock.Anything, "stressID", types.ContainerRemoveOptions{Force: true}).Return(nil)
+	client = newPodmanClient(dockerClient{containerAPI: engineClient}, podmanInfo{})
+	id, err = client.StressContainer(context.TODO(), c, []string{"--cpu", "1"}, "stress", false, true, false)
+	assert.NoError(t, err)
+	assert.NoError(t, client.StopStressContainer(context.TODO(), c, id, false))
+	engineClient.AssertExpectations(t)
+}
@@ -0,0 +1,320 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// handle of netem qdisc, nested into pre-existing shaper qdisc
+const nestedNetemHandle = "30:"
+
+// qdisc from 'tc -j qdisc show' output
+type qdisc struct {
+	Kind    string          `json:"kind"`
+	Handle  string          `json:"handle"`
+	Parent  string          `json:"parent,omitempty"`
+	Root    bool            `json:"root,omitempty"`
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// traffic control configuration of network device: qdiscs and filters
+type tcSnapshot struct {
+	qdiscs  []qdisc
+	filters []interface{}
+}
+
+// traffic control state of network device with applied netem: original configuration and netem parent class
+type netemState struct {
+	original tcSnapshot
+	parent   string
+}
+
+// netem states by container and network device; kept from netem start till netem stop
+var netemStates = struct {
+	sync.Mutex
+	m map[string]netemState
+}{m: map[string]netemState{}}
+
+func netemStateKey(c Container, dev string) string {
+	return c.ID() + " " + dev
+}
+
+// snapshot qdiscs and filters of network device: 'tc -j qdisc show dev <dev>' and 'tc -j filter show dev <dev>'
+func (client dockerClient) tcSnapshot(ctx context.Context, c Container, dev string, tcimage string, pull bool) (tcSnapshot, error) {
+	var s tcSnapshot
+	output, err := client.tcCommandOutput(ctx, c, []string{"-j", "qdisc", "show", "dev", dev}, tcimage, pull)
+	if err != nil {
+		return s, err
+	}
+	if err = json.Unmarshal([]byte(output), &s.qdiscs); err != nil {
+		return s, fmt.Errorf("failed to parse qdiscs of %s: %s", dev, err)
+	}
+	output, err = client.tcCommandOutput(ctx, c, []string{"-j", "filter", "show", "dev", dev}, tcimage, pull)
+	if err != nil {
+		return s, err
+	}
+	// no filters: older tc prints nothing instead of empty list
+	if strings.TrimSpace(output) != "" {
+		if err = json.Unmarshal([]byte(output), &s.filters); err != nil {
+			return s, fmt.Errorf("failed to parse filters of %s: %s", dev, err)
+		}
+	}
+	return s, nil
+}
+
+// root qdisc; nil if not found
+func (s tcSnapshot) root() *qdisc {
+	for i := range s.qdiscs {
+		if s.qdiscs[i].Root {
+			return &s.qdiscs[i]
+		}
+	}
+	return nil
+}
+
+// ingress (or clsact) qdisc; nil if not found
+func (s tcSnapshot) ingress() *qdisc {
+	for i := range s.qdiscs {
+		if s.qdiscs[i].Kind == "ingress" || s.qdiscs[i].Kind == "clsact" {
+			return &s.qdiscs[i]
+		}
+	}
+	return nil
+}
+
+// child qdisc of class; nil if not found
+func (s tcSnapshot) child(class string) *qdisc {
+	for i := range s.qdiscs {
+		if s.qdiscs[i].Parent == class {
+			return &s.qdiscs[i]
+		}
+	}
+	return nil
+}
+
+// default root qdisc (noqueue, pfifo_fast, mq, ...) is created by kernel and is restored by kernel, when root qdisc is deleted
+func isDefaultQdisc(q *qdisc) bool {
+	return q == nil || q.Handle == "0:"
+}
+
+// netem parent for new netem: empty for root qdisc or class of pre-existing tbf shaper (netem is nested into it);
+// any other pre-existing configuration is not replaced
+func (s tcSnapshot) netemParent(dev string, direction string, filter NetemFilter) (string, error) {
+	if direction == DirectionIngress || direction == DirectionBoth {
+		if q := s.ingress(); q != nil {
+			return "", fmt.Errorf("network interface %s already has %s qdisc %s: refusing to replace it", dev, q.Kind, q.Handle)
+		}
+	}
+	if direction == DirectionIngress {
+		return "", nil
+	}
+	root := s.root()
+	if isDefaultQdisc(root) {
+		return "", nil
+	}
+	// tbf has single class '<handle>1', where netem can be attached instead of default bfifo
+	class := root.Handle + "1"
+	if root.Kind != "tbf" || !isDefaultQdisc(s.child(class)) {
+		return "", fmt.Errorf("network interface %s already has %s qdisc %s: refusing to replace it", dev, root.Kind, root.Handle)
+	}
+	if !filter.Empty() {
+		return "", fmt.Errorf("network interface %s has %s qdisc %s: traffic filter cannot be nested into it", dev, root.Kind, root.Handle)
+	}
+	return class, nil
+}
+
+// parent of already applied netem: class of tbf shaper, if netem is nested into it, or empty for root qdisc
+func (s tcSnapshot) appliedNetemParent() string {
+	if root := s.root(); root != nil && root.Kind == "tbf" {
+		class := root.Handle + "1"
+		if q := s.child(class); q != nil && q.Kind == "netem" && q.Handle == nestedNetemHandle {
+			return class
+		}
+	}
+	return ""
+}
+
+// snapshot traffic control configuration of network device before applying netem and find netem parent;
+// netem is not applied, if configuration cannot be restored after netem is stopped
+func (client dockerClient) prepareNetem(ctx context.Context, c Container, dev string, direction string, filter NetemFilter, tcimage string, pull bool) (string, error) {
+	s, err := client.tcSnapshot(ctx, c, dev, tcimage, pull)
+	if err != nil {
+		return "", fmt.Errorf("failed to snapshot traffic control configuration of %s in container %s: %s", dev, c.Name(), err)
+	}
+	parent, err := s.netemParent(dev, direction, filter)
+	if err != nil {
+		return "", err
+	}
+	log.WithFields(log.Fields{
+		"name":   c.Name(),
+		"iface":  dev,
+		"qdiscs": len(s.qdiscs),
+		"parent": parent,
+	}).Debug("traffic control configuration snapshot")
+	netemStates.Lock()
+	netemStates.m[netemStateKey(c, dev)] = netemState{original: s, parent: parent}
+	netemStates.Unlock()
+	return parent, nil
+}
+
+// forget netem state of network device, when netem is not applied after snapshot
+func forgetNetem(c Container, dev string) {
+	netemStates.Lock()
+	delete(netemStates.m, netemStateKey(c, dev))
+	netemStates.Unlock()
+}
+
+// parent of applied netem: from netem state or, when unknown (recovery), from current configuration
+func (client dockerClient) netemParent(ctx context.Context, c Container, dev string, tcimage string, pull bool) string {
+	netemStates.Lock()
+	state, ok := netemStates.m[netemStateKey(c, dev)]
+	netemStates.Unlock()
+	if ok {
+		return state.parent
+	}
+	s, err := client.tcSnapshot(ctx, c, dev, tcimage, pull)
+	if err != nil {
+		log.WithError(err).Warn("failed to snapshot traffic control configuration")
+		return ""
+	}
+	return s.appliedNetemParent()
+}
+
+// restore original traffic control configuration of network device after netem is stopped: qdiscs, left by netem,
+// are deleted and deleted pre-existing root qdisc is re-created from snapshot, taken before netem is applied
+func (client dockerClient) restoreOriginal(ctx context.Context, c Container, dev string, tcimage string, pull bool) error {
+	key := netemStateKey(c, dev)
+	netemStates.Lock()
+	state, ok := netemStates.m[key]
+	delete(netemStates.m, key)
+	netemStates.Unlock()
+	if !ok {
+		return nil
+	}
+	s, err := client.tcSnapshot(ctx, c, dev, tcimage, pull)
+	if err != nil {
+		return err
+	}
+	if s.equal(state.original) {
+		return nil
+	}
+	commands, err := state.original.restoreCommands(dev, s)
+	if err != nil {
+		return fmt.Errorf("failed to restore original traffic control configuration of %s in container %s: %s", dev, c.Name(), err)
+	}
+	for _, command := range commands {
+		log.WithField("tc", strings.Join(command, " ")).Debug("restoring traffic control configuration")
+		if err = client.tcCommand(ctx, c, command, tcimage, pull); err != nil {
+			log.WithError(err).Error("failed to execute tc command")
+			return err
+		}
+	}
+	if s, err = client.tcSnapshot(ctx, c, dev, tcimage, pull); err != nil {
+		return err
+	}
+	if !s.equal(state.original) {
+		return fmt.Errorf("failed to restore original traffic control configuration of %s in container %s", dev, c.Name())
+	}
+	return nil
+}
+
+// same qdiscs (options are not compared: re-created qdisc can report them rounded) and same filters
+func (s tcSnapshot) equal(other tcSnapshot) bool {
+	if len(s.qdiscs) != len(other.qdiscs) {
+		return false
+	}
+	for i := range s.qdiscs {
+		if !sameQdisc(&s.qdiscs[i], &other.qdiscs[i]) {
+			return false
+		}
+	}
+	return reflect.DeepEqual(s.filters, other.filters)
+}
+
+// same kind, handle and placement; default qdiscs are created by kernel and are always the same
+func sameQdisc(a, b *qdisc) bool {
+	if isDefaultQdisc(a) || isDefaultQdisc(b) {
+		return isDefaultQdisc(a) && isDefaultQdisc(b)
+	}
+	return a.Kind == b.Kind && a.Handle == b.Handle && a.Parent == b.Parent && a.Root == b.Root
+}
+
+// snapshot has the same qdisc
+func (s tcSnapshot) has(q *qdisc) bool {
+	for i := range s.qdiscs {
+		if sameQdisc(&s.qdiscs[i], q) {
+			return true
+		}
+	}
+	return false
+}
+
+// tc commands, restoring original configuration (snapshot) from current configuration of network device
+func (s tcSnapshot) restoreCommands(dev string, current tcSnapshot) ([][]string, error) {
+	var commands [][]string
+	// 'tc qdisc del dev <dev> ingress': deletes ingress qdisc with its filters
+	if q := current.ingress(); q != nil && s.ingress() == nil {
+		commands = append(commands, []string{"qdisc", "del", "dev", dev, q.Kind})
+	}
+	root, original := current.root(), s.root()
+	if !sameQdisc(root, original) {
+		// 'tc qdisc del dev <dev> root': deletes root qdisc with its children and filters; kernel creates default one
+		if !isDefaultQdisc(root) {
+			commands = append(commands, []string{"qdisc", "del", "dev", dev, "root"})
+		}
+		// 'tc qdisc add dev <dev> root handle <handle> <kind> <options>'
+		if !isDefaultQdisc(original) {
+			args, err := original.args()
+			if err != nil {
+				return nil, err
+			}
+			commands = append(commands, append([]string{"qdisc", "add", "dev", dev, "root", "handle", original.Handle}, args...))
+		}
+		return commands, nil
+	}
+	// same root qdisc: delete its children, left by netem
+	for i := range current.qdiscs {
+		q := &current.qdiscs[i]
+		if q.Root || isDefaultQdisc(q) || q.Kind == "ingress" || q.Kind == "clsact" || s.has(q) {
+			continue
+		}
+		// 'tc qdisc del dev <dev> parent <parent> handle <handle>'
+		commands = append(commands, []string{"qdisc", "del", "dev", dev, "parent", q.Parent, "handle", q.Handle})
+	}
+	return commands, nil
+}
+
+// tbf options from 'tc -j qdisc show' output: rate in bytes per second, burst and limit in bytes, latency in microseconds
+type tbfOptions struct {
+	Rate  uint64   `json:"rate"`
+	Burst uint64   `json:"burst"`
+	Limit *uint64  `json:"limit,omitempty"`
+	Lat   *float64 `json:"lat,omitempty"`
+}
+
+// kind and options of qdisc as tc arguments; only tbf shaper (the only pre-existing qdisc, netem is nested into)
+// can be re-created
+func (q *qdisc) args() ([]string, error) {
+	if q.Kind != "tbf" {
+		return nil, fmt.Errorf("cannot re-create %s qdisc %s", q.Kind, q.Handle)
+	}
+	var o tbfOptions
+	if err := json.Unmarshal(q.Options, &o); err != nil {
+		return nil, fmt.Errorf("failed to parse options of %s qdisc %s: %s", q.Kind, q.Handle, err)
+	}
+	if o.Rate == 0 || o.Burst == 0 || (o.Limit == nil && o.Lat == nil) {
+		return nil, fmt.Errorf("cannot re-create %s qdisc %s: rate, burst and limit or latency are required", q.Kind, q.Handle)
+	}
+	args := []string{q.Kind, "rate", fmt.Sprintf("%dbps", o.Rate), "burst", fmt.Sprintf("%db", o.Burst)}
+	if o.Limit != nil {
+		return append(args, "limit", fmt.Sprintf("%db", *o.Limit)), nil
+	}
+	return append(args, "latency", strconv.FormatFloat(*o.Lat, 'f', -1, 64)+"us"), nil
+}
@@ -0,0 +1,319 @@
+package container
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shinespb/pumba/mocks"
+	"github.com/shinespb/pumba/pkg/util"
+)
+
+// 'tc -j qdisc show' output of network device with default root qdisc
+const defaultQdiscs = `[{"kind":"noqueue","handle":"0:","root":true,"refcnt":2,"options":{}}]`
+
+// 'tc -j qdisc show' output of network device with tbf shaper, configured by CNI bandwidth plugin
+const tbfQdiscs = `[{"kind":"tbf","handle":"1:","root":true,"refcnt":2,"options":{"rate":125000,"burst":1600,"lat":25000}}]`
+
+// expect tc command with output: every call gets its own output stream
+func expectTCOutput(t *testing.T, engineClient *mocks.APIClient, args []string, output string) {
+	expectCommandOutput(t, engineClient, "tc", args, output)
+}
+
+// expect command with output: every call gets its own output stream
+func expectCommandOutput(t *testing.T, engineClient *mocks.APIClient, command string, args []string, output string) {
+	expectExecOutput(t, engineClient, command+" "+strings.Join(args, " "), command, args, output)
+}
+
+// expect command with output, executed with exec id; returns exec create call
+func expectExecOutput(t *testing.T, engineClient *mocks.APIClient, id string, command string, args []string, output string) *mock.Call {
+	config := types.ExecConfig{AttachStdout: true, AttachStderr: true, Cmd: append([]string{command}, args...)}
+	call := engineClient.On("ContainerExecCreate", mock.Anything, "abc123", config).Return(types.IDResponse{ID: id}, nil)
+	engineClient.On("ContainerExecAttach", mock.Anything, id, types.ExecStartCheck{}).Return(
+		func(context.Context, string, types.ExecStartCheck) types.HijackedResponse {
+			conn, _ := net.Pipe()
+			return types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(stdoutStream(t, output))}
+		}, nil)
+	engineClient.On("ContainerExecInspect", mock.Anything, id).Return(types.ContainerExecInspect{}, nil)
+	return call
+}
+
+// expect traffic control configuration snapshot of network device
+func expectTCSnapshot(t *testing.T, engineClient *mocks.APIClient, dev string, qdiscs string) {
+	expectTCOutput(t, engineClient, []string{"-j", "qdisc", "show", "dev", dev}, qdiscs)
+	expectTCOutput(t, engineClient, []string{"-j", "filter", "show", "dev", dev}, "")
+}
+
+// expect traffic control configuration snapshots of network device, taken one after another
+func expectTCSnapshots(t *testing.T, engineClient *mocks.APIClient, dev string, qdiscs ...string) {
+	for i, q := range qdiscs {
+		id := fmt.Sprintf("snapshot %d", i)
+		expectExecOutput(t, engineClient, id+" qdisc", "tc", []string{"-j", "qdisc", "show", "dev", dev}, q).Once()
+		expectExecOutput(t, engineClient, id+" filter", "tc", []string{"-j", "filter", "show", "dev", dev}, "").Once()
+	}
+}
+
+// expect tc commands, executed in target container
+func expectTCCommands(engineClient *mocks.APIClient, commands ...[]string) {
+	checkConfig := types.ExecConfig{Cmd: []string{"which", "tc"}}
+	engineClient.On("ContainerExecCreate", mock.Anything, "abc123", checkConfig).Return(types.IDResponse{ID: "checkID"}, nil)
+	engineClient.On("ContainerExecStart", mock.Anything, "checkID", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", mock.Anything, "checkID").Return(types.ContainerExecInspect{}, nil)
+	for _, cmd := range commands {
+		id := strings.Join(cmd, " ")
+		engineClient.On("ContainerExecCreate", mock.Anything, "abc123", types.ExecConfig{Cmd: cmd, Privileged: true}).Return(types.IDResponse{ID: id}, nil)
+		engineClient.On("ContainerExecStart", mock.Anything, id, types.ExecStartCheck{}).Return(nil)
+		engineClient.On("ContainerExecInspect", mock.Anything, id).Return(types.ContainerExecInspect{}, nil)
+	}
+}
+
+// forget netem states, kept by tests, which do not stop netem
+func resetNetemStates() {
+	netemStates.Lock()
+	netemStates.m = map[string]netemState{}
+	netemStates.Unlock()
+}
+
+func parseSnapshot(t *testing.T, qdiscs string) tcSnapshot {
+	engineClient := NewMockEngine()
+	expectTCSnapshot(t, engineClient, "eth0", qdiscs)
+	client := dockerClient{containerAPI: engineClient}
+	s, err := client.tcSnapshot(context.TODO(), Container{containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123"))}, "eth0", "", false)
+	assert.NoError(t, err)
+	return s
+}
+
+func Test_tcSnapshot_netemParent(t *testing.T) {
+	tests := []struct {
+		name      string
+		qdiscs    string
+		direction string
+		filter    NetemFilter
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "default root qdisc",
+			qdiscs:    defaultQdiscs,
+			direction: DirectionBoth,
+		},
+		{
+			name:      "nest into tbf",
+			qdiscs:    tbfQdiscs,
+			direction: DirectionEgress,
+			want:      "1:1",
+		},
+		{
+			name:      "tbf with traffic filter",
+			qdiscs:    tbfQdiscs,
+			direction: DirectionEgress,
+			filter:    NetemFilter{Targets: []*net.IPNet{util.ParseCIDR("10.0.0.1")}},
+			wantErr:   true,
+		},
+		{
+			name:      "tbf with child qdisc",
+			qdiscs:    `[{"kind":"tbf","handle":"1:","root":true},{"kind":"netem","handle":"30:","parent":"1:1"}]`,
+			direction: DirectionEgress,
+			wantErr:   true,
+		},
+		{
+			name:      "htb root qdisc",
+			qdiscs:    `[{"kind":"htb","handle":"1:","root":true}]`,
+			direction: DirectionEgress,
+			wantErr:   true,
+		},
+		{
+			name:      "ingress only with htb root qdisc",
+			qdiscs:    `[{"kind":"htb","handle":"1:","root":true}]`,
+			direction: DirectionIngress,
+		},
+		{
+			name:      "existing ingress qdisc",
+			qdiscs:    `[{"kind":"noqueue","handle":"0:","root":true},{"kind":"ingress","handle":"ffff:","parent":"ffff:fff1"}]`,
+			direction: DirectionIngress,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSnapshot(t, tt.qdiscs).netemParent("eth0", tt.direction, tt.filter)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_tcSnapshot_appliedNetemParent(t *testing.T) {
+	assert.Equal(t, "", parseSnapshot(t, `[{"kind":"netem","handle":"8001:","root":true}]`).appliedNetemParent())
+	assert.Equal(t, "1:1", parseSnapshot(t, `[{"kind":"tbf","handle":"1:","root":true},{"kind":"netem","handle":"30:","parent":"1:1"}]`).appliedNetemParent())
+}
+
+func TestNetemContainer_NestedIntoTBF(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+	engineClient := NewMockEngine()
+	// same configuration before netem start and after netem stop
+	expectTCSnapshot(t, engineClient, "eth0", tbfQdiscs)
+
+	expectTCCommands(engineClient,
+		[]string{"tc", "qdisc", "add", "dev", "eth0", "parent", "1:1", "handle", "30:", "netem", "delay", "500ms"},
+		[]string{"tc", "qdisc", "change", "dev", "eth0", "parent", "1:1", "handle", "30:", "netem", "delay", "300ms"},
+		[]string{"tc", "qdisc", "del", "dev", "eth0", "parent", "1:1", "handle", "30:"},
+	)
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.NetemContainer(context.TODO(), c, "eth0", DirectionEgress, []string{"delay", "500ms"}, NetemFilter{}, 0, "", false, false)
+	assert.NoError(t, err)
+	err = client.ChangeNetemContainer(context.TODO(), c, "eth0", DirectionEgress, []string{"delay", "300ms"}, NetemFilter{}, "", false, false)
+	assert.NoError(t, err)
+	err = client.StopNetemContainer(context.TODO(), c, "eth0", DirectionEgress, NetemFilter{}, "", false, false)
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+	assert.Empty(t, netemStates.m)
+}
+
+func TestNetemContainer_RefuseExistingQdisc(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+	engineClient := NewMockEngine()
+	expectTCSnapshot(t, engineClient, "eth0", `[{"kind":"htb","handle":"1:","root":true}]`)
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.NetemContainer(context.TODO(), c, "eth0", DirectionEgress, []string{"delay", "500ms"}, NetemFilter{}, 0, "", false, false)
+
+	assert.Error(t, err)
+	engineClient.AssertExpectations(t)
+	assert.Empty(t, netemStates.m)
+}
+
+func TestNetemContainer_SnapshotFailed(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+	engineClient := NewMockEngine()
+	// tc without JSON support
+	expectTCOutput(t, engineClient, []string{"-j", "qdisc", "show", "dev", "eth0"}, "qdisc noqueue 0: root refcnt 2")
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.NetemContainer(context.TODO(), c, "eth0", DirectionEgress, []string{"delay", "500ms"}, NetemFilter{}, 0, "", false, false)
+
+	assert.Error(t, err)
+	engineClient.AssertExpectations(t)
+	assert.Empty(t, netemStates.m)
+}
+
+func TestStopNetemContainer_RestoreOriginal(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+	defer resetNetemStates()
+	netemStates.m[netemStateKey(c, "eth0")] = netemState{original: parseSnapshot(t, tbfQdiscs)}
+	engineClient := NewMockEngine()
+	// original tbf is gone after netem stop and is re-created
+	expectTCSnapshots(t, engineClient, "eth0", defaultQdiscs, tbfQdiscs)
+	expectTCCommands(engineClient,
+		[]string{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"},
+		[]string{"tc", "qdisc", "add", "dev", "eth0", "root", "handle", "1:", "tbf", "rate", "125000bps", "burst", "1600b", "latency", "25000us"},
+	)
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.StopNetemContainer(context.TODO(), c, "eth0", DirectionEgress, NetemFilter{}, "", false, false)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+	assert.Empty(t, netemStates.m)
+}
+
+func TestStopNetemContainer_NotRestored(t *testing.T) {
+	c := Container{
+		containerInfo: ContainerDetailsResponse(AsMap("ID", "abc123")),
+	}
+	defer resetNetemStates()
+	netemStates.m[netemStateKey(c, "eth0")] = netemState{original: parseSnapshot(t, tbfQdiscs)}
+	engineClient := NewMockEngine()
+	// re-created tbf is gone again
+	expectTCSnapshots(t, engineClient, "eth0", defaultQdiscs, defaultQdiscs)
+	expectTCCommands(engineClient,
+		[]string{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"},
+		[]string{"tc", "qdisc", "add", "dev", "eth0", "root", "handle", "1:", "tbf", "rate", "125000bps", "burst", "1600b", "latency", "25000us"},
+	)
+
+	client := dockerClient{containerAPI: engineClient}
+	err := client.StopNetemContainer(context.TODO(), c, "eth0", DirectionEgress, NetemFilter{}, "", false, false)
+
+	assert.Error(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func Test_tcSnapshot_restoreCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		current  string
+		want     [][]string
+		wantErr  bool
+	}{
+		{
+			name:     "restored",
+			original: tbfQdiscs,
+			current:  tbfQdiscs,
+		},
+		{
+			name:     "netem root left",
+			original: defaultQdiscs,
+			current:  `[{"kind":"netem","handle":"8001:","root":true}]`,
+			want:     [][]string{{"qdisc", "del", "dev", "eth0", "root"}},
+		},
+		{
+			name:     "netem nested into tbf left",
+			original: tbfQdiscs,
+			current:  `[{"kind":"tbf","handle":"1:","root":true},{"kind":"netem","handle":"30:","parent":"1:1"}]`,
+			want:     [][]string{{"qdisc", "del", "dev", "eth0", "parent", "1:1", "handle", "30:"}},
+		},
+		{
+			name:     "ingress left",
+			original: defaultQdiscs,
+			current:  `[{"kind":"noqueue","handle":"0:","root":true},{"kind":"ingress","handle":"ffff:","parent":"ffff:fff1"}]`,
+			want:     [][]string{{"qdisc", "del", "dev", "eth0", "ingress"}},
+		},
+		{
+			name:     "tbf replaced",
+			original: `[{"kind":"tbf","handle":"1:","root":true,"options":{"rate":125000,"burst":1600,"limit":3200}}]`,
+			current:  `[{"kind":"prio","handle":"1:","root":true}]`,
+			want: [][]string{
+				{"qdisc", "del", "dev", "eth0", "root"},
+				{"qdisc", "add", "dev", "eth0", "root", "handle", "1:", "tbf", "rate", "125000bps", "burst", "1600b", "limit", "3200b"},
+			},
+		},
+		{
+			name:     "htb deleted",
+			original: `[{"kind":"htb","handle":"1:","root":true}]`,
+			current:  defaultQdiscs,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSnapshot(t, tt.original).restoreCommands("eth0", parseSnapshot(t, tt.current))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
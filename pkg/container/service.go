@@ -0,0 +1,124 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	types "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// poll interval of Swarm service rolling update status
+var serviceUpdatePollInterval = time.Second
+
+// ServiceReplicas get number of replicas of Docker Swarm replicated service
+func (client dockerClient) ServiceReplicas(ctx context.Context, name string) (uint64, error) {
+	service, err := client.inspectReplicatedService(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	return *service.Spec.Mode.Replicated.Replicas, nil
+}
+
+// ScaleService set number of replicas of Docker Swarm replicated service; returns previous number of replicas
+func (client dockerClient) ScaleService(ctx context.Context, name string, replicas uint64, dryrun bool) (uint64, error) {
+	service, err := client.inspectReplicatedService(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	mode := service.Spec.Mode.Replicated
+	previous := *mode.Replicas
+	log.WithFields(log.Fields{
+		"service":  name,
+		"id":       service.ID,
+		"previous": previous,
+		"replicas": replicas,
+		"dryrun":   dryrun,
+	}).Info("scaling service")
+	if !dryrun {
+		mode.Replicas = &replicas
+		if err = client.updateService(ctx, service); err != nil {
+			return 0, err
+		}
+	}
+	return previous, nil
+}
+
+// RestartService force rolling update (restart) of all tasks of Docker Swarm service and wait for it to complete
+func (client dockerClient) RestartService(ctx context.Context, name string, dryrun bool) error {
+	service, _, err := client.serviceAPI.ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+	if err != nil {
+		log.WithError(err).WithField("service", name).Error("failed to inspect service")
+		return err
+	}
+	log.WithFields(log.Fields{
+		"service": name,
+		"id":      service.ID,
+		"dryrun":  dryrun,
+	}).Info("restarting service tasks")
+	if dryrun {
+		return nil
+	}
+	// same as 'docker service update --force'
+	service.Spec.TaskTemplate.ForceUpdate++
+	if err = client.updateService(ctx, service); err != nil {
+		return err
+	}
+	return client.waitForServiceUpdate(ctx, service.ID)
+}
+
+// inspect Docker Swarm service, which must be a replicated service
+func (client dockerClient) inspectReplicatedService(ctx context.Context, name string) (swarm.Service, error) {
+	service, _, err := client.serviceAPI.ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+	if err != nil {
+		log.WithError(err).WithField("service", name).Error("failed to inspect service")
+		return service, err
+	}
+	if mode := service.Spec.Mode.Replicated; mode == nil || mode.Replicas == nil {
+		return service, fmt.Errorf("cannot scale service %s: not a replicated service", name)
+	}
+	return service, nil
+}
+
+func (client dockerClient) updateService(ctx context.Context, service swarm.Service) error {
+	resp, err := client.serviceAPI.ServiceUpdate(ctx, service.ID, service.Version, service.Spec, types.ServiceUpdateOptions{})
+	if err != nil {
+		log.WithError(err).WithField("service", service.Spec.Name).Error("failed to update service")
+		return err
+	}
+	for _, warning := range resp.Warnings {
+		log.WithField("service", service.Spec.Name).Warn(warning)
+	}
+	return nil
+}
+
+// wait for rolling update of service to complete; paused or rolled back update is an error
+func (client dockerClient) waitForServiceUpdate(ctx context.Context, id string) error {
+	for {
+		service, _, err := client.serviceAPI.ServiceInspectWithRaw(ctx, id, types.ServiceInspectOptions{})
+		if err != nil {
+			return err
+		}
+		// update status is reset by service update and set by Swarm updater, once it starts
+		if status := service.UpdateStatus; status != nil {
+			log.WithFields(log.Fields{
+				"service": service.Spec.Name,
+				"state":   status.State,
+			}).Debug("service update status")
+			switch status.State {
+			case swarm.UpdateStateCompleted:
+				return nil
+			case swarm.UpdateStatePaused, swarm.UpdateStateRollbackStarted, swarm.UpdateStateRollbackPaused, swarm.UpdateStateRollbackCompleted:
+				return fmt.Errorf("rolling update of service %s is %s: %s", service.Spec.Name, status.State, status.Message)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(serviceUpdatePollInterval):
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testService(replicas *uint64) swarm.Service {
+	service := swarm.Service{ID: "svc1"}
+	service.Version.Index = 10
+	service.Spec.Name = "web"
+	if replicas != nil {
+		service.Spec.Mode.Replicated = &swarm.ReplicatedService{Replicas: replicas}
+	} else {
+		service.Spec.Mode.Global = &swarm.GlobalService{}
+	}
+	return service
+}
+
+func TestScaleService(t *testing.T) {
+	replicas := uint64(3)
+	engineClient := NewMockEngine()
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "web", types.ServiceInspectOptions{}).Return(testService(&replicas), nil, nil)
+	engineClient.On("ServiceUpdate", mock.Anything, "svc1", swarm.Version{Index: 10}, mock.MatchedBy(func(spec swarm.ServiceSpec) bool {
+		return *spec.Mode.Replicated.Replicas == 1
+	}), types.ServiceUpdateOptions{}).Return(types.ServiceUpdateResponse{}, nil)
+	client := dockerClient{serviceAPI: engineClient}
+
+	previous, err := client.ScaleService(context.TODO(), "web", 1, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), previous)
+	engineClient.AssertExpectations(t)
+}
+
+func TestScaleService_DryRun(t *testing.T) {
+	replicas := uint64(2)
+	engineClient := NewMockEngine()
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "web", types.ServiceInspectOptions{}).Return(testService(&replicas), nil, nil)
+	client := dockerClient{serviceAPI: engineClient}
+
+	previous, err := client.ScaleService(context.TODO(), "web", 0, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), previous)
+	engineClient.AssertNotCalled(t, "ServiceUpdate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestScaleService_Global(t *testing.T) {
+	engineClient := NewMockEngine()
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "web", types.ServiceInspectOptions{}).Return(testService(nil), nil, nil)
+	client := dockerClient{serviceAPI: engineClient}
+
+	_, err := client.ScaleService(context.TODO(), "web", 0, false)
+
+	assert.EqualError(t, err, "cannot scale service web: not a replicated service")
+}
+
+func TestServiceReplicas(t *testing.T) {
+	replicas := uint64(3)
+	engineClient := NewMockEngine()
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "web", types.ServiceInspectOptions{}).Return(testService(&replicas), nil, nil)
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "global", types.ServiceInspectOptions{}).Return(testService(nil), nil, nil)
+	client := dockerClient{serviceAPI: engineClient}
+
+	got, err := client.ServiceReplicas(context.TODO(), "web")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), got)
+	_, err = client.ServiceReplicas(context.TODO(), "global")
+	assert.EqualError(t, err, "cannot scale service global: not a replicated service")
+	engineClient.AssertNotCalled(t, "ServiceUpdate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRestartService(t *testing.T) {
+	defer func(interval time.Duration) { serviceUpdatePollInterval = interval }(serviceUpdatePollInterval)
+	serviceUpdatePollInterval = time.Millisecond
+	replicas := uint64(2)
+	pending := testService(&replicas)
+	updating := testService(&replicas)
+	updating.UpdateStatus = &swarm.UpdateStatus{State: swarm.UpdateStateUpdating}
+	completed := testService(&replicas)
+	completed.UpdateStatus = &swarm.UpdateStatus{State: swarm.UpdateStateCompleted}
+
+	engineClient := NewMockEngine()
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "web", types.ServiceInspectOptions{}).Return(testService(&replicas), nil, nil)
+	engineClient.On("ServiceUpdate", mock.Anything, "svc1", swarm.Version{Index: 10}, mock.MatchedBy(func(spec swarm.ServiceSpec) bool {
+		return spec.TaskTemplate.ForceUpdate == 1
+	}), types.ServiceUpdateOptions{}).Return(types.ServiceUpdateResponse{Warnings: []string{"image not pinned"}}, nil)
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "svc1", types.ServiceInspectOptions{}).Return(pending, nil, nil).Once()
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "svc1", types.ServiceInspectOptions{}).Return(updating, nil, nil).Once()
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "svc1", types.ServiceInspectOptions{}).Return(completed, nil, nil).Once()
+	client := dockerClient{serviceAPI: engineClient}
+
+	err := client.RestartService(context.TODO(), "web", false)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestRestartService_RolledBack(t *testing.T) {
+	replicas := uint64(2)
+	rolledBack := testService(&replicas)
+	rolledBack.UpdateStatus = &swarm.UpdateStatus{State: swarm.UpdateStateRollbackCompleted, Message: "update failed"}
+
+	engineClient := NewMockEngine()
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "web", types.ServiceInspectOptions{}).Return(testService(&replicas), nil, nil)
+	engineClient.On("ServiceUpdate", mock.Anything, "svc1", mock.Anything, mock.Anything, mock.Anything).Return(types.ServiceUpdateResponse{}, nil)
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "svc1", types.ServiceInspectOptions{}).Return(rolledBack, nil, nil)
+	client := dockerClient{serviceAPI: engineClient}
+
+	err := client.RestartService(context.TODO(), "web", false)
+
+	assert.EqualError(t, err, "rolling update of service web is rollback_completed: update failed")
+}
+
+func TestRestartService_InspectError(t *testing.T) {
+	engineClient := NewMockEngine()
+	engineClient.On("ServiceInspectWithRaw", mock.Anything, "web", types.ServiceInspectOptions{}).Return(swarm.Service{}, nil, errors.New("This node is not a swarm manager"))
+	client := dockerClient{serviceAPI: engineClient}
+
+	assert.Error(t, client.RestartService(context.TODO(), "web", false))
+	engineClient.AssertNotCalled(t, "ServiceUpdate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
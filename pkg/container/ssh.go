@@ -0,0 +1,182 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultSSHPort = "22"
+	// Docker daemon socket on remote host
+	defaultRemoteSocket = "/var/run/docker.sock"
+)
+
+// private keys, tried after ssh-agent keys (as ssh does)
+var sshKeyFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// sshDialer dials Docker daemon socket on remote host, through single (shared) SSH connection;
+// SSH connection is authenticated with ssh-agent and user private keys, and host key is verified with user known_hosts
+type sshDialer struct {
+	user    string
+	addr    string
+	socket  string
+	sshDir  string
+	timeout time.Duration
+	mu      sync.Mutex
+	client  *ssh.Client
+}
+
+// create dialer from 'ssh://[user@]host[:port][/socket]' Docker host URL
+func newSSHDialer(u *url.URL, timeout time.Duration) (*sshDialer, error) {
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("bad ssh Docker host '%s': host is required", u.String())
+	}
+	d := &sshDialer{socket: defaultRemoteSocket, timeout: timeout}
+	if u.Path != "" && u.Path != "/" {
+		d.socket = u.Path
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultSSHPort
+	}
+	d.addr = net.JoinHostPort(u.Hostname(), port)
+	if u.User != nil {
+		d.user = u.User.Username()
+	}
+	if d.user == "" {
+		current, err := user.Current()
+		if err != nil {
+			return nil, err
+		}
+		d.user = current.Username
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	d.sshDir = filepath.Join(home, ".ssh")
+	return d, nil
+}
+
+// DialContext connect to remote Docker daemon socket; broken SSH connection is reconnected once
+func (d *sshDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := d.sshClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.Dial("unix", d.socket)
+	if err == nil {
+		return conn, nil
+	}
+	log.WithError(err).WithField("host", d.addr).Debug("failed to dial Docker socket, reconnecting")
+	d.reset(client)
+	if client, err = d.sshClient(ctx); err != nil {
+		return nil, err
+	}
+	if conn, err = client.Dial("unix", d.socket); err != nil {
+		return nil, fmt.Errorf("failed to dial Docker socket %s on %s: %s", d.socket, d.addr, err)
+	}
+	return conn, nil
+}
+
+// get shared SSH connection; connect if not connected
+func (d *sshDialer) sshClient(ctx context.Context) (*ssh.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client != nil {
+		return d.client, nil
+	}
+	client, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.client = client
+	return client, nil
+}
+
+// drop broken SSH connection
+func (d *sshDialer) reset(client *ssh.Client) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client == client {
+		d.client.Close()
+		d.client = nil
+	}
+}
+
+func (d *sshDialer) connect(ctx context.Context) (*ssh.Client, error) {
+	hostKeyCallback, err := knownhosts.New(filepath.Join(d.sshDir, "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ssh known hosts: %s", err)
+	}
+	signers := d.keySigners()
+	// ssh-agent signs with agent connection: keep it open during handshake
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			defer conn.Close()
+			if agentSigners, err := agent.NewClient(conn).Signers(); err == nil {
+				signers = append(agentSigners, signers...)
+			}
+		} else {
+			log.WithError(err).Debug("failed to connect to ssh-agent")
+		}
+	}
+	if len(signers) == 0 {
+		return nil, errors.New("no ssh keys: start ssh-agent with keys or create private key in ~/.ssh")
+	}
+	config := &ssh.ClientConfig{
+		User:            d.user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         d.timeout,
+	}
+	log.WithFields(log.Fields{
+		"host":   d.addr,
+		"user":   d.user,
+		"socket": d.socket,
+	}).Debug("connecting to Docker host over ssh")
+	dialer := net.Dialer{Timeout: d.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", d.addr)
+	if err != nil {
+		return nil, err
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, d.addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh connection to %s failed: %s", d.addr, err)
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// signers of unencrypted user private keys; passphrase protected keys must be added to ssh-agent
+func (d *sshDialer) keySigners() []ssh.Signer {
+	var signers []ssh.Signer
+	for _, name := range sshKeyFiles {
+		path := filepath.Join(d.sshDir, name)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			log.WithError(err).WithField("key", path).Debug("skipping ssh private key")
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	return signers
+}
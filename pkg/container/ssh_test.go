@@ -0,0 +1,205 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	dockerapi "github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ssh test environment: user home with private key and known_hosts, and ssh server forwarding unix sockets
+type sshTestEnv struct {
+	home    string
+	addr    string
+	hostKey ssh.PublicKey
+}
+
+func newSSHTestEnv(t *testing.T) (*sshTestEnv, func()) {
+	home, err := ioutil.TempDir("", "ssh")
+	assert.NoError(t, err)
+	assert.NoError(t, os.Mkdir(filepath.Join(home, ".ssh"), 0700))
+	// user private key
+	userKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(userKey)})
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(home, ".ssh", "id_rsa"), keyPEM, 0600))
+	userPub, err := ssh.NewPublicKey(&userKey.PublicKey)
+	assert.NoError(t, err)
+	// ssh server
+	hostKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	assert.NoError(t, err)
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "pumba" && bytes.Equal(key.Marshal(), userPub.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key of %s", conn.User())
+		},
+	}
+	config.AddHostKey(hostSigner)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go serveSSH(listener, config)
+	// no ssh-agent keys
+	authSock, hasAuthSock := os.LookupEnv("SSH_AUTH_SOCK")
+	os.Unsetenv("SSH_AUTH_SOCK")
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	return &sshTestEnv{home: home, addr: listener.Addr().String(), hostKey: hostSigner.PublicKey()}, func() {
+		listener.Close()
+		os.Setenv("HOME", oldHome)
+		if hasAuthSock {
+			os.Setenv("SSH_AUTH_SOCK", authSock)
+		}
+		os.RemoveAll(home)
+	}
+}
+
+// trust ssh server host key
+func (env *sshTestEnv) trustHost(t *testing.T) {
+	line := knownhosts.Line([]string{knownhosts.Normalize(env.addr)}, env.hostKey)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(env.home, ".ssh", "known_hosts"), []byte(line+"\n"), 0600))
+}
+
+// forward 'direct-streamlocal' channels to local unix sockets
+func serveSSH(listener net.Listener, config *ssh.ServerConfig) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			_, chans, reqs, err := ssh.NewServerConn(conn, config)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			for newChannel := range chans {
+				var msg struct {
+					SocketPath string
+					Reserved0  string
+					Reserved1  uint32
+				}
+				if newChannel.ChannelType() != "direct-streamlocal@openssh.com" || ssh.Unmarshal(newChannel.ExtraData(), &msg) != nil {
+					newChannel.Reject(ssh.UnknownChannelType, "unsupported channel")
+					continue
+				}
+				socket, err := net.Dial("unix", msg.SocketPath)
+				if err != nil {
+					newChannel.Reject(ssh.ConnectionFailed, err.Error())
+					continue
+				}
+				channel, requests, err := newChannel.Accept()
+				if err != nil {
+					socket.Close()
+					continue
+				}
+				go ssh.DiscardRequests(requests)
+				go func() {
+					io.Copy(channel, socket)
+					channel.CloseWrite()
+				}()
+				go func() {
+					io.Copy(socket, channel)
+					socket.Close()
+				}()
+			}
+		}()
+	}
+}
+
+// fake Docker daemon on unix socket
+func startDockerSocket(t *testing.T, dir string) (string, func()) {
+	socket := filepath.Join(dir, "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"Id":"abc123","Names":["/web"]}]`)
+	}))
+	server.Listener = listener
+	server.Start()
+	return socket, server.Close
+}
+
+func TestHTTPClient_SSH(t *testing.T) {
+	env, cleanup := newSSHTestEnv(t)
+	defer cleanup()
+	env.trustHost(t)
+	socket, stop := startDockerSocket(t, env.home)
+	defer stop()
+	host := "ssh://pumba@" + env.addr + socket
+
+	httpClient, err := HTTPClient(host, nil)
+	assert.NoError(t, err)
+	apiClient, err := dockerapi.NewClient(host, "", httpClient, nil)
+	assert.NoError(t, err)
+	containers, err := apiClient.ContainerList(context.TODO(), types.ContainerListOptions{})
+
+	assert.NoError(t, err)
+	if assert.Len(t, containers, 1) {
+		assert.Equal(t, "abc123", containers[0].ID)
+	}
+	// hijacked connections are dialed over ssh too
+	conn, err := apiClient.Dialer()(context.TODO())
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+}
+
+func TestHTTPClient_SSHUnknownHost(t *testing.T) {
+	env, cleanup := newSSHTestEnv(t)
+	defer cleanup()
+	// known_hosts without server key
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(env.home, ".ssh", "known_hosts"), nil, 0600))
+
+	httpClient, err := HTTPClient("ssh://pumba@"+env.addr, nil)
+	assert.NoError(t, err)
+	_, err = httpClient.Get("http://docker/_ping")
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "knownhosts: key is unknown")
+	}
+}
+
+func TestNewSSHDialer(t *testing.T) {
+	u, _ := url.Parse("ssh://deploy@build1.example.com")
+	d, err := newSSHDialer(u, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "deploy", d.user)
+	assert.Equal(t, "build1.example.com:22", d.addr)
+	assert.Equal(t, "/var/run/docker.sock", d.socket)
+
+	u, _ = url.Parse("ssh://build1.example.com:2222/run/user/1000/docker.sock")
+	d, err = newSSHDialer(u, time.Second)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, d.user)
+	assert.Equal(t, "build1.example.com:2222", d.addr)
+	assert.Equal(t, "/run/user/1000/docker.sock", d.socket)
+
+	u, _ = url.Parse("ssh:///var/run/docker.sock")
+	_, err = newSSHDialer(u, time.Second)
+	assert.Error(t, err)
+}
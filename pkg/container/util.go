@@ -4,6 +4,7 @@ import (
 	"context"
 	"math/rand"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -53,7 +54,69 @@ func RegexContainerFilter(pattern string) Filter {
 	}
 }
 
-func ListContainers(ctx context.Context, client Client, names []string, pattern string, all bool) ([]Container, error) {
+// LabelFilter filter containers by labels; each label selector is one of
+// 'key=value', 'key!=value' or 'key' (label exists); all selectors must match
+func LabelFilter(labels []string) Filter {
+	return func(c Container) bool {
+		if c.IsPumba() || c.IsPumbaSkip() {
+			return false
+		}
+		containerLabels := c.Labels()
+		for _, label := range labels {
+			// split on first operator: value may contain '=' and '!='
+			i := strings.Index(label, "=")
+			switch {
+			case i < 0:
+				if _, ok := containerLabels[label]; !ok {
+					return false
+				}
+			case i > 0 && label[i-1] == '!':
+				if val, ok := containerLabels[label[:i-1]]; ok && val == label[i+1:] {
+					return false
+				}
+			default:
+				if val, ok := containerLabels[label[:i]]; !ok || val != label[i+1:] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+}
+
+// ComposeLabels convert Docker Compose project and/or service names into label selectors
+func ComposeLabels(project string, service string) []string {
+	labels := []string{}
+	if project != "" {
+		labels = append(labels, composeProjectLabel+"="+project)
+	}
+	if service != "" {
+		labels = append(labels, composeServiceLabel+"="+service)
+	}
+	return labels
+}
+
+// SwarmServiceLabels label selector for containers of Docker Swarm service tasks; empty if service is not set
+func SwarmServiceLabels(service string) []string {
+	if service == "" {
+		return []string{}
+	}
+	return []string{swarmServiceLabel + "=" + service}
+}
+
+// AndFilter combine filters: container must match all of them
+func AndFilter(filters ...Filter) Filter {
+	return func(c Container) bool {
+		for _, filter := range filters {
+			if !filter(c) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func ListContainers(ctx context.Context, client Client, names []string, pattern string, labels []string, all bool) ([]Container, error) {
 	var filter Filter
 
 	if pattern != "" {
@@ -61,6 +124,9 @@ func ListContainers(ctx context.Context, client Client, names []string, pattern
 	} else {
 		filter = ContainerFilter(names)
 	}
+	if len(labels) > 0 {
+		filter = AndFilter(filter, LabelFilter(labels))
+	}
 
 	if all {
 		return client.ListAllContainers(ctx, filter)
@@ -77,23 +143,52 @@ func RandomContainer(containers []Container) *Container {
 	return nil
 }
 
-func ListRunningContainers(ctx context.Context, client Client, names []string, pattern string) ([]Container, error) {
-	return ListContainers(ctx, client, names, pattern, false)
+func ListRunningContainers(ctx context.Context, client Client, names []string, pattern string, labels []string) ([]Container, error) {
+	return ListContainers(ctx, client, names, pattern, labels, false)
 }
 
-func ListNContainers(ctx context.Context, client Client, names []string, pattern string, limit int) ([]Container, error) {
-	containers, err := ListRunningContainers(ctx, client, names, pattern)
+// ListNContainers list up to limit random running containers; if perService is set,
+// the limit is applied to every Docker Compose service separately
+func ListNContainers(ctx context.Context, client Client, names []string, pattern string, labels []string, limit int, perService bool) ([]Container, error) {
+	containers, err := ListRunningContainers(ctx, client, names, pattern, labels)
 	if err != nil {
 		return nil, err
 	}
 
+	if perService && limit > 0 {
+		shuffle(containers)
+		return limitPerService(containers, limit), nil
+	}
+
 	if len(containers) > limit && limit > 0 {
-		for i := range containers {
-			j := rand.Intn(i + 1)
-			containers[i], containers[j] = containers[j], containers[i]
-		}
+		shuffle(containers)
 		return containers[0:limit], nil
 	}
 
 	return containers, nil
 }
+
+func shuffle(containers []Container) {
+	for i := range containers {
+		j := rand.Intn(i + 1)
+		containers[i], containers[j] = containers[j], containers[i]
+	}
+}
+
+// keep up to limit containers of each Docker Compose (or Swarm) service;
+// containers not created by Docker Compose or Swarm are treated as a single service
+func limitPerService(containers []Container, limit int) []Container {
+	count := map[string]int{}
+	limited := []Container{}
+	for _, c := range containers {
+		service := c.ComposeProject() + "/" + c.ComposeService()
+		if swarm := c.SwarmService(); swarm != "" {
+			service = "swarm/" + swarm
+		}
+		if count[service] < limit {
+			count[service]++
+			limited = append(limited, c)
+		}
+	}
+	return limited
+}
@@ -0,0 +1,101 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func containerWithLabels(name string, labels map[string]string) Container {
+	return Container{
+		containerInfo: ContainerDetailsResponse(AsMap("Name", name, "Labels", labels)),
+	}
+}
+
+func TestLabelFilter(t *testing.T) {
+	c := containerWithLabels("c1", map[string]string{"app": "web", "tier": "frontend", "expr": "a!=b", "neg": "x=y"})
+	tests := []struct {
+		name   string
+		labels []string
+		want   bool
+	}{
+		{name: "no labels", labels: []string{}, want: true},
+		{name: "equal match", labels: []string{"app=web"}, want: true},
+		{name: "equal mismatch", labels: []string{"app=db"}, want: false},
+		{name: "equal missing label", labels: []string{"env=prod"}, want: false},
+		{name: "not equal match", labels: []string{"app!=db"}, want: true},
+		{name: "not equal mismatch", labels: []string{"app!=web"}, want: false},
+		{name: "not equal missing label", labels: []string{"env!=prod"}, want: true},
+		{name: "label exists", labels: []string{"tier"}, want: true},
+		{name: "label does not exist", labels: []string{"env"}, want: false},
+		{name: "all labels match", labels: []string{"app=web", "tier!=backend", "tier"}, want: true},
+		{name: "one label does not match", labels: []string{"app=web", "tier=backend"}, want: false},
+		{name: "equal value with not equal operator", labels: []string{"expr=a!=b"}, want: true},
+		{name: "not equal value with equal operator", labels: []string{"neg!=x=y"}, want: false},
+		{name: "not equal other value with equal operator", labels: []string{"neg!=x=z"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, LabelFilter(tt.labels)(c))
+		})
+	}
+}
+
+func TestLabelFilter_SkipPumba(t *testing.T) {
+	c := containerWithLabels("pumba", map[string]string{"app": "web", pumbaLabel: "true"})
+
+	assert.False(t, LabelFilter([]string{"app=web"})(c))
+}
+
+func TestAndFilter(t *testing.T) {
+	c := containerWithLabels("/c1", map[string]string{"app": "web"})
+
+	assert.True(t, AndFilter(ContainerFilter([]string{"c1"}), LabelFilter([]string{"app=web"}))(c))
+	assert.False(t, AndFilter(ContainerFilter([]string{"c2"}), LabelFilter([]string{"app=web"}))(c))
+	assert.False(t, AndFilter(RegexContainerFilter("^c"), LabelFilter([]string{"app=db"}))(c))
+}
+
+func TestComposeLabels(t *testing.T) {
+	assert.Equal(t, []string{}, ComposeLabels("", ""))
+	assert.Equal(t, []string{"com.docker.compose.project=proj"}, ComposeLabels("proj", ""))
+	assert.Equal(t, []string{"com.docker.compose.service=web"}, ComposeLabels("", "web"))
+	assert.Equal(t, []string{"com.docker.compose.project=proj", "com.docker.compose.service=web"}, ComposeLabels("proj", "web"))
+}
+
+func TestLimitPerService(t *testing.T) {
+	compose := func(name, project, service string) Container {
+		return containerWithLabels(name, map[string]string{composeProjectLabel: project, composeServiceLabel: service})
+	}
+	containers := []Container{
+		compose("proj_web_1", "proj", "web"),
+		compose("proj_web_2", "proj", "web"),
+		compose("proj_db_1", "proj", "db"),
+		compose("other_web_1", "other", "web"),
+		containerWithLabels("plain1", map[string]string{}),
+		containerWithLabels("plain2", map[string]string{}),
+	}
+
+	limited := limitPerService(containers, 1)
+
+	assert.Equal(t, []Container{containers[0], containers[2], containers[3], containers[4]}, limited)
+	assert.Equal(t, containers, limitPerService(containers, 2))
+}
+
+func TestSwarmServiceLabels(t *testing.T) {
+	assert.Equal(t, []string{}, SwarmServiceLabels(""))
+	assert.Equal(t, []string{"com.docker.swarm.service.name=web"}, SwarmServiceLabels("web"))
+}
+
+func TestLimitPerService_Swarm(t *testing.T) {
+	task := func(name, service string) Container {
+		return containerWithLabels(name, map[string]string{swarmServiceLabel: service})
+	}
+	containers := []Container{
+		task("web.1.abc", "web"),
+		task("web.2.def", "web"),
+		task("db.1.ghi", "db"),
+		containerWithLabels("plain1", map[string]string{}),
+	}
+
+	assert.Equal(t, []Container{containers[0], containers[2], containers[3]}, limitPerService(containers, 1))
+}
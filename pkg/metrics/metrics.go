@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const namespace = "pumba"
+
+// chaos action outcomes
+const (
+	// OutcomeSuccess chaos action completed
+	OutcomeSuccess = "success"
+	// OutcomeFailure chaos action failed
+	OutcomeFailure = "failure"
+)
+
+var (
+	// ChaosActions number of executed chaos actions per command and outcome
+	ChaosActions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chaos_actions_total",
+		Help:      "Number of executed chaos actions, per command and outcome.",
+	}, []string{"command", "outcome"})
+
+	// ChaosActionDuration chaos action duration per command
+	ChaosActionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "chaos_action_duration_seconds",
+		Help:      "Duration of chaos actions, including chaos duration, per command.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600},
+	}, []string{"command"})
+
+	// ContainersAffected number of containers affected by chaos actions
+	ContainersAffected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "containers_affected_total",
+		Help:      "Number of containers affected by chaos actions, per action (kill, stop, pause, remove, netem, iptables, stress).",
+	}, []string{"action"})
+
+	// NetemSessions number of active netem sessions
+	NetemSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "netem_active_sessions",
+		Help:      "Number of containers with active network emulation.",
+	})
+
+	// DockerAPILatency Docker API request latency per method and endpoint
+	DockerAPILatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "docker_api_request_duration_seconds",
+		Help:      "Docker API request latency, per HTTP method and endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "endpoint"})
+
+	// LastTick time of the last chaos command execution
+	LastTick = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_tick_timestamp_seconds",
+		Help:      "Unix time of the last chaos command execution (interval tick).",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(ChaosActions, ChaosActionDuration, ContainersAffected, NetemSessions, DockerAPILatency, LastTick)
+}
+
+// Serve start HTTP server, exposing Prometheus metrics on `/metrics` path, in background
+func Serve(addr string) error {
+	// listen before returning, so bad or busy address is reported immediately
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.WithError(err).WithField("addr", addr).Error("failed to listen for metrics requests")
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		log.WithField("addr", listener.Addr()).Info("serving Prometheus metrics")
+		if err := http.Serve(listener, mux); err != nil {
+			log.WithError(err).Error("failed to serve Prometheus metrics")
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/urfave/cli"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/server"
+)
+
+type serverContext struct {
+	context context.Context
+}
+
+// NewServerCLICommand initialize CLI server command and bind it to the serverContext
+func NewServerCLICommand(ctx context.Context) *cli.Command {
+	cmdContext := &serverContext{context: ctx}
+	return &cli.Command{
+		Name: "server",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "listen, l",
+				Usage: "control API listen address; control API has no authentication: non-loopback address requires --allow-public",
+				Value: server.DefaultListenAddress,
+			},
+			cli.BoolFlag{
+				Name:  "allow-public",
+				Usage: "allow control API to listen on non-loopback address: anyone, who can reach it, can run chaos experiments",
+			},
+		},
+		Usage:       "run HTTP control API server",
+		Description: "start, list and cancel chaos experiments with REST API: 'POST /experiments', 'GET /experiments', 'GET|DELETE /experiments/{id}'; canceled experiments restore target containers",
+		Action:      cmdContext.server,
+	}
+}
+
+// SERVER Command
+func (cmd *serverContext) server(c *cli.Context) error {
+	// get dry-run mode: applied to all experiments
+	dryRun := c.GlobalBool("dry-run")
+	// get listen address
+	addr := c.String("listen")
+	// control API is not exposed by accident
+	if err := server.CheckListenAddress(addr, c.Bool("allow-public")); err != nil {
+		return err
+	}
+	// serve control API until stopped
+	return server.NewServer(cmd.context, chaos.DockerClient, dryRun).ListenAndServe(addr)
+}
@@ -0,0 +1,324 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shinespb/pumba/pkg/chaos"
+	"github.com/shinespb/pumba/pkg/chaos/scenario"
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/shinespb/pumba/pkg/util"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultListenAddress default control API listen address: loopback only
+const DefaultListenAddress = "127.0.0.1:8080"
+
+// ExperimentRequest `POST /experiments` request: chaos command with the same parameters as CLI command
+type ExperimentRequest struct {
+	// chaos command: 'kill', 'netem delay', 'iptables drop', ...
+	Command string `json:"command"`
+	// target containers: list of names or single RE2 regex, prefixed with 're2:'
+	Containers []string `json:"containers"`
+	// target container groups; used by 'partition' command only
+	Groups []string `json:"groups"`
+	// target Swarm services; used by 'service scale' and 'service restart' commands only
+	Services []string `json:"services"`
+	// label selectors
+	Labels []string `json:"labels"`
+	// limit number of target containers
+	Limit int `json:"limit"`
+	// apply limit to every Docker Compose service
+	PerService bool `json:"per-service"`
+	// randomly select single matching container
+	Random bool `json:"random"`
+	// chaos duration; use with optional unit suffix: 'ms/s/m/h'
+	Duration string `json:"duration"`
+	// recurrent interval; experiment runs until canceled
+	Interval string `json:"interval"`
+	// command specific options: same as command line flags; lists can be arrays or comma separated strings
+	Options map[string]interface{} `json:"options"`
+}
+
+// Experiment running chaos command
+type Experiment struct {
+	ID         string    `json:"id"`
+	Command    string    `json:"command"`
+	Containers []string  `json:"containers,omitempty"`
+	Groups     []string  `json:"groups,omitempty"`
+	Services   []string  `json:"services,omitempty"`
+	Labels     []string  `json:"labels,omitempty"`
+	Duration   string    `json:"duration,omitempty"`
+	Interval   string    `json:"interval,omitempty"`
+	Started    time.Time `json:"started"`
+	// remaining time; empty for recurrent experiments and commands without duration
+	Remaining string `json:"remaining,omitempty"`
+
+	// experiment end time; zero if unknown
+	ends   time.Time
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Server HTTP control API: start, list and cancel chaos experiments
+type Server struct {
+	// top context: canceled on server shutdown
+	ctx    context.Context
+	client container.Client
+	dryRun bool
+
+	mu          sync.Mutex
+	seq         int
+	experiments map[string]*Experiment
+	running     sync.WaitGroup
+}
+
+// NewServer create new control API server; all experiments are canceled when ctx is done
+func NewServer(ctx context.Context, client container.Client, dryRun bool) *Server {
+	return &Server{
+		ctx:         ctx,
+		client:      client,
+		dryRun:      dryRun,
+		experiments: map[string]*Experiment{},
+	}
+}
+
+// CheckListenAddress check control API listen address: control API has no authentication,
+// so listening on non-loopback (or any) address must be allowed explicitly
+func CheckListenAddress(addr string, allowPublic bool) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("bad listen address '%s': %v", addr, err)
+	}
+	if allowPublic || host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("control API has no authentication: refusing to listen on non-loopback address '%s' without --allow-public", addr)
+}
+
+// ListenAndServe serve control API on address until ctx is done and wait for all experiments to restore
+func (s *Server) ListenAndServe(addr string) error {
+	srv := &http.Server{Addr: addr, Handler: s.Handler()}
+	go func() {
+		<-s.ctx.Done()
+		log.Debug("shutting down control API server")
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.WithError(err).Error("failed to shut down control API server")
+		}
+	}()
+	log.WithField("addr", addr).Info("serving control API")
+	err := srv.ListenAndServe()
+	// experiment contexts are derived from top context: wait for restore
+	s.running.Wait()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Handler control API HTTP handler
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/experiments", s.handleExperiments)
+	mux.HandleFunc("/experiments/", s.handleExperiment)
+	return mux
+}
+
+// `/experiments`: list or start experiments
+func (s *Server) handleExperiments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.list())
+	case http.MethodPost:
+		var req ExperimentRequest
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("bad request: %v", err))
+			return
+		}
+		e, err := s.start(&req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, e)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// `/experiments/{id}`: get or cancel experiment
+func (s *Server) handleExperiment(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/experiments/")
+	s.mu.Lock()
+	e, ok := s.experiments[id]
+	var view Experiment
+	if ok {
+		view = e.view(time.Now())
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("experiment '%s' not found", id))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, view)
+	case http.MethodDelete:
+		log.WithField("id", id).Info("canceling experiment")
+		e.cancel()
+		// wait for restore: stop netem, unpause, restart, ...
+		select {
+		case <-e.done:
+		case <-r.Context().Done():
+			return
+		}
+		view.Remaining = ""
+		writeJSON(w, http.StatusOK, view)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// create chaos command and run it in background
+func (s *Server) start(req *ExperimentRequest) (*Experiment, error) {
+	interval, err := util.GetIntervalValue(req.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("bad interval: %v", err)
+	}
+	step := &scenario.Step{
+		Command:    req.Command,
+		Containers: req.Containers,
+		Groups:     req.Groups,
+		Services:   req.Services,
+		Labels:     req.Labels,
+		Limit:      req.Limit,
+		PerService: req.PerService,
+		Random:     req.Random,
+		Duration:   req.Duration,
+		Options:    map[string]string{},
+	}
+	for name, value := range req.Options {
+		step.Options[name] = optionValue(value)
+	}
+	cmd, err := scenario.NewStepCommand(s.client, step, s.dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	e := &Experiment{
+		Command:    strings.Join(strings.Fields(req.Command), " "),
+		Containers: req.Containers,
+		Groups:     req.Groups,
+		Services:   req.Services,
+		Labels:     req.Labels,
+		Duration:   req.Duration,
+		Interval:   req.Interval,
+		Started:    now,
+		done:       make(chan struct{}),
+	}
+	// duration is validated by command constructor
+	if duration, err := time.ParseDuration(req.Duration); err == nil && interval == 0 {
+		e.ends = now.Add(duration)
+	}
+	// derive experiment context from top context, like RunChaosCommand does for CLI commands
+	ctx, cancel := context.WithCancel(s.ctx)
+	e.cancel = cancel
+
+	s.mu.Lock()
+	s.seq++
+	e.ID = strconv.Itoa(s.seq)
+	s.experiments[e.ID] = e
+	view := e.view(now)
+	s.mu.Unlock()
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		defer close(e.done)
+		defer cancel()
+		logger := log.WithFields(log.Fields{"id": e.ID, "command": e.Command})
+		logger.Info("starting experiment")
+		if err := chaos.RunChaosCommand(ctx, cmd, req.Interval, req.Random); err != nil {
+			logger.WithError(err).Error("experiment failed")
+		} else {
+			logger.Info("experiment completed")
+		}
+		s.mu.Lock()
+		delete(s.experiments, e.ID)
+		s.mu.Unlock()
+	}()
+	return &view, nil
+}
+
+// list running experiments, ordered by start
+func (s *Server) list() []Experiment {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Experiment, 0, len(s.experiments))
+	for _, e := range s.experiments {
+		list = append(list, e.view(now))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, _ := strconv.Atoi(list[i].ID)
+		b, _ := strconv.Atoi(list[j].ID)
+		return a < b
+	})
+	return list
+}
+
+// experiment copy with remaining time; call with lock held
+func (e *Experiment) view(now time.Time) Experiment {
+	v := *e
+	if !e.ends.IsZero() {
+		remaining := e.ends.Sub(now)
+		if remaining < 0 {
+			remaining = 0
+		}
+		v.Remaining = remaining.Round(time.Second).String()
+	}
+	return v
+}
+
+// convert JSON option value to command line flag value
+func optionValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, optionValue(item))
+		}
+		return strings.Join(items, ",")
+	case float64:
+		// JSON numbers: no exponent format for big numbers (1000000, not 1e+06)
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Error("failed to write response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shinespb/pumba/pkg/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func request(t *testing.T, method, url, body string) (*http.Response, map[string]interface{}) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp, result
+}
+
+func TestServer_StartListCancel(t *testing.T) {
+	mockClient := new(container.MockClient)
+	c := container.CreateTestContainers(1)
+	mockClient.On("ListContainers", mock.Anything, mock.AnythingOfType("container.Filter")).Return(c, nil)
+	mockClient.On("PauseContainer", mock.Anything, c[0], false).Return(nil)
+	mockClient.On("UnpauseContainer", mock.Anything, c[0], false).Return(nil)
+
+	s := NewServer(context.TODO(), mockClient, false)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	// start
+	resp, e := request(t, http.MethodPost, ts.URL+"/experiments", `{"command": "pause", "containers": ["c1"], "duration": "1h"}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "1", e["id"])
+	assert.Equal(t, "pause", e["command"])
+	assert.Equal(t, "1h0m0s", e["remaining"])
+
+	// list
+	resp, err := http.Get(ts.URL + "/experiments")
+	assert.NoError(t, err)
+	var list []Experiment
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	resp.Body.Close()
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "1", list[0].ID)
+		assert.Equal(t, []string{"c1"}, list[0].Containers)
+	}
+
+	// cancel: waits for unpause
+	resp, _ = request(t, http.MethodDelete, ts.URL+"/experiments/1", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mockClient.AssertCalled(t, "UnpauseContainer", mock.Anything, c[0], false)
+
+	// canceled experiment is removed
+	resp, _ = request(t, http.MethodDelete, ts.URL+"/experiments/1", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServer_StartServiceScale(t *testing.T) {
+	mockClient := new(container.MockClient)
+	mockClient.On("ServiceReplicas", mock.Anything, "web").Return(uint64(3), nil)
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(1), false).Return(uint64(3), nil)
+	mockClient.On("ScaleService", mock.Anything, "web", uint64(3), false).Return(uint64(1), nil)
+
+	s := NewServer(context.TODO(), mockClient, false)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, e := request(t, http.MethodPost, ts.URL+"/experiments", `{"command": "service scale", "services": ["web"], "duration": "1h", "options": {"replicas": 1}}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "service scale", e["command"])
+	assert.Equal(t, []interface{}{"web"}, e["services"])
+
+	// cancel: waits for service replicas to be restored
+	resp, _ = request(t, http.MethodDelete, ts.URL+"/experiments/1", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mockClient.AssertExpectations(t)
+}
+
+func TestServer_StartErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		err  string
+	}{
+		{
+			name: "bad json",
+			body: `{"command":`,
+			err:  "bad request",
+		},
+		{
+			name: "unknown field",
+			body: `{"command": "kill", "target": ["c1"]}`,
+			err:  "unknown field",
+		},
+		{
+			name: "no command",
+			body: `{"containers": ["c1"]}`,
+			err:  "undefined command",
+		},
+		{
+			name: "unknown command",
+			body: `{"command": "explode"}`,
+			err:  "unknown command 'explode'",
+		},
+		{
+			name: "bad option",
+			body: `{"command": "netem delay", "duration": "10s", "options": {"time": "long"}}`,
+			err:  "bad option 'time'",
+		},
+		{
+			name: "service without services",
+			body: `{"command": "service scale", "duration": "10s"}`,
+			err:  "undefined Swarm service",
+		},
+		{
+			name: "bad interval",
+			body: `{"command": "kill", "interval": "often"}`,
+			err:  "bad interval",
+		},
+	}
+	s := NewServer(context.TODO(), nil, true)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, result := request(t, http.MethodPost, ts.URL+"/experiments", tt.body)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Contains(t, result["error"], tt.err)
+		})
+	}
+}
+
+func TestOptionValue(t *testing.T) {
+	var options map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"time": 300, "percent": 0.5, "pull-image": false, "target": ["10.0.0.1", "10.0.0.2"], "rate": "1mbit", "limit": 1000000, "ports": [8080, 10000000]}`), &options))
+	assert.Equal(t, "300", optionValue(options["time"]))
+	assert.Equal(t, "0.5", optionValue(options["percent"]))
+	assert.Equal(t, "false", optionValue(options["pull-image"]))
+	assert.Equal(t, "10.0.0.1,10.0.0.2", optionValue(options["target"]))
+	assert.Equal(t, "1mbit", optionValue(options["rate"]))
+	assert.Equal(t, "1000000", optionValue(options["limit"]))
+	assert.Equal(t, "8080,10000000", optionValue(options["ports"]))
+}
+
+func TestCheckListenAddress(t *testing.T) {
+	tests := []struct {
+		addr        string
+		allowPublic bool
+		wantErr     bool
+	}{
+		{addr: DefaultListenAddress},
+		{addr: "localhost:8080"},
+		{addr: "[::1]:8080"},
+		{addr: ":8080", wantErr: true},
+		{addr: "0.0.0.0:8080", wantErr: true},
+		{addr: "10.0.0.5:8080", wantErr: true},
+		{addr: ":8080", allowPublic: true},
+		{addr: "8080", allowPublic: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			err := CheckListenAddress(tt.addr, tt.allowPublic)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -56,9 +56,12 @@ func GetDurationValue(durationStr string, interval time.Duration) (time.Duration
 	return duration, nil
 }
 
-// CIDRNotation Ensure IP string is in CIDR notation
+// CIDRNotation Ensure IP string is in CIDR notation: single IPv4 address is /32 and single IPv6 address is /128
 func CIDRNotation(ip string) string {
 	if !strings.Contains(ip, "/") {
+		if strings.Contains(ip, ":") {
+			return ip + "/128"
+		}
 		return ip + "/32"
 	}
 	return ip